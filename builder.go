@@ -0,0 +1,317 @@
+package iavl
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"io"
+
+	"github.com/pkg/errors"
+
+	dbm "github.com/tendermint/tm-db"
+
+	"github.com/tendermint/iavl/internal/encoding"
+)
+
+// kvStreamMaxSize is the maximum size of a key or value in a key/value stream, to avoid
+// allocating excessive memory for corrupt lengths.
+const kvStreamMaxSize = 256 << 20
+
+// Builder builds a balanced tree from key/value pairs in ascending key order, which is much
+// faster than setting them one by one. It is created by MutableTree.Build(), and callers must
+// call Close() when done.
+//
+// Nodes are written to the database in batches as pairs are added, like Importer.Add(). The pairs
+// are built into perfect subtrees as they arrive, and only the inner nodes on the right spine of
+// each of these subtrees are kept in memory until Commit() joins them into a balanced tree, so
+// memory usage is logarithmic in the number of pairs. Builds can't be resumed: if a build is
+// aborted, the database must be discarded.
+//
+// Builder is not concurrency-safe, it is the caller's responsibility to ensure the tree is not
+// modified while building.
+type Builder struct {
+	tree      *MutableTree
+	version   int64
+	batch     dbm.Batch
+	batchSize uint32
+	stack     []builderSubtree // perfect subtrees of strictly decreasing height
+	lastKey   []byte
+}
+
+// builderSubtree is a perfect subtree on the stack of a Builder, along with its leftmost key. Its
+// root and the inner nodes on its right spine have not been written yet, and have child pointers.
+type builderSubtree struct {
+	node     *Node
+	leftmost []byte
+}
+
+// Build returns a builder for a new version of an empty tree. All nodes are given the version,
+// which must be greater than 0.
+func (tree *MutableTree) Build(version int64) (*Builder, error) {
+	if version <= 0 {
+		return nil, errors.New("version must be greater than 0")
+	}
+	if _, err := validateImport(tree, version, false); err != nil {
+		return nil, err
+	}
+	pending, err := tree.ndb.getImportCheckpointVersions()
+	if err != nil {
+		return nil, err
+	}
+	if len(pending) > 0 {
+		return nil, errors.Errorf("found aborted import of version %v, it must be resumed or cleaned up",
+			pending[0])
+	}
+	return &Builder{
+		tree:    tree,
+		version: version,
+		batch:   tree.ndb.snapshotDB.NewBatch(),
+	}, nil
+}
+
+// Add adds a key/value pair. Keys must be strictly increasing, and values must not be nil. The
+// key and value are not retained, and may be modified once Add() returns.
+func (b *Builder) Add(key, value []byte) error {
+	if b.tree == nil {
+		return ErrNoImport
+	}
+	if key == nil {
+		return errors.New("key cannot be nil")
+	}
+	if value == nil {
+		return errors.Errorf("value for key %X cannot be nil", key)
+	}
+	if b.lastKey != nil && bytes.Compare(key, b.lastKey) <= 0 {
+		return errors.Errorf("key %X must be greater than the previous key %X", key, b.lastKey)
+	}
+
+	key = append([]byte{}, key...)
+	node := &Node{
+		key:     key,
+		value:   value,
+		version: b.version,
+		size:    1,
+	}
+	node._hash()
+	if err := b.writeNode(node); err != nil {
+		return err
+	}
+	node.value = nil
+	b.lastKey = key
+
+	// Subtrees of equal height are joined like a binary counter. The left subtree is then no
+	// longer on the right spine, so its pending nodes are written.
+	b.stack = append(b.stack, builderSubtree{node: node, leftmost: key})
+	for n := len(b.stack); n >= 2 && b.stack[n-2].node.height == b.stack[n-1].node.height; n-- {
+		left, right := b.stack[n-2], b.stack[n-1]
+		if err := b.writePending(left.node); err != nil {
+			return err
+		}
+		b.stack[n-2] = builderSubtree{node: b.newInner(left.node, right.leftmost, right.node), leftmost: left.leftmost}
+		b.stack = b.stack[:n-1]
+	}
+	return nil
+}
+
+// AddIterator adds all remaining pairs of the iterator, which must be ascending. It does not
+// close the iterator.
+func (b *Builder) AddIterator(itr dbm.Iterator) error {
+	for ; itr.Valid(); itr.Next() {
+		if err := b.Add(itr.Key(), itr.Value()); err != nil {
+			return err
+		}
+	}
+	return itr.Error()
+}
+
+// AddKVStream adds all pairs read from a key/value stream written by WriteKVStream(), until the
+// end of the stream.
+func (b *Builder) AddKVStream(r io.Reader) error {
+	br := bufio.NewReader(r)
+	for count := 0; ; count++ {
+		key, err := readKVStreamBytes(br)
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return errors.Wrapf(err, "reading key %v", count)
+		}
+		value, err := readKVStreamBytes(br)
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		if err != nil {
+			return errors.Wrapf(err, "reading value %v", count)
+		}
+		if err = b.Add(key, value); err != nil {
+			return err
+		}
+	}
+}
+
+// WriteKVStream writes all remaining pairs of the iterator to w as a key/value stream, which can
+// be added to a Builder with AddKVStream(). The stream is a sequence of keys and values, each
+// prefixed by its length as an unsigned varint. It does not close the iterator.
+func WriteKVStream(w io.Writer, itr dbm.Iterator) error {
+	bw := bufio.NewWriter(w)
+	for ; itr.Valid(); itr.Next() {
+		if err := encoding.EncodeByteSlice(bw, itr.Key()); err != nil {
+			return err
+		}
+		if err := encoding.EncodeByteSlice(bw, itr.Value()); err != nil {
+			return err
+		}
+	}
+	if err := itr.Error(); err != nil {
+		return err
+	}
+	return bw.Flush()
+}
+
+// readKVStreamBytes reads a length-prefixed byte slice of a key/value stream. It returns io.EOF
+// only if the stream ended before the slice.
+func readKVStreamBytes(r *bufio.Reader) ([]byte, error) {
+	size, err := binary.ReadUvarint(r)
+	if err != nil {
+		return nil, err
+	}
+	if size > kvStreamMaxSize {
+		return nil, errors.Errorf("size %v too large", size)
+	}
+	bz := make([]byte, size)
+	if _, err := io.ReadFull(r, bz); err != nil {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return nil, err
+	}
+	return bz, nil
+}
+
+// newInner returns a hashed inner node with the given children, which it keeps pointers to.
+func (b *Builder) newInner(left *Node, key []byte, right *Node) *Node {
+	node := &Node{
+		key:       key,
+		version:   b.version,
+		height:    maxInt8(left.height, right.height) + 1,
+		size:      left.size + right.size,
+		leftHash:  left.hash,
+		leftNode:  left,
+		rightHash: right.hash,
+		rightNode: right,
+	}
+	node._hash()
+	return node
+}
+
+// joinRight joins the left and right trees with the given key between them, where the left tree
+// is perfect and at least two levels higher than the right one. It descends the right spine of the
+// left tree to the subtree one level higher than the right tree, and rebalances the new nodes on
+// the way up with left rotations like an AVL tree join.
+func (b *Builder) joinRight(left *Node, key []byte, right *Node) *Node {
+	l, c := left.leftNode, left.rightNode
+	if c.height == right.height+1 {
+		return b.newInner(l, left.key, b.newInner(c, key, right))
+	}
+	node := b.joinRight(c, key, right)
+	if node.height <= l.height+1 {
+		return b.newInner(l, left.key, node)
+	}
+	return b.rotateLeft(b.newInner(l, left.key, node))
+}
+
+// rotateLeft rotates a pending node to the left, replacing it and its right child.
+func (b *Builder) rotateLeft(node *Node) *Node {
+	right := node.rightNode
+	return b.newInner(b.newInner(node.leftNode, node.key, right.leftNode), right.key, right.rightNode)
+}
+
+// writePending writes the node and its descendants which have not been written yet, in post-order,
+// and drops their child pointers.
+func (b *Builder) writePending(node *Node) error {
+	if node.saved {
+		return nil
+	}
+	if err := b.writePending(node.leftNode); err != nil {
+		return err
+	}
+	if err := b.writePending(node.rightNode); err != nil {
+		return err
+	}
+	if err := b.writeNode(node); err != nil {
+		return err
+	}
+	node.leftNode = nil
+	node.rightNode = nil
+	return nil
+}
+
+// writeNode adds the node to the batch, flushing it to the database if it is full.
+func (b *Builder) writeNode(node *Node) error {
+	var buf bytes.Buffer
+	if err := node.writeBytes(&buf); err != nil {
+		return err
+	}
+	b.batch.Set(b.tree.ndb.nodeKey(node.hash), buf.Bytes())
+	b.batchSize++
+	node.saved = true
+	if b.batchSize >= maxBatchSize {
+		if err := b.batch.Write(); err != nil {
+			return err
+		}
+		b.batch.Close()
+		b.batch = b.tree.ndb.snapshotDB.NewBatch()
+		b.batchSize = 0
+	}
+	return nil
+}
+
+// Commit joins the subtrees into a balanced tree, writes the remaining inner nodes, and makes the
+// version visible. It can only be called once, and calls Close() internally. An empty build
+// creates an empty version.
+func (b *Builder) Commit() error {
+	if b.tree == nil {
+		return ErrNoImport
+	}
+
+	// The subtrees are joined from the right. Since each subtree has more leaves than all those
+	// to its right, the joined right tree is never higher than it.
+	rootHash := []byte{}
+	if n := len(b.stack); n > 0 {
+		root := b.stack[n-1]
+		for i := n - 2; i >= 0; i-- {
+			left := b.stack[i].node
+			if left.height > root.node.height+1 {
+				root.node = b.joinRight(left, root.leftmost, root.node)
+			} else {
+				root.node = b.newInner(left, root.leftmost, root.node)
+			}
+			root.leftmost = b.stack[i].leftmost
+		}
+		if err := b.writePending(root.node); err != nil {
+			return err
+		}
+		rootHash = root.node.hash
+	}
+	b.batch.Set(b.tree.ndb.rootKey(b.version), rootHash)
+	if err := b.batch.WriteSync(); err != nil {
+		return err
+	}
+
+	tree := b.tree
+	b.Close()
+	tree.ndb.resetLatestVersion(b.version)
+	_, err := tree.LoadVersion(b.version)
+	return err
+}
+
+// Close frees all resources. It is safe to call multiple times. Leaf nodes may already have been
+// written to the database, but the version is not visible unless Commit() succeeded.
+func (b *Builder) Close() {
+	if b.batch != nil {
+		b.batch.Close()
+	}
+	b.batch = nil
+	b.tree = nil
+	b.stack = nil
+}
@@ -0,0 +1,145 @@
+package iavl
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	db "github.com/tendermint/tm-db"
+)
+
+// setupBuilderSource returns a database with the given number of key/value pairs.
+func setupBuilderSource(t *testing.T, size int) db.DB {
+	source := db.NewMemDB()
+	for i := 0; i < size; i++ {
+		require.NoError(t, source.Set([]byte(fmt.Sprintf("key%08d", i*7)), []byte(fmt.Sprintf("value%v", i))))
+	}
+	return source
+}
+
+func TestBuilder(t *testing.T) {
+	for _, size := range []int{0, 1, 2, 3, 5, 6, 11, 33, 100, 129, maxBatchSize + 7} {
+		size := size
+		t.Run(fmt.Sprintf("%v pairs", size), func(t *testing.T) {
+			source := setupBuilderSource(t, size)
+
+			memDB := db.NewMemDB()
+			tree, err := NewMutableTree(memDB, 0)
+			require.NoError(t, err)
+			builder, err := tree.Build(3)
+			require.NoError(t, err)
+			defer builder.Close()
+			itr, err := source.Iterator(nil, nil)
+			require.NoError(t, err)
+			require.NoError(t, builder.AddIterator(itr))
+			itr.Close()
+			require.NoError(t, builder.Commit())
+			require.EqualValues(t, 3, tree.Version())
+			require.EqualValues(t, size, tree.Size())
+
+			// The tree is balanced, and only its nodes are written.
+			if size > 0 {
+				requireBalanced(t, tree.ImmutableTree, tree.root)
+				require.EqualValues(t, math.Ceil(math.Log2(float64(size))), tree.Height())
+				require.EqualValues(t, 2*size-1, countKeys(memDB, nodeKeyFormat.Key()))
+			}
+
+			// The version can be loaded, and changes saved on top of it.
+			loaded, err := NewMutableTree(memDB, 0)
+			require.NoError(t, err)
+			version, err := loaded.Load()
+			require.NoError(t, err)
+			require.EqualValues(t, 3, version)
+			require.Equal(t, tree.Hash(), loaded.Hash())
+			itr, err = source.Iterator(nil, nil)
+			require.NoError(t, err)
+			for ; itr.Valid(); itr.Next() {
+				_, value := loaded.Get(itr.Key())
+				require.Equal(t, itr.Value(), value, "key %s", itr.Key())
+			}
+			itr.Close()
+			loaded.Set([]byte("new"), []byte("value"))
+			_, version, err = loaded.SaveVersion()
+			require.NoError(t, err)
+			require.EqualValues(t, 4, version)
+		})
+	}
+}
+
+// requireBalanced checks that the subtree is AVL balanced, and that the key of each inner node is
+// the leftmost key of its right subtree. It returns the leftmost key of the subtree.
+func requireBalanced(t *testing.T, tree *ImmutableTree, node *Node) []byte {
+	if node.isLeaf() {
+		return node.key
+	}
+	left, right := node.getLeftNode(tree), node.getRightNode(tree)
+	require.LessOrEqual(t, int(left.height-right.height), 1)
+	require.LessOrEqual(t, int(right.height-left.height), 1)
+	require.EqualValues(t, maxInt8(left.height, right.height)+1, node.height)
+	require.Equal(t, requireBalanced(t, tree, right), node.key)
+	return requireBalanced(t, tree, left)
+}
+
+func TestBuilder_KVStream(t *testing.T) {
+	source := setupBuilderSource(t, 500)
+	itr, err := source.Iterator(nil, nil)
+	require.NoError(t, err)
+	var buf bytes.Buffer
+	require.NoError(t, WriteKVStream(&buf, itr))
+	itr.Close()
+
+	build := func(bz []byte) (*MutableTree, error) {
+		tree, err := NewMutableTree(db.NewMemDB(), 0)
+		require.NoError(t, err)
+		builder, err := tree.Build(1)
+		require.NoError(t, err)
+		defer builder.Close()
+		if err := builder.AddKVStream(bytes.NewReader(bz)); err != nil {
+			return nil, err
+		}
+		return tree, builder.Commit()
+	}
+
+	tree, err := build(buf.Bytes())
+	require.NoError(t, err)
+	require.EqualValues(t, 500, tree.Size())
+	itr, err = source.Iterator(nil, nil)
+	require.NoError(t, err)
+	defer itr.Close()
+	for ; itr.Valid(); itr.Next() {
+		_, value := tree.Get(itr.Key())
+		require.Equal(t, itr.Value(), value)
+	}
+
+	for _, size := range []int{1, buf.Len() / 2, buf.Len() - 1} {
+		_, err = build(buf.Bytes()[:size])
+		require.Error(t, err, "truncated to %v bytes", size)
+	}
+}
+
+func TestBuilder_Invalid(t *testing.T) {
+	nonEmpty := setupDeltaTree(t, 1)
+	_, err := nonEmpty.Build(2)
+	require.Error(t, err)
+
+	tree, err := NewMutableTree(db.NewMemDB(), 0)
+	require.NoError(t, err)
+	_, err = tree.Build(0)
+	require.Error(t, err)
+
+	builder, err := tree.Build(1)
+	require.NoError(t, err)
+	defer builder.Close()
+	require.NoError(t, builder.Add([]byte("b"), []byte{1}))
+	require.Error(t, builder.Add([]byte("b"), []byte{2}), "duplicate key")
+	require.Error(t, builder.Add([]byte("a"), []byte{3}), "unsorted key")
+	require.Error(t, builder.Add([]byte("c"), nil), "nil value")
+	require.Error(t, builder.Add(nil, []byte{4}), "nil key")
+	require.NoError(t, builder.Add([]byte("c"), []byte{5}))
+	require.NoError(t, builder.Commit())
+	require.EqualValues(t, 2, tree.Size())
+	require.Error(t, builder.Add([]byte("d"), []byte{6}))
+}
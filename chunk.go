@@ -0,0 +1,536 @@
+package iavl
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/pkg/errors"
+
+	"github.com/tendermint/iavl/internal/encoding"
+)
+
+// ErrInvalidChunk is returned when an export chunk is malformed or does not verify against the
+// trusted root hash.
+var ErrInvalidChunk = errors.New("invalid chunk")
+
+// ChunkBoundary is a node outside of an ExportChunk which is needed to verify it. Subtrees are
+// given by their root Hash, Height and Size, and subtrees in the left boundary also by the Key of
+// their leftmost leaf, which the keys of inner nodes are checked against. Inner nodes above the
+// chunk, which are exported in later chunks, have no Hash and are given by their Height and
+// Version; their hash is computed from their children while verifying.
+type ChunkBoundary struct {
+	Hash    []byte
+	Key     []byte
+	Height  int8
+	Size    int64
+	Version int64
+}
+
+// ExportChunk is a part of a chunked export, created by ChunkExporter. It contains a
+// contiguous run of nodes in the export order, i.e. depth-first post-order, along with the
+// boundary nodes needed to verify it against the tree's root hash on its own.
+//
+// Left contains the subtrees exported by previous chunks which have not yet been attached to a
+// parent, bottom first. Right contains the rest of the export after the chunk in post-order,
+// with any complete subtrees replaced by their root hash. It is empty for the final chunk.
+type ExportChunk struct {
+	Index int
+	Nodes []*ExportNode
+	Left  []ChunkBoundary
+	Right []ChunkBoundary
+}
+
+// Verify verifies that the chunk's nodes are part of the tree with the given root hash, in
+// the position given by the chunk boundaries. The keys of inner nodes, which are not covered by
+// the hash, must be the leftmost keys of their right subtrees; for subtrees in the left boundary,
+// these are taken from the boundary, so they must be checked against earlier chunks as done by
+// ChunkImporter. It does not verify the chunk index.
+func (c *ExportChunk) Verify(rootHash []byte) error {
+	stack := make([]chunkSubtree, 0, len(c.Left)+len(c.Nodes))
+	for _, left := range c.Left {
+		if len(left.Hash) == 0 {
+			return errors.Wrap(ErrInvalidChunk, "left boundary must be a subtree")
+		}
+		stack = append(stack, chunkSubtree{
+			node:     &Node{hash: left.Hash, height: left.Height, size: left.Size},
+			leftmost: left.Key,
+		})
+	}
+
+	var err error
+	for _, exportNode := range c.Nodes {
+		if exportNode == nil {
+			return errors.Wrap(ErrInvalidChunk, "node cannot be nil")
+		}
+		node := &Node{
+			key:     exportNode.Key,
+			value:   exportNode.Value,
+			version: exportNode.Version,
+			height:  exportNode.Height,
+		}
+		if stack, err = attachChunkNode(stack, node, true); err != nil {
+			return err
+		}
+		if err = node.validate(); err != nil {
+			return errors.Wrap(ErrInvalidChunk, err.Error())
+		}
+		node._hash()
+	}
+
+	for _, right := range c.Right {
+		if len(right.Hash) > 0 {
+			stack = append(stack, chunkSubtree{node: &Node{hash: right.Hash, height: right.Height, size: right.Size}})
+			continue
+		}
+		if right.Version <= 0 {
+			return errors.Wrap(ErrInvalidChunk, "version must be greater than 0")
+		}
+		node := &Node{version: right.Version, height: right.Height}
+		if stack, err = attachChunkNode(stack, node, false); err != nil {
+			return err
+		}
+		node._hash()
+	}
+
+	var hash []byte
+	switch len(stack) {
+	case 0:
+	case 1:
+		hash = stack[0].node.hash
+	default:
+		return errors.Wrapf(ErrInvalidChunk, "nodes do not form a tree, found %v roots", len(stack))
+	}
+	if !bytes.Equal(hash, rootHash) {
+		return errors.Wrapf(ErrInvalidChunk, "computed root hash %X does not match root hash %X", hash, rootHash)
+	}
+	return nil
+}
+
+// chunkSubtree is a subtree on the stack of ExportChunk.Verify(), along with its leftmost key.
+// The leftmost key is unknown for subtrees in the right boundary.
+type chunkSubtree struct {
+	node     *Node
+	leftmost []byte
+}
+
+// attachChunkNode attaches an inner node to its two children on top of the stack, and pushes
+// the node onto the stack. Leaf nodes are pushed as is. If checkKey is set, the key of an inner
+// node must be the leftmost key of its right child. The node hash is not computed.
+func attachChunkNode(stack []chunkSubtree, node *Node, checkKey bool) ([]chunkSubtree, error) {
+	if node.height == 0 {
+		node.size = 1
+		return append(stack, chunkSubtree{node: node, leftmost: node.key}), nil
+	}
+	if node.height < 0 {
+		return nil, errors.Wrap(ErrInvalidChunk, "height cannot be less than 0")
+	}
+	size := len(stack)
+	if size < 2 || stack[size-2].node.height >= node.height || stack[size-1].node.height >= node.height {
+		return nil, errors.Wrapf(ErrInvalidChunk, "missing children for inner node at height %v", node.height)
+	}
+	left, right := stack[size-2], stack[size-1]
+	if checkKey && !bytes.Equal(node.key, right.leftmost) {
+		return nil, errors.Wrapf(ErrInvalidChunk, "inner node key %X does not match leftmost key %X of its right subtree",
+			node.key, right.leftmost)
+	}
+	node.leftHash = left.node.hash
+	node.rightHash = right.node.hash
+	node.size = left.node.size + right.node.size
+	return append(stack[:size-2], chunkSubtree{node: node, leftmost: left.leftmost}), nil
+}
+
+// MarshalBinary encodes the chunk. Nodes are encoded like in the snapshot format.
+func (c *ExportChunk) MarshalBinary() ([]byte, error) {
+	var buf, nodeBuf bytes.Buffer
+	if c.Index < 0 {
+		return nil, errors.New("chunk index cannot be negative")
+	}
+	if err := encoding.EncodeUvarint(&buf, uint64(c.Index)); err != nil {
+		return nil, err
+	}
+	if err := encodeChunkBoundaries(&buf, c.Left); err != nil {
+		return nil, errors.Wrap(err, "encoding left boundary")
+	}
+	if err := encoding.EncodeUvarint(&buf, uint64(len(c.Nodes))); err != nil {
+		return nil, err
+	}
+	for _, node := range c.Nodes {
+		nodeBuf.Reset()
+		if err := encodeSnapshotNode(&nodeBuf, node); err != nil {
+			return nil, errors.Wrap(err, "encoding node")
+		}
+		if err := encoding.EncodeByteSlice(&buf, nodeBuf.Bytes()); err != nil {
+			return nil, err
+		}
+	}
+	if err := encodeChunkBoundaries(&buf, c.Right); err != nil {
+		return nil, errors.Wrap(err, "encoding right boundary")
+	}
+	return buf.Bytes(), nil
+}
+
+// UnmarshalBinary decodes a chunk encoded by MarshalBinary(). Decoding errors wrap
+// ErrInvalidChunk.
+func (c *ExportChunk) UnmarshalBinary(bz []byte) error {
+	index, n, err := encoding.DecodeUvarint(bz)
+	if err != nil {
+		return errors.Wrapf(ErrInvalidChunk, "decoding index: %v", err)
+	}
+	bz = bz[n:]
+	left, n, err := decodeChunkBoundaries(bz)
+	if err != nil {
+		return errors.Wrapf(ErrInvalidChunk, "decoding left boundary: %v", err)
+	}
+	bz = bz[n:]
+	count, n, err := encoding.DecodeUvarint(bz)
+	if err != nil {
+		return errors.Wrapf(ErrInvalidChunk, "decoding node count: %v", err)
+	}
+	bz = bz[n:]
+	// Each node takes at least one byte, so a larger count is corrupt.
+	if count > uint64(len(bz)) {
+		return errors.Wrapf(ErrInvalidChunk, "node count %v exceeds chunk size", count)
+	}
+	nodes := make([]*ExportNode, 0, count)
+	for i := uint64(0); i < count; i++ {
+		nodeBz, n, err := encoding.DecodeByteSlice(bz)
+		if err != nil {
+			return errors.Wrapf(ErrInvalidChunk, "decoding node %v: %v", i, err)
+		}
+		bz = bz[n:]
+		node, err := decodeSnapshotNode(nodeBz)
+		if err != nil {
+			return errors.Wrapf(ErrInvalidChunk, "decoding node %v: %v", i, err)
+		}
+		nodes = append(nodes, node)
+	}
+	right, n, err := decodeChunkBoundaries(bz)
+	if err != nil {
+		return errors.Wrapf(ErrInvalidChunk, "decoding right boundary: %v", err)
+	}
+	bz = bz[n:]
+	if len(bz) > 0 {
+		return errors.Wrapf(ErrInvalidChunk, "%v trailing bytes", len(bz))
+	}
+
+	*c = ExportChunk{Index: int(index), Nodes: nodes, Left: left, Right: right}
+	return nil
+}
+
+func encodeChunkBoundaries(buf *bytes.Buffer, boundaries []ChunkBoundary) error {
+	if err := encoding.EncodeUvarint(buf, uint64(len(boundaries))); err != nil {
+		return err
+	}
+	for _, b := range boundaries {
+		if err := encoding.EncodeByteSlice(buf, b.Hash); err != nil {
+			return err
+		}
+		if err := encoding.EncodeByteSlice(buf, b.Key); err != nil {
+			return err
+		}
+		if err := encoding.EncodeVarint(buf, int64(b.Height)); err != nil {
+			return err
+		}
+		if err := encoding.EncodeVarint(buf, b.Size); err != nil {
+			return err
+		}
+		if err := encoding.EncodeVarint(buf, b.Version); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func decodeChunkBoundaries(bz []byte) ([]ChunkBoundary, int, error) {
+	count, n, err := encoding.DecodeUvarint(bz)
+	if err != nil {
+		return nil, 0, err
+	}
+	read := n
+	// Each boundary takes at least five bytes, so a larger count is corrupt.
+	if count > uint64(len(bz)-read)/5 {
+		return nil, 0, errors.Errorf("boundary count %v exceeds chunk size", count)
+	}
+	boundaries := make([]ChunkBoundary, 0, count)
+	for i := uint64(0); i < count; i++ {
+		var b ChunkBoundary
+		if b.Hash, n, err = encoding.DecodeByteSlice(bz[read:]); err != nil {
+			return nil, 0, err
+		}
+		read += n
+		if b.Key, n, err = encoding.DecodeByteSlice(bz[read:]); err != nil {
+			return nil, 0, err
+		}
+		read += n
+		if b.Height, n, err = encoding.DecodeInt8(bz[read:]); err != nil {
+			return nil, 0, err
+		}
+		read += n
+		if b.Size, n, err = encoding.DecodeVarint(bz[read:]); err != nil {
+			return nil, 0, err
+		}
+		read += n
+		if b.Version, n, err = encoding.DecodeVarint(bz[read:]); err != nil {
+			return nil, 0, err
+		}
+		read += n
+		boundaries = append(boundaries, b)
+	}
+	return boundaries, read, nil
+}
+
+// chunkFrame is a node on the path of a ChunkExporter traversal. state is 0 before traversing
+// the node's children, 1 while traversing the left child and 2 while traversing the right child.
+type chunkFrame struct {
+	node  *Node
+	state int
+}
+
+// ChunkExporter exports an ImmutableTree as a sequence of ExportChunks, each of which can be
+// verified against the root hash on its own. It is created by ImmutableTree.ExportChunks(), and
+// callers must call Close() when done.
+//
+// The chunks can be imported with MutableTree.ImportChunks() to recreate an identical tree.
+type ChunkExporter struct {
+	tree      *ImmutableTree
+	chunkSize int
+	index     int
+	path      []*chunkFrame   // the ancestors of the next node to export, root first
+	stack     []ChunkBoundary // exported subtrees which have not been attached to a parent
+	done      bool
+}
+
+// ExportChunks returns an exporter which exports the tree in chunks of up to chunkSize nodes.
+// An empty tree is exported as a single empty chunk.
+func (t *ImmutableTree) ExportChunks(chunkSize int) (*ChunkExporter, error) {
+	if chunkSize < 1 {
+		return nil, errors.Errorf("chunk size must be at least 1, got %v", chunkSize)
+	}
+	exporter := &ChunkExporter{
+		tree:      t,
+		chunkSize: chunkSize,
+	}
+	if t.root != nil {
+		exporter.path = []*chunkFrame{{node: t.root}}
+	}
+	t.ndb.incrVersionReaders(t.version)
+	return exporter, nil
+}
+
+// Next returns the next chunk, or ExportDone when all chunks have been exported.
+func (e *ChunkExporter) Next() (*ExportChunk, error) {
+	if e.tree == nil {
+		return nil, errors.New("chunk exporter is closed")
+	}
+	if e.done {
+		return nil, ExportDone
+	}
+
+	chunk := &ExportChunk{
+		Index: e.index,
+		Nodes: make([]*ExportNode, 0, e.chunkSize),
+		Left:  append([]ChunkBoundary(nil), e.stack...),
+	}
+	for len(chunk.Nodes) < e.chunkSize && len(e.path) > 0 {
+		node := e.nextNode()
+		chunk.Nodes = append(chunk.Nodes, newExportNode(node))
+		key := node.key
+		if node.height > 0 {
+			key = e.stack[len(e.stack)-2].Key
+			e.stack = e.stack[:len(e.stack)-2]
+		}
+		e.stack = append(e.stack, ChunkBoundary{Hash: node._hash(), Key: key, Height: node.height, Size: node.size})
+	}
+	chunk.Right = e.rightBoundary()
+
+	e.index++
+	e.done = len(e.path) == 0
+	return chunk, nil
+}
+
+// nextNode returns the next node in post-order, and removes it from the path.
+func (e *ChunkExporter) nextNode() *Node {
+	for {
+		frame := e.path[len(e.path)-1]
+		switch {
+		case frame.node.isLeaf() || frame.state == 2:
+			e.path = e.path[:len(e.path)-1]
+			return frame.node
+		case frame.state == 0:
+			frame.state = 1
+			e.path = append(e.path, &chunkFrame{node: frame.node.getLeftNode(e.tree)})
+		default:
+			frame.state = 2
+			e.path = append(e.path, &chunkFrame{node: frame.node.getRightNode(e.tree)})
+		}
+	}
+}
+
+// rightBoundary returns the rest of the export in post-order, with the right subtrees of the
+// remaining path replaced by their root hash.
+func (e *ChunkExporter) rightBoundary() []ChunkBoundary {
+	boundary := make([]ChunkBoundary, 0, 2*len(e.path))
+	for i := len(e.path) - 1; i >= 0; i-- {
+		node := e.path[i].node
+		if e.path[i].state < 2 {
+			right := node.getRightNode(e.tree)
+			boundary = append(boundary, ChunkBoundary{Hash: right._hash(), Height: right.height, Size: right.size})
+		}
+		boundary = append(boundary, ChunkBoundary{Height: node.height, Version: node.version})
+	}
+	return boundary
+}
+
+// Close releases the tree version. It is safe to call multiple times.
+func (e *ChunkExporter) Close() {
+	if e.tree != nil {
+		e.tree.ndb.decrVersionReaders(e.tree.version)
+	}
+	e.tree = nil
+	e.path = nil
+}
+
+// ChunkError is returned by ChunkImporter when a chunk is rejected, and identifies the chunk.
+type ChunkError struct {
+	Index int
+	Err   error
+}
+
+func (e *ChunkError) Error() string {
+	return fmt.Sprintf("chunk %v: %v", e.Index, e.Err)
+}
+
+// Cause returns the underlying error, for use with errors.Cause().
+func (e *ChunkError) Cause() error {
+	return e.Err
+}
+
+// Unwrap returns the underlying error.
+func (e *ChunkError) Unwrap() error {
+	return e.Err
+}
+
+// ChunkImporter imports ExportChunks into an empty MutableTree, verifying each chunk against a
+// trusted root hash before its nodes are imported. It is created by MutableTree.ImportChunks(),
+// and callers must call Close() when done.
+type ChunkImporter struct {
+	importer *Importer
+	rootHash []byte
+	next     int
+	done     bool
+}
+
+// ImportChunks returns an importer for chunks exported by ChunkExporter from the tree with the
+// given version and root hash. See MutableTree.Import() for the requirements on the tree.
+func (tree *MutableTree) ImportChunks(version int64, rootHash []byte) (*ChunkImporter, error) {
+	importer, err := tree.Import(version)
+	if err != nil {
+		return nil, err
+	}
+	return &ChunkImporter{importer: importer, rootHash: rootHash}, nil
+}
+
+// Add verifies a chunk and imports its nodes. Chunks must be added in order. If the chunk is
+// rejected, a *ChunkError is returned and the import is not modified, so the chunk can be
+// fetched again, e.g. from a different peer. Any other error, e.g. when writing the nodes to the
+// database fails or the import context is cancelled, leaves the import partially modified, and it
+// must be aborted.
+func (ci *ChunkImporter) Add(chunk *ExportChunk) error {
+	if ci.importer.tree == nil {
+		return ErrNoImport
+	}
+	if chunk == nil {
+		return errors.New("chunk cannot be nil")
+	}
+	if ci.done {
+		return errors.New("all chunks have been imported")
+	}
+	if err := ci.verify(chunk); err != nil {
+		return &ChunkError{Index: ci.next, Err: err}
+	}
+	staged, err := ci.stage(chunk)
+	if err != nil {
+		return &ChunkError{Index: ci.next, Err: err}
+	}
+
+	if err := ci.importer.ctx.Err(); err != nil {
+		return err
+	}
+	for _, s := range staged {
+		if err := ci.importer.addNode(s.node, s.leftmost, s.children); err != nil {
+			return err
+		}
+	}
+	ci.next++
+	ci.done = len(chunk.Right) == 0
+	return nil
+}
+
+// verify verifies a chunk against the root hash and the nodes imported so far.
+func (ci *ChunkImporter) verify(chunk *ExportChunk) error {
+	if chunk.Index != ci.next {
+		return errors.Wrapf(ErrInvalidChunk, "expected chunk index %v, got %v", ci.next, chunk.Index)
+	}
+	if len(chunk.Nodes) == 0 && len(ci.rootHash) > 0 {
+		return errors.Wrap(ErrInvalidChunk, "chunk has no nodes")
+	}
+	for _, node := range chunk.Nodes {
+		if node != nil && node.Version > ci.importer.version {
+			return errors.Wrapf(ErrInvalidChunk, "node version %v can't be greater than import version %v",
+				node.Version, ci.importer.version)
+		}
+	}
+	stack := ci.importer.stack
+	if len(chunk.Left) != len(stack) {
+		return errors.Wrapf(ErrInvalidChunk, "left boundary has %v subtrees, expected %v",
+			len(chunk.Left), len(stack))
+	}
+	for i, left := range chunk.Left {
+		if !bytes.Equal(left.Hash, stack[i].hash) || !bytes.Equal(left.Key, ci.importer.leftmost[i]) ||
+			left.Height != stack[i].height || left.Size != stack[i].size {
+			return errors.Wrapf(ErrInvalidChunk, "left boundary subtree %v does not match imported nodes", i)
+		}
+	}
+	return chunk.Verify(ci.rootHash)
+}
+
+// stagedChunkNode is a node of a chunk which has been built by ChunkImporter.stage().
+type stagedChunkNode struct {
+	node     *Node
+	leftmost []byte
+	children int
+}
+
+// stage builds the nodes of a chunk on a copy of the importer's stack, so that nodes which the
+// importer would reject are detected before any of them are added.
+func (ci *ChunkImporter) stage(chunk *ExportChunk) ([]stagedChunkNode, error) {
+	stack := append([]*Node{}, ci.importer.stack...)
+	leftmost := append([][]byte{}, ci.importer.leftmost...)
+	staged := make([]stagedChunkNode, 0, len(chunk.Nodes))
+	for i, exportNode := range chunk.Nodes {
+		node, nodeLeftmost, children, err := ci.importer.buildNode(stack, leftmost, exportNode)
+		if err != nil {
+			return nil, errors.Wrapf(ErrInvalidChunk, "node %v: %v", i, err)
+		}
+		stack = append(stack[:len(stack)-children], node)
+		leftmost = append(leftmost[:len(leftmost)-children], nodeLeftmost)
+		staged = append(staged, stagedChunkNode{node: node, leftmost: nodeLeftmost, children: children})
+	}
+	return staged, nil
+}
+
+// Commit finalizes the import once all chunks have been added. See Importer.Commit().
+func (ci *ChunkImporter) Commit() error {
+	if ci.importer.tree == nil {
+		return ErrNoImport
+	}
+	if !ci.done {
+		return errors.Errorf("import is incomplete, expected chunk %v", ci.next)
+	}
+	return ci.importer.Commit()
+}
+
+// Close frees all resources. It is safe to call multiple times.
+func (ci *ChunkImporter) Close() {
+	ci.importer.Close()
+}
@@ -0,0 +1,177 @@
+package iavl
+
+import (
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+
+	db "github.com/tendermint/tm-db"
+)
+
+// exportAllChunks exports all chunks of the tree, returning them.
+func exportAllChunks(t *testing.T, tree *ImmutableTree, chunkSize int) []*ExportChunk {
+	exporter, err := tree.ExportChunks(chunkSize)
+	require.NoError(t, err)
+	defer exporter.Close()
+	chunks := []*ExportChunk{}
+	for {
+		chunk, err := exporter.Next()
+		if err == ExportDone {
+			return chunks
+		}
+		require.NoError(t, err)
+		chunks = append(chunks, chunk)
+	}
+}
+
+func TestChunkExporter(t *testing.T) {
+	testcases := map[string]*ImmutableTree{
+		"empty tree": NewImmutableTree(db.NewMemDB(), 0),
+		"basic tree": setupExportTreeBasic(t),
+		"sized tree": setupExportTreeSized(t, 1024),
+	}
+	for desc, tree := range testcases {
+		tree := tree
+		t.Run(desc, func(t *testing.T) {
+			expect := exportAll(t, tree.Export())
+			for _, chunkSize := range []int{1, 7, 100, 100000} {
+				chunks := exportAllChunks(t, tree, chunkSize)
+				require.NotEmpty(t, chunks)
+
+				nodes := []*ExportNode{}
+				for i, chunk := range chunks {
+					require.Equal(t, i, chunk.Index)
+					require.LessOrEqual(t, len(chunk.Nodes), chunkSize)
+					require.NoError(t, chunk.Verify(tree.Hash()), "chunk %v of size %v", i, chunkSize)
+					require.Equal(t, i == len(chunks)-1, len(chunk.Right) == 0)
+					nodes = append(nodes, chunk.Nodes...)
+
+					bz, err := chunk.MarshalBinary()
+					require.NoError(t, err)
+					decoded := &ExportChunk{}
+					require.NoError(t, decoded.UnmarshalBinary(bz))
+					require.NoError(t, decoded.Verify(tree.Hash()))
+					require.Equal(t, chunk.Nodes, decoded.Nodes)
+				}
+				require.Equal(t, expect, nodes, "chunk size %v", chunkSize)
+
+				newTree, err := NewMutableTree(db.NewMemDB(), 0)
+				require.NoError(t, err)
+				importer, err := newTree.ImportChunks(tree.Version(), tree.Hash())
+				require.NoError(t, err)
+				for _, chunk := range chunks {
+					require.NoError(t, importer.Add(chunk))
+				}
+				require.NoError(t, importer.Commit())
+				require.Equal(t, tree.Hash(), newTree.Hash())
+				require.Equal(t, tree.Size(), newTree.Size())
+			}
+		})
+	}
+}
+
+func TestChunkExporter_Close(t *testing.T) {
+	tree := setupExportTreeSized(t, 64)
+	_, err := tree.ExportChunks(0)
+	require.Error(t, err)
+
+	exporter, err := tree.ExportChunks(10)
+	require.NoError(t, err)
+	_, err = exporter.Next()
+	require.NoError(t, err)
+	exporter.Close()
+	exporter.Close()
+	_, err = exporter.Next()
+	require.Error(t, err)
+}
+
+func TestChunkImporter_Invalid(t *testing.T) {
+	tree := setupExportTreeSized(t, 256)
+	chunks := exportAllChunks(t, tree, 50)
+	require.Greater(t, len(chunks), 3)
+
+	copyChunk := func(chunk *ExportChunk) *ExportChunk {
+		bz, err := chunk.MarshalBinary()
+		require.NoError(t, err)
+		c := &ExportChunk{}
+		require.NoError(t, c.UnmarshalBinary(bz))
+		return c
+	}
+
+	tamper := map[string]func(c *ExportChunk){
+		"wrong index": func(c *ExportChunk) { c.Index++ },
+		"no nodes":    func(c *ExportChunk) { c.Nodes = nil },
+		"dropped node": func(c *ExportChunk) {
+			c.Nodes = c.Nodes[1:]
+		},
+		"changed value": func(c *ExportChunk) {
+			for _, node := range c.Nodes {
+				if node.Height == 0 {
+					node.Value = append(node.Value, 1)
+					return
+				}
+			}
+		},
+		"changed version": func(c *ExportChunk) { c.Nodes[len(c.Nodes)-1].Version-- },
+		"changed inner key": func(c *ExportChunk) {
+			for i := len(c.Nodes) - 1; i >= 0; i-- {
+				if c.Nodes[i].Height > 0 {
+					c.Nodes[i].Key = append(c.Nodes[i].Key, 0)
+					return
+				}
+			}
+		},
+		"changed left key": func(c *ExportChunk) {
+			if len(c.Left) == 0 {
+				c.Left = append(c.Left, ChunkBoundary{Hash: []byte{1}, Key: []byte{1}, Size: 1})
+				return
+			}
+			c.Left[len(c.Left)-1].Key = append(c.Left[len(c.Left)-1].Key, 0)
+		},
+		"changed left": func(c *ExportChunk) {
+			if len(c.Left) == 0 {
+				c.Left = append(c.Left, ChunkBoundary{Hash: []byte{1}, Size: 1})
+				return
+			}
+			c.Left[0].Hash[0] ^= 0x01
+		},
+		"changed right": func(c *ExportChunk) {
+			if len(c.Right) == 0 {
+				c.Right = append(c.Right, ChunkBoundary{Height: 100, Version: 1})
+				return
+			}
+			c.Right[0].Height++
+		},
+	}
+
+	newTree, err := NewMutableTree(db.NewMemDB(), 0)
+	require.NoError(t, err)
+	importer, err := newTree.ImportChunks(tree.Version(), tree.Hash())
+	require.NoError(t, err)
+	defer importer.Close()
+
+	for i, chunk := range chunks {
+		for desc, fn := range tamper {
+			bad := copyChunk(chunk)
+			fn(bad)
+			err := importer.Add(bad)
+			require.Error(t, err, "%v in chunk %v", desc, i)
+			chunkErr, ok := err.(*ChunkError)
+			require.True(t, ok, "%v in chunk %v: unexpected error %v", desc, i, err)
+			require.Equal(t, i, chunkErr.Index)
+			require.True(t, errors.Is(err, ErrInvalidChunk), "%v in chunk %v: unexpected error %v", desc, i, err)
+		}
+		if i+1 < len(chunks) {
+			require.Error(t, importer.Add(chunks[i+1]), "out of order chunk %v", i+1)
+			require.Error(t, importer.Commit())
+		}
+		require.NoError(t, importer.Add(chunk))
+	}
+	require.Error(t, importer.Add(chunks[0]))
+	require.NoError(t, importer.Commit())
+	require.Equal(t, tree.Hash(), newTree.Hash())
+
+	// Chunks do not verify against a different root hash.
+	require.Error(t, chunks[0].Verify(setupExportTreeSized(t, 16).Hash()))
+}
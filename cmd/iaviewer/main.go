@@ -5,6 +5,7 @@ import (
 	"crypto/sha256"
 	"encoding/hex"
 	"fmt"
+	"io/ioutil"
 	"os"
 	"strconv"
 	"strings"
@@ -20,8 +21,37 @@ const (
 
 func main() {
 	args := os.Args[1:]
+	if len(args) >= 2 && args[0] == "gc" {
+		dryRun := len(args) == 3 && args[2] == "--dry-run"
+		if len(args) > 3 || (len(args) == 3 && !dryRun) {
+			fmt.Fprintln(os.Stderr, "Usage: iaviewer gc <leveldb dir> [--dry-run]")
+			os.Exit(1)
+		}
+		if err := CollectGarbage(args[1], dryRun); err != nil {
+			fmt.Fprintf(os.Stderr, "Error collecting garbage: %s\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+	if len(args) >= 3 && args[0] == "migrate" {
+		backend := dbm.GoLevelDBBackend
+		if len(args) == 4 {
+			backend = dbm.BackendType(args[3])
+		}
+		if len(args) > 4 {
+			fmt.Fprintln(os.Stderr, "Usage: iaviewer migrate <leveldb dir> <target dir> [target backend]")
+			os.Exit(1)
+		}
+		if err := MigrateDB(args[1], args[2], backend); err != nil {
+			fmt.Fprintf(os.Stderr, "Error migrating data: %s\n", err)
+			os.Exit(1)
+		}
+		return
+	}
 	if len(args) < 2 || (args[0] != "data" && args[0] != "shape" && args[0] != "versions") {
 		fmt.Fprintln(os.Stderr, "Usage: iaviewer <data|shape|versions> <leveldb dir> [version number]")
+		fmt.Fprintln(os.Stderr, "       iaviewer gc <leveldb dir> [--dry-run]")
+		fmt.Fprintln(os.Stderr, "       iaviewer migrate <leveldb dir> <target dir> [target backend]")
 		os.Exit(1)
 	}
 
@@ -54,25 +84,34 @@ func main() {
 }
 
 func OpenDB(dir string) (dbm.DB, error) {
+	name, parent, err := splitDBDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	db, err := dbm.NewGoLevelDB(name, parent)
+	if err != nil {
+		return nil, err
+	}
+	return db, nil
+}
+
+// splitDBDir splits a database directory ending with .db into the database name and the
+// directory containing it.
+func splitDBDir(dir string) (string, string, error) {
 	switch {
 	case strings.HasSuffix(dir, ".db"):
 		dir = dir[:len(dir)-3]
 	case strings.HasSuffix(dir, ".db/"):
 		dir = dir[:len(dir)-4]
 	default:
-		return nil, fmt.Errorf("database directory must end with .db")
+		return "", "", fmt.Errorf("database directory must end with .db")
 	}
 	// TODO: doesn't work on windows!
 	cut := strings.LastIndex(dir, "/")
 	if cut == -1 {
-		return nil, fmt.Errorf("cannot cut paths on %s", dir)
+		return "", "", fmt.Errorf("cannot cut paths on %s", dir)
 	}
-	name := dir[cut+1:]
-	db, err := dbm.NewGoLevelDB(name, dir[:cut])
-	if err != nil {
-		return nil, err
-	}
-	return db, nil
+	return dir[cut+1:], dir[:cut], nil
 }
 
 // nolint: unused,deadcode
@@ -111,6 +150,79 @@ func ReadTree(dir string, version int) (*iavl.MutableTree, error) {
 	return tree, err
 }
 
+// CollectGarbage removes unreachable nodes and stale orphans from the database in the directory,
+// or only reports them if dryRun is set. Reachable nodes are recorded in a temporary database, so
+// that large databases can be collected in bounded memory.
+func CollectGarbage(dir string, dryRun bool) error {
+	db, err := OpenDB(dir)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	markDir, err := ioutil.TempDir("", "iaviewer-gc")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(markDir)
+	markDB, err := dbm.NewGoLevelDB("mark", markDir)
+	if err != nil {
+		return err
+	}
+	defer markDB.Close()
+
+	result, err := iavl.GarbageCollect(db, iavl.GCOptions{DryRun: dryRun, MarkDB: markDB})
+	if err != nil {
+		return err
+	}
+	verb := "Removed"
+	if dryRun {
+		verb = "Would remove"
+	}
+	fmt.Printf("Marked %d reachable nodes from %d roots\n", result.Reachable, result.Roots)
+	fmt.Printf("%s %d of %d nodes\n", verb, result.SweptNodes, result.Nodes)
+	fmt.Printf("%s %d of %d orphans\n", verb, result.SweptOrphans, result.Orphans)
+	return nil
+}
+
+// MigrateDB copies the live data of the database in the directory to a database of the given
+// backend in the target directory, which must also end with .db. An interrupted migration is
+// resumed by running it again with the same target.
+func MigrateDB(dir, targetDir string, backend dbm.BackendType) (err error) {
+	db, err := OpenDB(dir)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	name, parent, err := splitDBDir(targetDir)
+	if err != nil {
+		return err
+	}
+	// NewDB panics on unknown backends and open errors.
+	var target dbm.DB
+	func() {
+		defer func() {
+			if r := recover(); r != nil {
+				err = fmt.Errorf("opening %s database: %v", backend, r)
+			}
+		}()
+		target = dbm.NewDB(name, backend, parent)
+	}()
+	if err != nil {
+		return err
+	}
+	defer target.Close()
+
+	result, err := iavl.Migrate(db, target)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Migrated %d versions, %d of which were already copied\n", result.Versions, result.Resumed)
+	fmt.Printf("Copied %d nodes, %d orphans and %d metadata records\n", result.Nodes, result.Orphans, result.Metadata)
+	return nil
+}
+
 func PrintKeys(tree *iavl.MutableTree) {
 	fmt.Println("Printing all keys with hashed values (to detect diff)")
 	tree.Iterate(func(key []byte, value []byte) bool {
@@ -0,0 +1,410 @@
+package iavl
+
+import (
+	"bytes"
+	"context"
+
+	"github.com/pkg/errors"
+)
+
+// DeltaNode is an item of a delta export. It is either a Node which is not present in the base
+// version, or the Hash of a subtree which is present in the base version and is reused as is.
+type DeltaNode struct {
+	Node *ExportNode
+	Hash []byte
+}
+
+// DeltaExporter exports the changes between two versions of a tree. It is created by
+// MutableTree.ExportDelta(), and callers must call Close() when done.
+//
+// Items are exported depth-first post-order (LRN) like Exporter, except that subtrees which are
+// present under the base root are exported as a single DeltaNode containing their hash. This
+// order must be preserved when importing with MutableTree.ImportDelta().
+type DeltaExporter struct {
+	base   *ImmutableTree
+	target *ImmutableTree
+	ch     chan *DeltaNode
+	cancel context.CancelFunc
+	err    error // set by the export goroutine before closing ch, if aborted by the parent context
+}
+
+// ExportDelta returns an exporter for the nodes reachable from the root of targetVersion which
+// are not present under the root of baseVersion. A baseVersion of 0 exports the entire target
+// version.
+func (tree *MutableTree) ExportDelta(baseVersion, targetVersion int64) (*DeltaExporter, error) {
+	return tree.ExportDeltaCtx(context.Background(), baseVersion, targetVersion)
+}
+
+// ExportDeltaCtx is like ExportDelta, but the export is aborted when the context is cancelled or
+// its deadline expires, in which case DeltaExporter.Next() returns the context error.
+func (tree *MutableTree) ExportDeltaCtx(ctx context.Context, baseVersion, targetVersion int64) (*DeltaExporter, error) {
+	if baseVersion < 0 || baseVersion >= targetVersion {
+		return nil, errors.Errorf("base version %v must be between 0 and target version %v",
+			baseVersion, targetVersion)
+	}
+	base := &ImmutableTree{ndb: tree.ndb}
+	if baseVersion > 0 {
+		var err error
+		if base, err = tree.GetImmutable(baseVersion); err != nil {
+			return nil, errors.Wrapf(err, "loading base version %v", baseVersion)
+		}
+	}
+	target, err := tree.GetImmutable(targetVersion)
+	if err != nil {
+		return nil, errors.Wrapf(err, "loading target version %v", targetVersion)
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	exporter := &DeltaExporter{
+		base:   base,
+		target: target,
+		ch:     make(chan *DeltaNode, exportBufferSize),
+		cancel: cancel,
+	}
+	tree.ndb.incrVersionReaders(base.version)
+	tree.ndb.incrVersionReaders(target.version)
+	go exporter.export(ctx)
+
+	return exporter, nil
+}
+
+// export exports the delta
+func (e *DeltaExporter) export(ctx context.Context) {
+	defer close(e.ch)
+	root := e.target.root
+	if root == nil {
+		return
+	}
+	send := func(node *DeltaNode) bool { return e.send(ctx, node) }
+	if !exportDelta(e.base, e.target, root, leftmostKey(e.target, root), send) {
+		e.err = ctx.Err()
+	}
+}
+
+// exportDelta exports the subtree of target rooted at node, whose leftmost key is minKey, with
+// the subtrees present under the root of base replaced by their hash. It returns false if send
+// did, which aborts the export.
+func exportDelta(base, target *ImmutableTree, node *Node, minKey []byte, send func(*DeltaNode) bool) bool {
+	if findSubtree(base, node._hash(), node.height, minKey) != nil {
+		return send(&DeltaNode{Hash: node.hash})
+	}
+	if !node.isLeaf() {
+		if !exportDelta(base, target, node.getLeftNode(target), minKey, send) {
+			return false
+		}
+		if !exportDelta(base, target, node.getRightNode(target), node.key, send) {
+			return false
+		}
+	}
+	return send(&DeltaNode{Node: newExportNode(node)})
+}
+
+// deltaOrphans calls fn for each node of base which is not part of target, i.e. each node which
+// is orphaned by going from base to target. It returns false if fn did, which aborts the traversal.
+func deltaOrphans(base, target *ImmutableTree, fn func(*Node) bool) bool {
+	if base.root == nil {
+		return true
+	}
+	var collect func(node *Node, minKey []byte) bool
+	collect = func(node *Node, minKey []byte) bool {
+		if findSubtree(target, node._hash(), node.height, minKey) != nil {
+			return true
+		}
+		if !fn(node) {
+			return false
+		}
+		if !node.isLeaf() {
+			return collect(node.getLeftNode(base), minKey) && collect(node.getRightNode(base), node.key)
+		}
+		return true
+	}
+	return collect(base.root, leftmostKey(base, base.root))
+}
+
+// send sends an item to the export channel, returning false if the context was cancelled.
+func (e *DeltaExporter) send(ctx context.Context, node *DeltaNode) bool {
+	select {
+	case e.ch <- node:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// Next fetches the next exported item, or returns ExportDone when done.
+func (e *DeltaExporter) Next() (*DeltaNode, error) {
+	if node, ok := <-e.ch; ok {
+		return node, nil
+	}
+	if e.err != nil {
+		return nil, e.err
+	}
+	return nil, ExportDone
+}
+
+// Close closes the exporter. It is safe to call multiple times.
+func (e *DeltaExporter) Close() {
+	e.cancel()
+	for range e.ch { // drain channel
+	}
+	if e.target != nil {
+		e.target.ndb.decrVersionReaders(e.base.version)
+		e.target.ndb.decrVersionReaders(e.target.version)
+	}
+	e.base = nil
+	e.target = nil
+}
+
+// leftmostKey returns the leftmost key of the subtree rooted at node.
+func leftmostKey(t *ImmutableTree, node *Node) []byte {
+	for !node.isLeaf() {
+		node = node.getLeftNode(t)
+	}
+	return node.key
+}
+
+// findSubtree returns the node with the given hash and height under the root of the tree, or
+// nil if there is none. minKey must be the leftmost key of the subtree: since the subtree
+// contains it, the subtree can only be on the path from the root to minKey.
+func findSubtree(t *ImmutableTree, hash []byte, height int8, minKey []byte) *Node {
+	node := t.root
+	for node != nil && node.height >= height {
+		if node.height == height {
+			if bytes.Equal(node._hash(), hash) {
+				return node
+			}
+			return nil
+		}
+		if bytes.Compare(minKey, node.key) < 0 {
+			node = node.getLeftNode(t)
+		} else {
+			node = node.getRightNode(t)
+		}
+	}
+	return nil
+}
+
+// DeltaImporter applies a delta export onto a tree at the base version of the delta. It is
+// created by MutableTree.ImportDelta(). Items must be added in the order returned by
+// DeltaExporter.
+//
+// DeltaImporter is not concurrency-safe, it is the caller's responsibility to ensure the tree is
+// not modified while performing an import.
+type DeltaImporter struct {
+	tree    *MutableTree
+	base    *ImmutableTree
+	version int64
+	stack   deltaStack
+	kept    map[string]bool // hashes of base subtrees and nodes present in the new version
+	opts    DeltaImportOptions
+}
+
+// DeltaImportOptions are options for MutableTree.ImportDeltaWithOptions().
+type DeltaImportOptions struct {
+	// ExpectedRootHash is the root hash of the target version of the delta. If set, Commit()
+	// fails without saving the version when the imported tree has a different root hash. An
+	// empty non-nil hash expects an empty tree.
+	ExpectedRootHash []byte
+}
+
+// ImportDelta returns an importer which applies a delta exported by MutableTree.ExportDelta()
+// onto the tree, creating the given version. The tree must be at the base version of the delta
+// with no unsaved changes, and version must be the delta's target version.
+func (tree *MutableTree) ImportDelta(version int64) (*DeltaImporter, error) {
+	return tree.ImportDeltaWithOptions(version, DeltaImportOptions{})
+}
+
+// ImportDeltaWithOptions is like ImportDelta, but with the given options. In particular, an
+// expected root hash makes the import safe for untrusted data, see DeltaImportOptions.
+func (tree *MutableTree) ImportDeltaWithOptions(version int64, opts DeltaImportOptions) (*DeltaImporter, error) {
+	if version <= tree.version {
+		return nil, errors.Errorf("version %v must be greater than the latest version %v",
+			version, tree.version)
+	}
+	if tree.version > 0 && !bytes.Equal(tree.WorkingHash(), tree.lastSaved.Hash()) {
+		return nil, errors.New("tree has unsaved changes")
+	}
+	base := &ImmutableTree{ndb: tree.ndb, version: tree.version}
+	if tree.version > 0 {
+		base = tree.lastSaved
+	}
+	return &DeltaImporter{
+		tree:    tree,
+		base:    base,
+		version: version,
+		kept:    make(map[string]bool),
+		opts:    opts,
+	}, nil
+}
+
+// Add adds an item of the delta. Subtree hashes must be present under the root of the base
+// version.
+func (i *DeltaImporter) Add(item *DeltaNode) error {
+	if i.tree == nil {
+		return ErrNoImport
+	}
+	node, err := i.stack.add(i.tree.ndb, i.base, item, i.version)
+	if err != nil {
+		return err
+	}
+	i.kept[string(node.hash)] = true
+	return nil
+}
+
+// deltaStack is the stack of subtrees built by a delta import, along with their leftmost keys.
+type deltaStack struct {
+	nodes    []*Node
+	leftmost [][]byte
+}
+
+// add builds the node for a delta item and pushes it onto the stack, returning the node. The
+// children of a new inner node are taken from the top of the stack, see Importer.Add(), and
+// subtree hashes must be present under the root of base in the database. The stack is not
+// modified if an error is returned.
+func (s *deltaStack) add(ndb *nodeDB, base *ImmutableTree, item *DeltaNode, version int64) (*Node, error) {
+	if item == nil || (item.Node == nil) == (item.Hash == nil) {
+		return nil, errors.New("delta node must have either a node or a hash")
+	}
+
+	if item.Hash != nil {
+		node, leftmost, err := findBaseSubtree(ndb, base, item.Hash)
+		if err != nil {
+			return nil, err
+		}
+		s.push(node, leftmost)
+		return node, nil
+	}
+
+	exportNode := item.Node
+	if exportNode.Version > version {
+		return nil, errors.Errorf("node version %v can't be greater than import version %v",
+			exportNode.Version, version)
+	}
+	node := &Node{
+		key:     exportNode.Key,
+		value:   exportNode.Value,
+		version: exportNode.Version,
+		height:  exportNode.Height,
+	}
+
+	stackSize := len(s.nodes)
+	leftmost := node.key
+	if node.height == 0 {
+		node.size = 1
+	} else {
+		if stackSize < 2 || s.nodes[stackSize-2].height >= node.height || s.nodes[stackSize-1].height >= node.height {
+			return nil, errors.New("inner node must have two children")
+		}
+		// The node hash does not cover the keys of inner nodes, see Importer.Add().
+		if !bytes.Equal(node.key, s.leftmost[stackSize-1]) {
+			return nil, errors.Errorf("inner node key %X does not match leftmost key %X of its right subtree",
+				node.key, s.leftmost[stackSize-1])
+		}
+		node.leftNode = s.nodes[stackSize-2]
+		node.leftHash = node.leftNode.hash
+		node.rightNode = s.nodes[stackSize-1]
+		node.rightHash = node.rightNode.hash
+		node.size = node.leftNode.size + node.rightNode.size
+		leftmost = s.leftmost[stackSize-2]
+	}
+	if err := node.validate(); err != nil {
+		return nil, err
+	}
+	node._hash()
+
+	if node.height > 0 {
+		s.nodes = s.nodes[:stackSize-2]
+		s.leftmost = s.leftmost[:stackSize-2]
+	}
+	s.push(node, leftmost)
+	return node, nil
+}
+
+// push pushes a subtree with the given leftmost key onto the stack.
+func (s *deltaStack) push(node *Node, leftmost []byte) {
+	s.nodes = append(s.nodes, node)
+	s.leftmost = append(s.leftmost, leftmost)
+}
+
+// findBaseSubtree returns the subtree with the given hash and its leftmost key, or an error if it
+// is not present under the root of base.
+func findBaseSubtree(ndb *nodeDB, base *ImmutableTree, hash []byte) (*Node, []byte, error) {
+	if base.root == nil {
+		return nil, nil, errors.Errorf("subtree %X not found in empty base version", hash)
+	}
+	if ok, err := ndb.Has(hash); err != nil {
+		return nil, nil, err
+	} else if !ok {
+		return nil, nil, errors.Errorf("subtree %X not found in base version %v", hash, base.version)
+	}
+	node := ndb.GetNode(hash)
+	leftmost := leftmostKey(base, node)
+	if findSubtree(base, hash, node.height, leftmost) == nil {
+		return nil, nil, errors.Errorf("subtree %X not found in base version %v", hash, base.version)
+	}
+	return node, leftmost, nil
+}
+
+// Commit saves the new version, recording the nodes of the base version which are not present
+// in it as orphans. It can only be called once, and returns the new root hash.
+func (i *DeltaImporter) Commit() ([]byte, error) {
+	if i.tree == nil {
+		return nil, ErrNoImport
+	}
+
+	var root *Node
+	rootHash := []byte{}
+	switch len(i.stack.nodes) {
+	case 0:
+	case 1:
+		root = i.stack.nodes[0]
+		rootHash = root.hash
+	default:
+		return nil, errors.Errorf("invalid node structure, found stack size %v when committing",
+			len(i.stack.nodes))
+	}
+	if i.opts.ExpectedRootHash != nil && !bytes.Equal(rootHash, i.opts.ExpectedRootHash) {
+		err := errors.Wrapf(ErrRootHashMismatch, "got %X, expected %X", rootHash, i.opts.ExpectedRootHash)
+		i.Close()
+		return nil, err
+	}
+
+	orphans := map[string]int64{}
+	if i.base.root != nil {
+		i.collectOrphans(i.base.root, orphans)
+	}
+
+	i.tree.ImmutableTree = &ImmutableTree{
+		root:    root,
+		ndb:     i.tree.ndb,
+		version: i.tree.version,
+	}
+	i.tree.orphans = orphans
+	hash, _, err := i.tree.saveVersion(i.version)
+	if err != nil {
+		i.tree.Rollback()
+		return nil, err
+	}
+	i.Close()
+	return hash, nil
+}
+
+// collectOrphans adds the nodes of the base subtree which are not kept to orphans.
+func (i *DeltaImporter) collectOrphans(node *Node, orphans map[string]int64) {
+	if i.kept[string(node._hash())] {
+		return
+	}
+	orphans[string(node.hash)] = node.version
+	if !node.isLeaf() {
+		i.collectOrphans(node.getLeftNode(i.base), orphans)
+		i.collectOrphans(node.getRightNode(i.base), orphans)
+	}
+}
+
+// Close frees all resources. It is safe to call multiple times. The tree is not modified unless
+// Commit() succeeded.
+func (i *DeltaImporter) Close() {
+	i.tree = nil
+	i.stack = deltaStack{}
+	i.kept = nil
+}
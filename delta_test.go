@@ -0,0 +1,247 @@
+package iavl
+
+import (
+	"fmt"
+	"math/rand"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+
+	db "github.com/tendermint/tm-db"
+)
+
+// setupDeltaTree creates a tree with the given number of versions, each of which updates,
+// adds and removes some keys.
+func setupDeltaTree(t *testing.T, versions int) *MutableTree {
+	r := rand.New(rand.NewSource(5))
+	tree, err := NewMutableTree(db.NewMemDB(), 0)
+	require.NoError(t, err)
+	for v := 1; v <= versions; v++ {
+		for i := 0; i < 64; i++ {
+			key := []byte(fmt.Sprintf("key%03d", r.Intn(512)))
+			if r.Intn(4) == 0 {
+				tree.Remove(key)
+			} else {
+				tree.Set(key, []byte(fmt.Sprintf("value%v-%v", v, i)))
+			}
+		}
+		_, _, err = tree.SaveVersion()
+		require.NoError(t, err)
+	}
+	return tree
+}
+
+func exportAllDelta(t *testing.T, tree *MutableTree, baseVersion, targetVersion int64) []*DeltaNode {
+	exporter, err := tree.ExportDelta(baseVersion, targetVersion)
+	require.NoError(t, err)
+	defer exporter.Close()
+	nodes := []*DeltaNode{}
+	for {
+		node, err := exporter.Next()
+		if err == ExportDone {
+			return nodes
+		}
+		require.NoError(t, err)
+		nodes = append(nodes, node)
+	}
+}
+
+// importDeltaBase imports the given version of the tree into a new tree.
+func importDeltaBase(t *testing.T, tree *MutableTree, version int64) *MutableTree {
+	newTree, err := NewMutableTree(db.NewMemDB(), 0)
+	require.NoError(t, err)
+	if version == 0 {
+		return newTree
+	}
+	itree, err := tree.GetImmutable(version)
+	require.NoError(t, err)
+	importer, err := newTree.Import(version)
+	require.NoError(t, err)
+	defer importer.Close()
+	for _, node := range exportAll(t, itree.Export()) {
+		require.NoError(t, importer.Add(node))
+	}
+	require.NoError(t, importer.Commit())
+	return newTree
+}
+
+func applyDelta(t *testing.T, tree *MutableTree, version int64, nodes []*DeltaNode) []byte {
+	importer, err := tree.ImportDelta(version)
+	require.NoError(t, err)
+	defer importer.Close()
+	for _, node := range nodes {
+		require.NoError(t, importer.Add(node))
+	}
+	hash, err := importer.Commit()
+	require.NoError(t, err)
+	return hash
+}
+
+func requireSameTree(t *testing.T, expect, actual *ImmutableTree) {
+	require.Equal(t, expect.Hash(), actual.Hash())
+	require.Equal(t, expect.Size(), actual.Size())
+	expect.Iterate(func(key, value []byte) bool {
+		_, actualValue := actual.Get(key)
+		require.Equal(t, value, actualValue, "key %s", key)
+		return false
+	})
+}
+
+func TestDelta(t *testing.T) {
+	tree := setupDeltaTree(t, 6)
+	full := exportAll(t, tree.ImmutableTree.Export())
+
+	testcases := []struct{ base, target int64 }{
+		{0, 6}, {1, 2}, {1, 6}, {3, 4}, {5, 6}, {2, 5},
+	}
+	for _, tc := range testcases {
+		tc := tc
+		t.Run(fmt.Sprintf("%v to %v", tc.base, tc.target), func(t *testing.T) {
+			target, err := tree.GetImmutable(tc.target)
+			require.NoError(t, err)
+			nodes := exportAllDelta(t, tree, tc.base, tc.target)
+			if tc.base > 0 {
+				require.Less(t, len(nodes), len(full))
+			}
+			for _, node := range nodes {
+				if node.Node != nil {
+					require.Greater(t, node.Node.Version, tc.base)
+				}
+			}
+
+			newTree := importDeltaBase(t, tree, tc.base)
+			hash := applyDelta(t, newTree, tc.target, nodes)
+			require.Equal(t, target.Hash(), hash)
+			require.Equal(t, tc.target, newTree.Version())
+			requireSameTree(t, target, newTree.ImmutableTree)
+
+			// The base version remains available, and deleting it keeps the new version intact.
+			if tc.base > 0 {
+				base, err := tree.GetImmutable(tc.base)
+				require.NoError(t, err)
+				newBase, err := newTree.GetImmutable(tc.base)
+				require.NoError(t, err)
+				requireSameTree(t, base, newBase)
+
+				require.NoError(t, newTree.DeleteVersion(tc.base))
+				reloaded, err := newTree.GetImmutable(tc.target)
+				require.NoError(t, err)
+				requireSameTree(t, target, reloaded)
+				require.Equal(t, exportAll(t, target.Export()), exportAll(t, reloaded.Export()))
+			}
+		})
+	}
+}
+
+func TestDelta_Chain(t *testing.T) {
+	tree := setupDeltaTree(t, 6)
+	newTree := importDeltaBase(t, tree, 1)
+	for _, version := range []int64{3, 4, 6} {
+		applyDelta(t, newTree, version, exportAllDelta(t, tree, newTree.Version(), version))
+	}
+	target, err := tree.GetImmutable(6)
+	require.NoError(t, err)
+	requireSameTree(t, target, newTree.ImmutableTree)
+	require.Equal(t, []int{1, 3, 4, 6}, newTree.AvailableVersions())
+
+	// Changes can be saved on top of the imported version.
+	newTree.Set([]byte("new"), []byte("value"))
+	_, version, err := newTree.SaveVersion()
+	require.NoError(t, err)
+	require.EqualValues(t, 7, version)
+}
+
+func TestDelta_EmptyTarget(t *testing.T) {
+	tree, err := NewMutableTree(db.NewMemDB(), 0)
+	require.NoError(t, err)
+	tree.Set([]byte("a"), []byte{1})
+	tree.Set([]byte("b"), []byte{2})
+	_, _, err = tree.SaveVersion()
+	require.NoError(t, err)
+	tree.Remove([]byte("a"))
+	tree.Remove([]byte("b"))
+	_, _, err = tree.SaveVersion()
+	require.NoError(t, err)
+
+	nodes := exportAllDelta(t, tree, 1, 2)
+	require.Empty(t, nodes)
+	newTree := importDeltaBase(t, tree, 1)
+	require.Nil(t, applyDelta(t, newTree, 2, nodes))
+	require.True(t, newTree.IsEmpty())
+}
+
+func TestDelta_Invalid(t *testing.T) {
+	tree := setupDeltaTree(t, 3)
+
+	_, err := tree.ExportDelta(2, 2)
+	require.Error(t, err)
+	_, err = tree.ExportDelta(1, 4)
+	require.Error(t, err)
+
+	newTree := importDeltaBase(t, tree, 2)
+	_, err = newTree.ImportDelta(2)
+	require.Error(t, err)
+
+	// Subtrees must be present under the base root, even if they are in the database.
+	importer, err := tree.ImportDelta(4)
+	require.NoError(t, err)
+	defer importer.Close()
+	first, err := tree.GetImmutable(1)
+	require.NoError(t, err)
+	require.Error(t, importer.Add(&DeltaNode{Hash: first.Hash()}))
+	require.NoError(t, importer.Add(&DeltaNode{Hash: tree.Hash()}))
+	require.Error(t, importer.Add(&DeltaNode{Hash: []byte{1, 2, 3}}))
+	require.Error(t, importer.Add(&DeltaNode{}))
+
+	newTree.Set([]byte("unsaved"), []byte{1})
+	_, err = newTree.ImportDelta(3)
+	require.Error(t, err)
+}
+
+func TestDelta_ExpectedRootHash(t *testing.T) {
+	tree := setupDeltaTree(t, 4)
+	target, err := tree.GetImmutable(4)
+	require.NoError(t, err)
+	nodes := exportAllDelta(t, tree, 2, 4)
+
+	importNodes := func(newTree *MutableTree, nodes []*DeltaNode, opts DeltaImportOptions) error {
+		importer, err := newTree.ImportDeltaWithOptions(4, opts)
+		require.NoError(t, err)
+		defer importer.Close()
+		for _, node := range nodes {
+			if err := importer.Add(node); err != nil {
+				return err
+			}
+		}
+		_, err = importer.Commit()
+		return err
+	}
+
+	// A mismatch does not save the version.
+	newTree := importDeltaBase(t, tree, 2)
+	wrongHash := append([]byte{}, target.Hash()...)
+	wrongHash[0] ^= 0x01
+	for _, hash := range [][]byte{wrongHash, {}} {
+		err = importNodes(newTree, nodes, DeltaImportOptions{ExpectedRootHash: hash})
+		require.True(t, errors.Is(err, ErrRootHashMismatch), "unexpected error %v", err)
+		require.Equal(t, []int{2}, newTree.AvailableVersions())
+		require.EqualValues(t, 2, newTree.Version())
+	}
+
+	// Inner node keys are not covered by the root hash, so changing one must be detected.
+	for i, node := range nodes {
+		if node.Node == nil || node.Node.Height == 0 {
+			continue
+		}
+		changed := *node.Node
+		changed.Key = append(append([]byte{}, changed.Key...), 0)
+		bad := append([]*DeltaNode{}, nodes...)
+		bad[i] = &DeltaNode{Node: &changed}
+		require.Error(t, importNodes(newTree, bad, DeltaImportOptions{ExpectedRootHash: target.Hash()}),
+			"inner node %v", i)
+	}
+
+	require.NoError(t, importNodes(newTree, nodes, DeltaImportOptions{ExpectedRootHash: target.Hash()}))
+	requireSameTree(t, target, newTree.ImmutableTree)
+}
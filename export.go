@@ -2,6 +2,7 @@ package iavl
 
 import (
 	"context"
+	"sync"
 
 	"github.com/pkg/errors"
 )
@@ -11,6 +12,11 @@ import (
 // especially since callers may export several IAVL stores in parallel (e.g. the Cosmos SDK).
 const exportBufferSize = 32
 
+// parallelExportBufferSize is the number of nodes to buffer for each subtree being read by a
+// parallel export. Subtrees are read ahead of the one being exported, so this bounds the memory
+// used by a parallel export to roughly workers * parallelExportBufferSize nodes.
+const parallelExportBufferSize = 1024
+
 // ExportDone is returned by Exporter.Next() when all items have been exported.
 var ExportDone = errors.New("export is complete") // nolint:golint
 
@@ -28,51 +34,267 @@ type ExportNode struct {
 // depth-first post-order (LRN), this order must be preserved when importing in order to recreate
 // the same tree structure.
 type Exporter struct {
-	tree   *ImmutableTree
-	ch     chan *ExportNode
-	cancel context.CancelFunc
+	tree     *ImmutableTree
+	rootHash []byte // root hash of the exported tree, which differs from tree's for range exports
+	size     int64  // number of exported leaves
+	ch       chan *ExportNode
+	cancel   context.CancelFunc
+	err      error // set by the export goroutine before closing ch, if aborted by the parent context
 }
 
 // NewExporter creates a new Exporter. Callers must call Close() when done.
-func newExporter(tree *ImmutableTree) *Exporter {
-	ctx, cancel := context.WithCancel(context.Background())
+func newExporter(parent context.Context, tree *ImmutableTree) *Exporter {
+	return newParallelExporter(parent, tree, 0, 1)
+}
+
+// newParallelExporter creates a new Exporter which reads the subtrees at splitDepth with the
+// given number of workers. It exports sequentially if splitDepth is 0 or workers is less than 2.
+// Callers must call Close() when done.
+func newParallelExporter(parent context.Context, tree *ImmutableTree, splitDepth uint8, workers int) *Exporter {
+	ctx, cancel := context.WithCancel(parent)
 	exporter := &Exporter{
-		tree:   tree,
-		ch:     make(chan *ExportNode, exportBufferSize),
-		cancel: cancel,
+		tree:     tree,
+		rootHash: tree.Hash(),
+		size:     tree.Size(),
+		ch:       make(chan *ExportNode, exportBufferSize),
+		cancel:   cancel,
 	}
 
 	tree.ndb.incrVersionReaders(tree.version)
-	go exporter.export(ctx)
+	if splitDepth > 0 && workers > 1 {
+		go exporter.exportParallel(parent, ctx, splitDepth, workers)
+	} else {
+		go exporter.export(parent, ctx)
+	}
 
 	return exporter
 }
 
 // export exports nodes
-func (e *Exporter) export(ctx context.Context) {
-	e.tree.root.traversePost(e.tree, true, func(node *Node) bool {
-		exportNode := &ExportNode{
-			Key:     node.key,
-			Value:   node.value,
-			Version: node.version,
-			Height:  node.height,
+func (e *Exporter) export(parent context.Context, ctx context.Context) {
+	defer close(e.ch)
+	if err := parent.Err(); err != nil {
+		e.err = err
+		return
+	}
+	stopped := e.tree.root.traversePost(e.tree, true, func(node *Node) bool {
+		return !e.send(ctx, newExportNode(node))
+	})
+	if stopped {
+		e.err = parent.Err()
+	}
+}
+
+// newRangeExporter creates a new Exporter for a balanced tree with the given root hash, built
+// from the leaves of the tree with indexes from lo (inclusive) to hi (exclusive). Callers must
+// call Close() when done.
+func newRangeExporter(parent context.Context, tree *ImmutableTree, lo, hi int64, rootHash []byte) *Exporter {
+	ctx, cancel := context.WithCancel(parent)
+	exporter := &Exporter{
+		tree:     tree,
+		rootHash: rootHash,
+		size:     hi - lo,
+		ch:       make(chan *ExportNode, exportBufferSize),
+		cancel:   cancel,
+	}
+
+	tree.ndb.incrVersionReaders(tree.version)
+	go exporter.exportRange(parent, ctx, lo, hi)
+
+	return exporter
+}
+
+// exportRange exports the nodes of a balanced tree built from the leaves of the tree with
+// indexes from lo (inclusive) to hi (exclusive).
+func (e *Exporter) exportRange(parent context.Context, ctx context.Context, lo, hi int64) {
+	defer close(e.ch)
+	if err := parent.Err(); err != nil {
+		e.err = err
+		return
+	}
+	if lo >= hi {
+		return
+	}
+	_, _, stopped := buildBalancedTree(lo, hi, e.tree.rangeLeaves(lo), func(node *Node) bool {
+		return !e.send(ctx, newExportNode(node))
+	})
+	if stopped {
+		e.err = parent.Err()
+	}
+}
+
+// buildBalancedTree builds a balanced tree from the leaves with indexes from lo (inclusive) to hi
+// (exclusive), which must not be empty, calling fn for each node in post-order until it returns
+// true. The leaves are given by leaf, which must return hashable nodes, and inner nodes have the
+// highest version of their children. It returns the hashed root and its leftmost key, without
+// child pointers.
+func buildBalancedTree(lo, hi int64, leaf func(int64) *Node, fn func(*Node) bool) (root *Node, minKey []byte, stopped bool) {
+	if hi-lo == 1 {
+		node := leaf(lo)
+		node._hash()
+		return node, node.key, fn(node)
+	}
+
+	// The left subtree gets the extra leaf, if any, so the subtree heights differ by at most one.
+	mid := lo + (hi-lo+1)/2
+	left, minKey, stopped := buildBalancedTree(lo, mid, leaf, fn)
+	if stopped {
+		return nil, nil, true
+	}
+	right, rightKey, stopped := buildBalancedTree(mid, hi, leaf, fn)
+	if stopped {
+		return nil, nil, true
+	}
+	node := &Node{
+		key:       rightKey,
+		version:   left.version,
+		height:    maxInt8(left.height, right.height) + 1,
+		size:      left.size + right.size,
+		leftHash:  left.hash,
+		rightHash: right.hash,
+	}
+	if right.version > node.version {
+		node.version = right.version
+	}
+	node._hash()
+	return node, minKey, fn(node)
+}
+
+// exportSegment is a part of the post-order traversal of a parallel export: either a subtree
+// which is read by a worker, or a single node above the split depth.
+type exportSegment struct {
+	node    *Node
+	subtree bool
+}
+
+// exportParallel exports nodes like export(), but reads the subtrees rooted at splitDepth
+// concurrently with up to the given number of workers. The nodes above splitDepth are read
+// first, and the subtrees are then read in order, at most workers of them ahead of the
+// subtree being exported.
+func (e *Exporter) exportParallel(parent context.Context, ctx context.Context, splitDepth uint8, workers int) {
+	defer close(e.ch)
+	if err := parent.Err(); err != nil {
+		e.err = err
+		return
+	}
+
+	segments := e.splitSegments(e.tree.root, 0, splitDepth, nil)
+
+	// The feeder starts a worker for each subtree, in order, once a slot is released by the
+	// export of an earlier subtree, and passes the worker's channel on via started. Sends on
+	// started never block, since it holds at most one channel per taken slot. All goroutines
+	// must exit before closing the channel, since Close() releases the version once the channel
+	// is drained.
+	var wg sync.WaitGroup
+	slots := make(chan struct{}, workers)
+	started := make(chan chan *ExportNode, workers)
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for _, segment := range segments {
+			if !segment.subtree {
+				continue
+			}
+			select {
+			case slots <- struct{}{}:
+			case <-ctx.Done():
+				return
+			}
+			ch := make(chan *ExportNode, parallelExportBufferSize)
+			started <- ch
+			wg.Add(1)
+			go func(root *Node) {
+				defer wg.Done()
+				defer close(ch)
+				root.traversePost(e.tree, true, func(node *Node) bool {
+					select {
+					case ch <- newExportNode(node):
+						return false
+					case <-ctx.Done():
+						return true
+					}
+				})
+			}(segment.node)
 		}
+	}()
+	defer wg.Wait()
 
+	for _, segment := range segments {
+		if !segment.subtree {
+			if !e.send(ctx, newExportNode(segment.node)) {
+				e.err = parent.Err()
+				return
+			}
+			continue
+		}
+		var ch chan *ExportNode
 		select {
-		case e.ch <- exportNode:
-			return false
+		case ch = <-started:
 		case <-ctx.Done():
-			return true
+			e.err = parent.Err()
+			return
 		}
-	})
-	close(e.ch)
+		for exportNode := range ch {
+			if !e.send(ctx, exportNode) {
+				e.err = parent.Err()
+				return
+			}
+		}
+		// The worker may have stopped early due to cancellation.
+		if ctx.Err() != nil {
+			e.err = parent.Err()
+			return
+		}
+		<-slots
+	}
 }
 
-// Next fetches the next exported node, or returns ExportDone when done.
+// splitSegments appends the post-order segments of the tree rooted at node, at the given depth,
+// splitting it into subtrees at splitDepth.
+func (e *Exporter) splitSegments(node *Node, depth, splitDepth uint8, segments []*exportSegment) []*exportSegment {
+	if node == nil {
+		return segments
+	}
+	if node.isLeaf() {
+		return append(segments, &exportSegment{node: node})
+	}
+	if depth >= splitDepth {
+		return append(segments, &exportSegment{node: node, subtree: true})
+	}
+	segments = e.splitSegments(node.getLeftNode(e.tree), depth+1, splitDepth, segments)
+	segments = e.splitSegments(node.getRightNode(e.tree), depth+1, splitDepth, segments)
+	return append(segments, &exportSegment{node: node})
+}
+
+// send sends a node to the exporter channel, returning false if the context was cancelled.
+func (e *Exporter) send(ctx context.Context, exportNode *ExportNode) bool {
+	select {
+	case e.ch <- exportNode:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+func newExportNode(node *Node) *ExportNode {
+	return &ExportNode{
+		Key:     node.key,
+		Value:   node.value,
+		Version: node.version,
+		Height:  node.height,
+	}
+}
+
+// Next fetches the next exported node, or returns ExportDone when done. If the export was
+// created with ExportCtx() and the context was cancelled before the export completed, the
+// context error is returned instead.
 func (e *Exporter) Next() (*ExportNode, error) {
 	if exportNode, ok := <-e.ch; ok {
 		return exportNode, nil
 	}
+	if e.err != nil {
+		return nil, e.err
+	}
 	return nil, ExportDone
 }
 
@@ -1,6 +1,8 @@
 package iavl
 
 import (
+	"bytes"
+	"context"
 	"math"
 	"math/rand"
 	"testing"
@@ -240,6 +242,89 @@ func TestExporter_Close(t *testing.T) {
 	exporter.Close()
 }
 
+func TestExporter_Cancel(t *testing.T) {
+	tree := setupExportTreeSized(t, 4096)
+	ctx, cancel := context.WithCancel(context.Background())
+	exporter := tree.ExportCtx(ctx)
+	defer exporter.Close()
+
+	node, err := exporter.Next()
+	require.NoError(t, err)
+	require.NotNil(t, node)
+
+	cancel()
+	for err == nil {
+		_, err = exporter.Next()
+	}
+	require.Equal(t, context.Canceled, err)
+
+	exporter = tree.ExportCtx(ctx)
+	defer exporter.Close()
+	node, err = exporter.Next()
+	require.Equal(t, context.Canceled, err)
+	require.Nil(t, node)
+}
+
+// exportAll exports all nodes, returning them.
+func exportAll(t *testing.T, exporter *Exporter) []*ExportNode {
+	defer exporter.Close()
+	nodes := []*ExportNode{}
+	for {
+		node, err := exporter.Next()
+		if err == ExportDone {
+			return nodes
+		}
+		require.NoError(t, err)
+		nodes = append(nodes, node)
+	}
+}
+
+func TestExporter_Parallel(t *testing.T) {
+	testcases := map[string]*ImmutableTree{
+		"empty tree":  NewImmutableTree(db.NewMemDB(), 0),
+		"basic tree":  setupExportTreeBasic(t),
+		"sized tree":  setupExportTreeSized(t, 4096),
+		"random tree": setupExportTreeRandom(t),
+	}
+	for desc, tree := range testcases {
+		tree := tree
+		t.Run(desc, func(t *testing.T) {
+			expect := exportAll(t, tree.Export())
+			for _, splitDepth := range []uint8{0, 1, 5, 30} {
+				for _, workers := range []int{1, 4} {
+					actual := exportAll(t, tree.ExportParallel(context.Background(), splitDepth, workers))
+					require.Equal(t, expect, actual, "splitDepth %v workers %v", splitDepth, workers)
+				}
+			}
+		})
+	}
+}
+
+func TestExporter_ParallelCancel(t *testing.T) {
+	tree := setupExportTreeSized(t, 4096)
+	ctx, cancel := context.WithCancel(context.Background())
+	exporter := tree.ExportParallel(ctx, 4, 4)
+	defer exporter.Close()
+
+	node, err := exporter.Next()
+	require.NoError(t, err)
+	require.NotNil(t, node)
+
+	cancel()
+	for err == nil {
+		_, err = exporter.Next()
+	}
+	require.Equal(t, context.Canceled, err)
+
+	// Closing before the export is complete must stop the workers.
+	exporter = tree.ExportParallel(context.Background(), 4, 4)
+	_, err = exporter.Next()
+	require.NoError(t, err)
+	exporter.Close()
+	_, err = exporter.Next()
+	require.Equal(t, ExportDone, err)
+}
+
 func TestExporter_DeleteVersionErrors(t *testing.T) {
 	tree, err := NewMutableTree(db.NewMemDB(), 0)
 	require.NoError(t, err)
@@ -312,6 +397,111 @@ func TestExporter_PruneVersionIgnores(t *testing.T) {
 	require.Equal(t, []int{2, 4, 5}, tree.AvailableVersions())
 }
 
+func TestExporter_Range(t *testing.T) {
+	tree := setupDeltaTree(t, 6).ImmutableTree
+	testcases := map[string]struct{ start, end []byte }{
+		"full range":     {nil, nil},
+		"bounded":        {[]byte("key100"), []byte("key200")},
+		"missing bounds": {[]byte("key100x"), []byte("key199x")},
+		"open start":     {nil, []byte("key050")},
+		"open end":       {[]byte("key450"), nil},
+		"single key":     {[]byte("key100"), []byte("key100\x00")},
+		"empty":          {[]byte("key600"), nil},
+		"reversed":       {[]byte("key200"), []byte("key100")},
+	}
+	for desc, tc := range testcases {
+		tc := tc
+		t.Run(desc, func(t *testing.T) {
+			exporter, rootHash := tree.ExportRange(tc.start, tc.end)
+			nodes := exportAll(t, exporter)
+
+			newTree, err := NewMutableTree(db.NewMemDB(), 0)
+			require.NoError(t, err)
+			importer, err := newTree.Import(tree.Version())
+			require.NoError(t, err)
+			defer importer.Close()
+			for _, node := range nodes {
+				require.NoError(t, importer.Add(node))
+			}
+			require.NoError(t, importer.Commit())
+			require.Equal(t, rootHash, newTree.Hash())
+
+			// The new tree contains exactly the leaves in the range, with their versions.
+			count := int64(0)
+			tree.IterateRangeInclusive(tc.start, tc.end, true, func(key, value []byte, version int64) bool {
+				if tc.end != nil && string(key) == string(tc.end) {
+					return false
+				}
+				count++
+				_, newValue := newTree.Get(key)
+				require.Equal(t, value, newValue, "key %s", key)
+				return false
+			})
+			require.Equal(t, count, newTree.Size())
+			if count == 0 {
+				require.Empty(t, nodes)
+				require.Nil(t, rootHash)
+				return
+			}
+			require.EqualValues(t, 2*count-1, len(nodes))
+
+			versions := map[string]int64{}
+			tree.IterateRangeInclusive(nil, nil, true, func(key, value []byte, version int64) bool {
+				versions[string(key)] = version
+				return false
+			})
+			for _, node := range nodes {
+				if node.Height == 0 {
+					require.Equal(t, versions[string(node.Key)], node.Version)
+				}
+			}
+
+			// The new tree is balanced.
+			require.EqualValues(t, math.Ceil(math.Log2(float64(count))), newTree.Height())
+
+			// Snapshots of the range have the header of the new tree.
+			exporter, _ = tree.ExportRange(tc.start, tc.end)
+			defer exporter.Close()
+			var buf bytes.Buffer
+			require.NoError(t, WriteSnapshot(&buf, exporter))
+			snapshotTree, err := NewMutableTree(db.NewMemDB(), 0)
+			require.NoError(t, err)
+			header, err := snapshotTree.ImportSnapshot(&buf)
+			require.NoError(t, err)
+			require.Equal(t, rootHash, header.RootHash)
+			require.EqualValues(t, len(nodes), header.NodeCount)
+			require.Equal(t, rootHash, snapshotTree.Hash())
+		})
+	}
+
+	// A cancelled context aborts computing the root hash and the export.
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	exporter, rootHash := tree.ExportRangeCtx(ctx, nil, nil)
+	defer exporter.Close()
+	require.Nil(t, rootHash)
+	_, err := exporter.Next()
+	require.Equal(t, context.Canceled, err)
+}
+
+func BenchmarkExportParallel(b *testing.B) {
+	b.StopTimer()
+	tree := setupExportTreeSized(b, 4096)
+	b.StartTimer()
+	for n := 0; n < b.N; n++ {
+		exporter := tree.ExportParallel(context.Background(), 4, 4)
+		for {
+			_, err := exporter.Next()
+			if err == ExportDone {
+				break
+			} else if err != nil {
+				b.Error(err)
+			}
+		}
+		exporter.Close()
+	}
+}
+
 func BenchmarkExport(b *testing.B) {
 	b.StopTimer()
 	tree := setupExportTreeSized(b, 4096)
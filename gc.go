@@ -0,0 +1,227 @@
+package iavl
+
+import (
+	"encoding/binary"
+	"sort"
+
+	"github.com/pkg/errors"
+
+	dbm "github.com/tendermint/tm-db"
+)
+
+// GCOptions are options for GarbageCollect().
+type GCOptions struct {
+	// DryRun only reports what would be removed, without modifying the database.
+	DryRun bool
+
+	// MarkDB is an empty scratch database used to record the reachable nodes, so that memory use
+	// does not grow with the size of the database. It is left populated, and should be discarded
+	// afterwards. Defaults to an in-memory database.
+	MarkDB dbm.DB
+}
+
+// GCResult reports the outcome of GarbageCollect(). For a dry run, the swept counts are the
+// entries which would have been removed.
+type GCResult struct {
+	Roots        int    // number of roots marked from
+	Reachable    uint64 // number of nodes reachable from a root
+	Nodes        uint64 // number of node entries scanned
+	SweptNodes   uint64 // number of unreachable node entries
+	Orphans      uint64 // number of orphan records scanned
+	SweptOrphans uint64 // number of stale orphan records
+}
+
+// GarbageCollect removes the nodes of a database which are not reachable from any root, along
+// with stale orphan records, i.e. those whose node is unreachable or still reachable from a root
+// after the orphan's last version. Such entries may be left by bugs in earlier releases, and are
+// never removed by pruning.
+//
+// Only the persisted versions in db are considered, so it must be run offline, on a database
+// which is not opened by a tree. It fails without modifying the database if a node is missing,
+// since its descendants would not be marked, or if an aborted import is pending.
+func GarbageCollect(db dbm.DB, opts GCOptions) (*GCResult, error) {
+	ndb := newNodeDB(db, dbm.NewMemDB(), 0, nil)
+	pending, err := ndb.getImportCheckpointVersions()
+	if err != nil {
+		return nil, err
+	}
+	if len(pending) > 0 {
+		return nil, errors.Errorf("found aborted import of version %v, it must be resumed or cleaned up",
+			pending[0])
+	}
+
+	markDB := opts.MarkDB
+	if markDB == nil {
+		markDB = dbm.NewMemDB()
+	}
+	itr, err := markDB.Iterator(nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	empty := !itr.Valid()
+	itr.Close()
+	if !empty {
+		return nil, errors.New("mark database must be empty")
+	}
+
+	result := &GCResult{}
+	if err = gcMark(ndb, markDB, result); err != nil {
+		return nil, err
+	}
+
+	result.Nodes, result.SweptNodes, err = gcSweep(db, nodeKeyFormat.Key(), opts.DryRun, func(key []byte) (bool, error) {
+		var hash []byte
+		nodeKeyFormat.Scan(key, &hash)
+		marked, err := markDB.Has(hash)
+		return !marked, err
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "sweeping nodes")
+	}
+
+	result.Orphans, result.SweptOrphans, err = gcSweep(db, orphanKeyFormat.Key(), opts.DryRun, func(key []byte) (bool, error) {
+		var toVersion, fromVersion int64
+		var hash []byte
+		orphanKeyFormat.Scan(key, &toVersion, &fromVersion, &hash)
+		bz, err := markDB.Get(hash)
+		if err != nil {
+			return false, err
+		}
+		return bz == nil || int64(binary.BigEndian.Uint64(bz)) > toVersion, nil
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "sweeping orphans")
+	}
+	return result, nil
+}
+
+// gcMark records the nodes reachable from each root of the database in markDB, by hash with the
+// latest version they are reachable from. Roots are marked from the latest version down, and
+// subtrees which are already marked are skipped, so each node is only read once.
+func gcMark(ndb *nodeDB, markDB dbm.DB, result *GCResult) error {
+	type root struct {
+		version int64
+		hash    []byte
+	}
+	roots := []root{}
+	traversePrefixFromDB(ndb.snapshotDB, rootKeyFormat.Key(), func(k, v []byte) {
+		var version int64
+		rootKeyFormat.Scan(k, &version)
+		roots = append(roots, root{version: version, hash: v})
+	})
+	sort.Slice(roots, func(i, j int) bool { return roots[i].version > roots[j].version })
+	result.Roots = len(roots)
+
+	batch := markDB.NewBatch()
+	defer func() { batch.Close() }()
+	var batchSize uint32
+	for _, r := range roots {
+		// Databases may retain the value slice, so each version needs its own.
+		mark := make([]byte, int64Size)
+		binary.BigEndian.PutUint64(mark, uint64(r.version))
+		pending := [][]byte{}
+		if len(r.hash) > 0 {
+			pending = append(pending, r.hash)
+		}
+		for len(pending) > 0 {
+			hash := pending[len(pending)-1]
+			pending = pending[:len(pending)-1]
+			if marked, err := markDB.Has(hash); err != nil {
+				return err
+			} else if marked {
+				continue
+			}
+
+			bz, err := ndb.snapshotDB.Get(ndb.nodeKey(hash))
+			if err != nil {
+				return err
+			}
+			if bz == nil {
+				return errors.Errorf("node %X reachable from version %v is missing", hash, r.version)
+			}
+			node, err := MakeNode(bz)
+			if err != nil {
+				return errors.Wrapf(err, "decoding node %X", hash)
+			}
+			if !node.isLeaf() {
+				pending = append(pending, node.leftHash, node.rightHash)
+			}
+
+			batch.Set(hash, mark)
+			result.Reachable++
+			batchSize++
+			if batchSize >= maxBatchSize {
+				if err = batch.Write(); err != nil {
+					return err
+				}
+				batch.Close()
+				batch = markDB.NewBatch()
+				batchSize = 0
+			}
+		}
+
+		// Subtrees are shared between versions, but never within one, so the marks only need to
+		// be readable once the version is done.
+		if err := batch.Write(); err != nil {
+			return err
+		}
+		batch.Close()
+		batch = markDB.NewBatch()
+		batchSize = 0
+	}
+	return nil
+}
+
+// gcSweep removes the entries with the given prefix for which stale returns true, unless dryRun
+// is set. The database is scanned in chunks, and the removals of each chunk are written once its
+// iterator is closed. It returns the number of entries scanned and stale.
+func gcSweep(db dbm.DB, prefix []byte, dryRun bool, stale func(key []byte) (bool, error)) (scanned, swept uint64, err error) {
+	start, end := prefix, cpIncr(prefix)
+	for start != nil {
+		var deletes [][]byte
+		deletes, start, err = gcScan(db, start, end, &scanned, stale)
+		if err != nil {
+			return 0, 0, err
+		}
+		swept += uint64(len(deletes))
+		if dryRun || len(deletes) == 0 {
+			continue
+		}
+		batch := db.NewBatch()
+		for _, key := range deletes {
+			batch.Delete(key)
+		}
+		err = batch.WriteSync()
+		batch.Close()
+		if err != nil {
+			return 0, 0, err
+		}
+	}
+	return scanned, swept, nil
+}
+
+// gcScan scans the entries from start until end or maxBatchSize stale entries are found, and
+// returns the stale keys along with the key to continue from, or nil if done.
+func gcScan(db dbm.DB, start, end []byte, scanned *uint64, stale func(key []byte) (bool, error)) ([][]byte, []byte, error) {
+	itr, err := db.Iterator(start, end)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer itr.Close()
+
+	deletes := [][]byte{}
+	for ; itr.Valid(); itr.Next() {
+		if len(deletes) >= maxBatchSize {
+			return deletes, append([]byte{}, itr.Key()...), nil
+		}
+		*scanned++
+		ok, err := stale(itr.Key())
+		if err != nil {
+			return nil, nil, err
+		}
+		if ok {
+			deletes = append(deletes, append([]byte{}, itr.Key()...))
+		}
+	}
+	return deletes, nil, itr.Error()
+}
@@ -0,0 +1,111 @@
+package iavl
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	db "github.com/tendermint/tm-db"
+)
+
+// setupGCTree returns a database with several versions, unreachable nodes and stale orphans, along
+// with the exports of its versions and the keys of the stale orphans which it adds.
+func setupGCTree(t *testing.T) (db.DB, map[int64][]*ExportNode, [][]byte) {
+	tree := setupDeltaTree(t, 8)
+	memDB := tree.ndb.snapshotDB
+
+	// Deleting versions in a single batch leaves unreachable nodes and stale orphans behind.
+	require.NoError(t, tree.DeleteVersions(2, 3, 4))
+
+	// An orphan record of a missing node, and one of a node which is still in the latest version.
+	stale := [][]byte{
+		orphanKeyFormat.Key(int64(6), int64(5), bytes.Repeat([]byte{1}, hashSize)),
+		orphanKeyFormat.Key(int64(6), tree.root.version, tree.root.hash),
+	}
+	for _, key := range stale {
+		require.NoError(t, memDB.Set(key, []byte{}))
+	}
+
+	versions := map[int64][]*ExportNode{}
+	for _, version := range tree.AvailableVersions() {
+		itree, err := tree.GetImmutable(int64(version))
+		require.NoError(t, err)
+		versions[int64(version)] = exportAll(t, itree.Export())
+	}
+	return memDB, versions, stale
+}
+
+func TestGarbageCollect(t *testing.T) {
+	memDB, versions, stale := setupGCTree(t)
+	before := dumpDB(t, memDB)
+
+	// A dry run reports the garbage without removing it.
+	result, err := GarbageCollect(memDB, GCOptions{DryRun: true})
+	require.NoError(t, err)
+	require.Equal(t, len(versions), result.Roots)
+	require.EqualValues(t, countKeys(memDB, nodeKeyFormat.Key()), result.Nodes)
+	require.EqualValues(t, countKeys(memDB, orphanKeyFormat.Key()), result.Orphans)
+	require.Greater(t, result.SweptNodes, uint64(0))
+	require.Greater(t, result.SweptOrphans, uint64(len(stale)))
+	require.Equal(t, result.Nodes-result.SweptNodes, result.Reachable)
+	require.Equal(t, before, dumpDB(t, memDB))
+
+	markDB := db.NewMemDB()
+	swept, err := GarbageCollect(memDB, GCOptions{MarkDB: markDB})
+	require.NoError(t, err)
+	require.Equal(t, result, swept)
+	require.EqualValues(t, result.Reachable, countKeys(memDB, nodeKeyFormat.Key()))
+	require.EqualValues(t, result.Orphans-result.SweptOrphans, countKeys(memDB, orphanKeyFormat.Key()))
+	for _, key := range stale {
+		ok, err := memDB.Has(key)
+		require.NoError(t, err)
+		require.False(t, ok)
+	}
+
+	// A non-empty mark database is rejected.
+	_, err = GarbageCollect(memDB, GCOptions{MarkDB: markDB})
+	require.Error(t, err)
+
+	// All versions are intact, and there is nothing left to collect.
+	tree, err := NewMutableTree(memDB, 0)
+	require.NoError(t, err)
+	_, err = tree.Load()
+	require.NoError(t, err)
+	for version, nodes := range versions {
+		itree, err := tree.GetImmutable(version)
+		require.NoError(t, err)
+		require.Equal(t, nodes, exportAll(t, itree.Export()))
+	}
+	result, err = GarbageCollect(memDB, GCOptions{})
+	require.NoError(t, err)
+	require.Zero(t, result.SweptNodes)
+	require.Zero(t, result.SweptOrphans)
+
+	// Versions can still be saved and deleted.
+	tree.Set([]byte("new"), []byte("value"))
+	_, _, err = tree.SaveVersion()
+	require.NoError(t, err)
+	require.NoError(t, tree.DeleteVersion(5))
+}
+
+func TestGarbageCollect_Errors(t *testing.T) {
+	memDB, _, _ := setupGCTree(t)
+
+	// A missing node fails the collection without modifying the database.
+	tree, err := NewMutableTree(memDB, 0)
+	require.NoError(t, err)
+	_, err = tree.Load()
+	require.NoError(t, err)
+	require.NoError(t, memDB.Delete(nodeKeyFormat.Key(tree.root.leftHash)))
+	before := dumpDB(t, memDB)
+	_, err = GarbageCollect(memDB, GCOptions{})
+	require.Error(t, err)
+	require.Equal(t, before, dumpDB(t, memDB))
+
+	// Pending imports must be resumed or cleaned up first.
+	pending := db.NewMemDB()
+	require.NoError(t, pending.Set(importKeyFormat.Key(int64(1)), []byte{}))
+	_, err = GarbageCollect(pending, GCOptions{})
+	require.Error(t, err)
+}
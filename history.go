@@ -0,0 +1,401 @@
+package iavl
+
+import (
+	"bytes"
+	"context"
+
+	"github.com/pkg/errors"
+
+	db "github.com/tendermint/tm-db"
+)
+
+// HistoryVersion starts a version in a history export. It is followed by the DeltaNodes of the
+// version, relative to the previous version of the export.
+type HistoryVersion struct {
+	Version  int64
+	RootHash []byte
+}
+
+// HistoryOrphan is an orphan record in a history export: the node with the given hash was
+// created in FromVersion, and ToVersion is the last exported version which contains it.
+type HistoryOrphan struct {
+	Hash        []byte
+	FromVersion int64
+	ToVersion   int64
+}
+
+// HistoryItem is an item of a history export. Exactly one of its fields is set.
+type HistoryItem struct {
+	Version  *HistoryVersion
+	Node     *DeltaNode
+	Orphan   *HistoryOrphan
+	Metadata *VersionMetadata
+}
+
+// HistoryExporter exports all available versions of a tree, along with its orphan records and
+// version metadata. It is created by MutableTree.ExportHistory(), and callers must call Close()
+// when done.
+//
+// Versions are exported in ascending order, each as a HistoryVersion followed by a delta against
+// the previous version as exported by DeltaExporter, so that nodes shared between versions are
+// only exported once. The orphan records follow, and then the metadata of all versions,
+// including deleted ones. This order must be preserved when importing with
+// MutableTree.ImportHistory().
+//
+// The orphan records are not copied from the database, where they may end at versions which have
+// been pruned, but derived from the exported versions: there is one for each node which is not
+// part of the latest version, ending at the last exported version which contains it.
+type HistoryExporter struct {
+	ndb      *nodeDB
+	versions []*ImmutableTree
+	ch       chan *HistoryItem
+	cancel   context.CancelFunc
+	err      error // set by the export goroutine before closing ch, if aborted
+}
+
+// ExportHistory returns an exporter for the complete history of the tree.
+func (tree *MutableTree) ExportHistory() (*HistoryExporter, error) {
+	return tree.ExportHistoryCtx(context.Background())
+}
+
+// ExportHistoryCtx is like ExportHistory, but the export is aborted when the context is cancelled
+// or its deadline expires, in which case HistoryExporter.Next() returns the context error.
+func (tree *MutableTree) ExportHistoryCtx(ctx context.Context) (*HistoryExporter, error) {
+	versions := make([]*ImmutableTree, 0, len(tree.versions))
+	for _, version := range tree.AvailableVersions() {
+		t, err := tree.GetImmutable(int64(version))
+		if err != nil {
+			return nil, errors.Wrapf(err, "loading version %v", version)
+		}
+		versions = append(versions, t)
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	exporter := &HistoryExporter{
+		ndb:      tree.ndb,
+		versions: versions,
+		ch:       make(chan *HistoryItem, exportBufferSize),
+		cancel:   cancel,
+	}
+	for _, t := range versions {
+		tree.ndb.incrVersionReaders(t.version)
+	}
+	go exporter.export(ctx)
+
+	return exporter, nil
+}
+
+// export exports the history.
+func (e *HistoryExporter) export(ctx context.Context) {
+	defer close(e.ch)
+	send := func(node *DeltaNode) bool { return e.send(ctx, &HistoryItem{Node: node}) }
+
+	base := &ImmutableTree{ndb: e.ndb}
+	for _, target := range e.versions {
+		if !e.send(ctx, &HistoryItem{Version: &HistoryVersion{Version: target.version, RootHash: target.Hash()}}) {
+			e.err = ctx.Err()
+			return
+		}
+		if root := target.root; root != nil && !exportDelta(base, target, root, leftmostKey(target, root), send) {
+			e.err = ctx.Err()
+			return
+		}
+		base = target
+	}
+
+	for i := 0; i+1 < len(e.versions); i++ {
+		base := e.versions[i]
+		ok := deltaOrphans(base, e.versions[i+1], func(node *Node) bool {
+			return e.send(ctx, &HistoryItem{Orphan: &HistoryOrphan{
+				Hash:        node.hash,
+				FromVersion: node.version,
+				ToVersion:   base.version,
+			}})
+		})
+		if !ok {
+			e.err = ctx.Err()
+			return
+		}
+	}
+
+	items, err := e.metadata()
+	if err != nil {
+		e.err = err
+		return
+	}
+	for _, item := range items {
+		if !e.send(ctx, item) {
+			e.err = ctx.Err()
+			return
+		}
+	}
+}
+
+// metadata returns the version metadata.
+func (e *HistoryExporter) metadata() ([]*HistoryItem, error) {
+	items := []*HistoryItem{}
+	var err error
+	traversePrefixFromDB(e.ndb.snapshotDB, metadataKeyFormat.Key(), func(k, v []byte) {
+		if err != nil {
+			return
+		}
+		var vm *VersionMetadata
+		if vm, err = unmarshalVersionMetadata(v); err != nil {
+			err = errors.Wrapf(err, "decoding metadata %X", k)
+			return
+		}
+		items = append(items, &HistoryItem{Metadata: vm})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+// send sends an item to the export channel, returning false if the context was cancelled.
+func (e *HistoryExporter) send(ctx context.Context, item *HistoryItem) bool {
+	select {
+	case e.ch <- item:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// Next fetches the next exported item, or returns ExportDone when done.
+func (e *HistoryExporter) Next() (*HistoryItem, error) {
+	if item, ok := <-e.ch; ok {
+		return item, nil
+	}
+	if e.err != nil {
+		return nil, e.err
+	}
+	return nil, ExportDone
+}
+
+// Close closes the exporter. It is safe to call multiple times.
+func (e *HistoryExporter) Close() {
+	e.cancel()
+	for range e.ch { // drain channel
+	}
+	for _, t := range e.versions {
+		e.ndb.decrVersionReaders(t.version)
+	}
+	e.versions = nil
+}
+
+// HistoryImporter imports a history export into an empty tree. It is created by
+// MutableTree.ImportHistory(). Items must be added in the order returned by HistoryExporter, and
+// callers must call Close() when done.
+//
+// As with Importer, all versions are written to the snapshot database. Nodes are written as each
+// version is completed, since later versions refer to them, but the version roots are kept in
+// memory and written by Commit(), so no version is visible until then. Unlike Importer, history
+// imports can't be resumed: if an import is aborted, the database must be discarded.
+//
+// HistoryImporter is not concurrency-safe, it is the caller's responsibility to ensure the tree
+// is not modified while performing an import.
+type HistoryImporter struct {
+	tree      *MutableTree
+	batch     db.Batch
+	batchSize uint32
+	base      *ImmutableTree  // the previous version, which the delta of the current one refers to
+	current   *HistoryVersion // the version being imported, if any
+	stack     deltaStack
+	roots     map[int64][]byte // the root hashes of the imported versions, written by Commit()
+	latest    int64
+}
+
+// ImportHistory returns an importer for a history exported by MutableTree.ExportHistory(). The
+// database must be empty.
+func (tree *MutableTree) ImportHistory() (*HistoryImporter, error) {
+	if _, err := validateImport(tree, 0, false); err != nil {
+		return nil, err
+	}
+	pending, err := tree.ndb.getImportCheckpointVersions()
+	if err != nil {
+		return nil, err
+	}
+	if len(pending) > 0 {
+		return nil, errors.Errorf("found aborted import of version %v, it must be resumed or cleaned up",
+			pending[0])
+	}
+	return &HistoryImporter{
+		tree:  tree,
+		batch: tree.ndb.snapshotDB.NewBatch(),
+		base:  &ImmutableTree{ndb: tree.ndb},
+		roots: map[int64][]byte{},
+	}, nil
+}
+
+// Add adds an item of the history.
+func (i *HistoryImporter) Add(item *HistoryItem) error {
+	if i.tree == nil {
+		return ErrNoImport
+	}
+	if item == nil {
+		return errors.New("history item cannot be nil")
+	}
+
+	switch {
+	case item.Version != nil:
+		if err := i.finishVersion(); err != nil {
+			return err
+		}
+		if item.Version.Version <= i.latest {
+			return errors.Errorf("version %v must be greater than the previous version %v",
+				item.Version.Version, i.latest)
+		}
+		i.current = item.Version
+		i.latest = item.Version.Version
+		return nil
+
+	case item.Node != nil:
+		if i.current == nil {
+			return errors.New("delta node must follow a version")
+		}
+		node, err := i.stack.add(i.tree.ndb, i.base, item.Node, i.current.Version)
+		if err != nil {
+			return errors.Wrapf(err, "importing version %v", i.current.Version)
+		}
+		if item.Node.Node == nil {
+			return nil
+		}
+		var buf bytes.Buffer
+		if err = node.writeBytes(&buf); err != nil {
+			return err
+		}
+		return i.set(i.tree.ndb.nodeKey(node.hash), buf.Bytes())
+
+	case item.Orphan != nil:
+		if err := i.finishVersion(); err != nil {
+			return err
+		}
+		orphan := item.Orphan
+		if len(orphan.Hash) != hashSize || orphan.FromVersion > orphan.ToVersion {
+			return errors.Errorf("invalid orphan record %X from version %v to %v",
+				orphan.Hash, orphan.FromVersion, orphan.ToVersion)
+		}
+		if _, ok := i.roots[orphan.ToVersion]; !ok || orphan.ToVersion == i.latest {
+			return errors.Errorf("orphan record %X must end at an imported version before %v, got %v",
+				orphan.Hash, i.latest, orphan.ToVersion)
+		}
+		return i.set(i.tree.ndb.orphanKey(orphan.FromVersion, orphan.ToVersion, orphan.Hash), orphan.Hash)
+
+	case item.Metadata != nil:
+		if err := i.finishVersion(); err != nil {
+			return err
+		}
+		// The nodes of all imported versions are written to the snapshot database.
+		vm := *item.Metadata
+		if _, ok := i.roots[vm.Version]; ok {
+			vm.Snapshot = true
+		}
+		bz, err := vm.marshal()
+		if err != nil {
+			return err
+		}
+		return i.set(metadataKeyFormat.Key(vm.Version), bz)
+
+	default:
+		return errors.New("history item must have a version, node, orphan or metadata")
+	}
+}
+
+// set adds a write to the batch, flushing it to the database if it is full.
+func (i *HistoryImporter) set(key, value []byte) error {
+	i.batch.Set(key, value)
+	i.batchSize++
+	if i.batchSize >= maxBatchSize {
+		return i.flush()
+	}
+	return nil
+}
+
+// flush writes the batch to the database.
+func (i *HistoryImporter) flush() error {
+	if err := i.batch.Write(); err != nil {
+		return err
+	}
+	i.batch.Close()
+	i.batch = i.tree.ndb.snapshotDB.NewBatch()
+	i.batchSize = 0
+	return nil
+}
+
+// finishVersion checks the root hash of the current version, if any, and records its root. Its
+// nodes are flushed to the database, so that the next version can refer to them.
+func (i *HistoryImporter) finishVersion() error {
+	if i.current == nil {
+		return nil
+	}
+	version := i.current.Version
+
+	base := &ImmutableTree{ndb: i.tree.ndb, version: version}
+	rootHash := []byte{}
+	switch len(i.stack.nodes) {
+	case 0:
+	case 1:
+		base.root = i.stack.nodes[0]
+		rootHash = base.root.hash
+	default:
+		return errors.Errorf("invalid node structure for version %v, found stack size %v",
+			version, len(i.stack.nodes))
+	}
+	if !bytes.Equal(rootHash, i.current.RootHash) {
+		return errors.Wrapf(ErrRootHashMismatch, "version %v has root hash %X, expected %X",
+			version, rootHash, i.current.RootHash)
+	}
+
+	if err := i.flush(); err != nil {
+		return err
+	}
+	i.base = base
+	i.current = nil
+	i.stack = deltaStack{}
+	i.roots[version] = rootHash
+	return nil
+}
+
+// Commit finalizes the import by writing any outstanding items and the roots of all imported
+// versions to the database, and loading the latest imported version. It can only be called once,
+// and calls Close() internally.
+func (i *HistoryImporter) Commit() error {
+	if i.tree == nil {
+		return ErrNoImport
+	}
+	if err := i.finishVersion(); err != nil {
+		return err
+	}
+	// The roots are written in a single batch, so that all versions become visible at once.
+	for version, rootHash := range i.roots {
+		i.batch.Set(i.tree.ndb.rootKey(version), rootHash)
+	}
+	if err := i.batch.WriteSync(); err != nil {
+		return err
+	}
+
+	tree := i.tree
+	latest := i.latest
+	i.Close()
+	if latest == 0 {
+		return nil
+	}
+	tree.ndb.resetLatestVersion(latest)
+	_, err := tree.LoadVersion(latest)
+	return err
+}
+
+// Close frees all resources. It is safe to call multiple times. Nodes of completed versions may
+// already have been written to the database, but no version is visible unless Commit()
+// succeeded.
+func (i *HistoryImporter) Close() {
+	if i.batch != nil {
+		i.batch.Close()
+	}
+	i.batch = nil
+	i.tree = nil
+	i.base = nil
+	i.stack = deltaStack{}
+	i.roots = nil
+}
@@ -0,0 +1,224 @@
+package iavl
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	db "github.com/tendermint/tm-db"
+)
+
+func exportAllHistory(t *testing.T, tree *MutableTree) []*HistoryItem {
+	exporter, err := tree.ExportHistory()
+	require.NoError(t, err)
+	defer exporter.Close()
+	items := []*HistoryItem{}
+	for {
+		item, err := exporter.Next()
+		if err == ExportDone {
+			return items
+		}
+		require.NoError(t, err)
+		items = append(items, item)
+	}
+}
+
+func importAllHistory(t *testing.T, tree *MutableTree, items []*HistoryItem) error {
+	importer, err := tree.ImportHistory()
+	require.NoError(t, err)
+	defer importer.Close()
+	for _, item := range items {
+		if err := importer.Add(item); err != nil {
+			return err
+		}
+	}
+	return importer.Commit()
+}
+
+func TestHistory(t *testing.T) {
+	tree := setupDeltaTree(t, 10)
+	for _, version := range []int64{2, 5, 6} {
+		require.NoError(t, tree.DeleteVersion(version))
+	}
+	sourceDB := tree.ndb.snapshotDB
+
+	items := exportAllHistory(t, tree)
+	versions, nodes, orphans, metadata := 0, 0, 0, 0
+	for _, item := range items {
+		switch {
+		case item.Version != nil:
+			versions++
+		case item.Node != nil:
+			nodes++
+		case item.Orphan != nil:
+			orphans++
+		case item.Metadata != nil:
+			metadata++
+		}
+	}
+	require.Equal(t, len(tree.AvailableVersions()), versions)
+	require.Equal(t, len(tree.ndb.nodesFromDB(sourceDB)), nodes-countDeltaHashes(items))
+	require.Equal(t, countKeys(sourceDB, orphanKeyFormat.Key()), orphans)
+	require.Equal(t, 10, metadata)
+
+	memDB := db.NewMemDB()
+	newTree, err := NewMutableTree(memDB, 0)
+	require.NoError(t, err)
+	require.NoError(t, importAllHistory(t, newTree, items))
+
+	// The imported database is identical to the source, and behaves identically.
+	require.Equal(t, dumpDB(t, sourceDB), dumpDB(t, memDB))
+	require.Equal(t, tree.AvailableVersions(), newTree.AvailableVersions())
+	require.Equal(t, tree.Version(), newTree.Version())
+	for _, version := range tree.AvailableVersions() {
+		expect, err := tree.GetImmutable(int64(version))
+		require.NoError(t, err)
+		actual, err := newTree.GetImmutable(int64(version))
+		require.NoError(t, err)
+		requireSameTree(t, expect, actual)
+
+		for _, key := range [][]byte{[]byte("key001"), []byte("key100"), []byte("key300")} {
+			_, expectValue := tree.GetVersioned(key, int64(version))
+			_, actualValue := newTree.GetVersioned(key, int64(version))
+			require.Equal(t, expectValue, actualValue)
+
+			expectValue, expectProof, err := tree.GetVersionedWithProof(key, int64(version))
+			require.NoError(t, err)
+			actualValue, actualProof, err := newTree.GetVersionedWithProof(key, int64(version))
+			require.NoError(t, err)
+			require.Equal(t, expectValue, actualValue)
+			require.Equal(t, expectProof.ComputeRootHash(), actualProof.ComputeRootHash())
+			require.NoError(t, actualProof.Verify(expect.Hash()))
+		}
+	}
+
+	for _, version := range []int64{3, 1} {
+		require.NoError(t, tree.DeleteVersion(version))
+		require.NoError(t, newTree.DeleteVersion(version))
+	}
+	require.Equal(t, dumpDB(t, sourceDB), dumpDB(t, memDB))
+
+	// An empty history imports as an empty tree.
+	emptyTree, err := NewMutableTree(db.NewMemDB(), 0)
+	require.NoError(t, err)
+	require.NoError(t, importAllHistory(t, emptyTree, exportAllHistory(t, emptyTree)))
+	require.Empty(t, emptyTree.AvailableVersions())
+}
+
+func TestHistory_Pruned(t *testing.T) {
+	// The source keeps every 2nd version and the 3 most recent ones, so orphan records in the
+	// snapshot database end at the previous snapshot version, not the previous version.
+	tree, err := NewMutableTreeWithOpts(db.NewMemDB(), db.NewMemDB(), 0, PruningOptions(2, 3))
+	require.NoError(t, err)
+	for version := 1; version <= 6; version++ {
+		for i := 0; i < 8; i++ {
+			tree.Set([]byte(fmt.Sprintf("key%v", i)), []byte(fmt.Sprintf("value%v-%v", version, i)))
+		}
+		switch version {
+		case 4:
+			tree.Set([]byte("b"), []byte("created"))
+		case 6:
+			tree.Set([]byte("b"), []byte("overwritten"))
+		}
+		_, _, err = tree.SaveVersion()
+		require.NoError(t, err)
+	}
+	require.Equal(t, []int{2, 4, 5, 6}, tree.AvailableVersions())
+
+	items := exportAllHistory(t, tree)
+	newTree, err := NewMutableTree(db.NewMemDB(), 0)
+	require.NoError(t, err)
+	require.NoError(t, importAllHistory(t, newTree, items))
+	require.Equal(t, tree.AvailableVersions(), newTree.AvailableVersions())
+
+	// Every node has at most one orphan record, ending at an exported version.
+	seen := map[string]bool{}
+	for _, item := range items {
+		if item.Orphan != nil {
+			require.False(t, seen[string(item.Orphan.Hash)], "orphan %X", item.Orphan.Hash)
+			seen[string(item.Orphan.Hash)] = true
+			require.True(t, newTree.VersionExists(item.Orphan.ToVersion))
+		}
+	}
+
+	// Deleting versions in the target keeps the remaining ones intact.
+	for _, version := range []int64{4, 2} {
+		require.NoError(t, newTree.DeleteVersion(version))
+		for _, remaining := range newTree.AvailableVersions() {
+			expect, err := tree.GetImmutable(int64(remaining))
+			require.NoError(t, err)
+			actual, err := newTree.GetImmutable(int64(remaining))
+			require.NoError(t, err)
+			requireSameTree(t, expect, actual)
+		}
+	}
+	_, value := newTree.GetVersioned([]byte("b"), 5)
+	require.Equal(t, []byte("created"), value)
+}
+
+// countDeltaHashes returns the number of subtree hashes in a history export.
+func countDeltaHashes(items []*HistoryItem) int {
+	count := 0
+	for _, item := range items {
+		if item.Node != nil && item.Node.Hash != nil {
+			count++
+		}
+	}
+	return count
+}
+
+func TestHistory_Invalid(t *testing.T) {
+	tree := setupDeltaTree(t, 3)
+	items := exportAllHistory(t, tree)
+
+	nonEmpty := setupDeltaTree(t, 1)
+	_, err := nonEmpty.ImportHistory()
+	require.Error(t, err)
+
+	// A changed root hash is detected when the next version starts.
+	newTree, err := NewMutableTree(db.NewMemDB(), 0)
+	require.NoError(t, err)
+	tampered := append([]*HistoryItem{}, items...)
+	tampered[0] = &HistoryItem{Version: &HistoryVersion{Version: 1, RootHash: []byte{1, 2, 3}}}
+	err = importAllHistory(t, newTree, tampered)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), ErrRootHashMismatch.Error())
+	require.Empty(t, newTree.AvailableVersions())
+
+	importer, err := newTree.ImportHistory()
+	require.NoError(t, err)
+	defer importer.Close()
+	require.Error(t, importer.Add(&HistoryItem{}))
+	require.Error(t, importer.Add(items[1]), "node before version")
+	require.NoError(t, importer.Add(items[0]))
+	require.Error(t, importer.Add(items[0]), "repeated version")
+	require.Error(t, importer.Add(&HistoryItem{Orphan: &HistoryOrphan{Hash: []byte{1}, FromVersion: 1, ToVersion: 2}}))
+	require.Error(t, importer.Add(&HistoryItem{Orphan: &HistoryOrphan{Hash: make([]byte, hashSize), FromVersion: 1, ToVersion: 5}}),
+		"orphan of a version which was not imported")
+
+	// Completed versions are not visible until the import is committed.
+	memDB := db.NewMemDB()
+	newTree, err = NewMutableTree(memDB, 0)
+	require.NoError(t, err)
+	importer, err = newTree.ImportHistory()
+	require.NoError(t, err)
+	versions := 0
+	for _, item := range items {
+		if item.Version != nil {
+			versions++
+		}
+		if versions == 3 {
+			break
+		}
+		require.NoError(t, importer.Add(item))
+	}
+	importer.Close()
+	require.NotZero(t, countKeys(memDB, nodeKeyFormat.Key()))
+	require.Zero(t, countKeys(memDB, rootKeyFormat.Key()))
+	newTree, err = NewMutableTree(memDB, 0)
+	require.NoError(t, err)
+	version, err := newTree.Load()
+	require.NoError(t, err)
+	require.Zero(t, version)
+}
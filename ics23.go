@@ -0,0 +1,200 @@
+package iavl
+
+import (
+	"bytes"
+	"crypto/sha256"
+
+	"github.com/pkg/errors"
+
+	"github.com/tendermint/iavl/internal/encoding"
+)
+
+// IavlSpec is the ICS23 ProofSpec of IAVL trees. Proofs generated by GetMembershipProof(),
+// GetNonMembershipProof() and GetBatchProof() must be verified against it.
+var IavlSpec = &ProofSpec{
+	LeafSpec: &LeafOp{
+		Prefix:       []byte{0},
+		Hash:         HashOp_SHA256,
+		PrehashValue: HashOp_SHA256,
+		Length:       LengthOp_VAR_PROTO,
+	},
+	InnerSpec: &InnerSpec{
+		ChildOrder:      []int32{0, 1},
+		MinPrefixLength: 4,
+		MaxPrefixLength: 12,
+		ChildSize:       33, // (with length byte)
+		Hash:            HashOp_SHA256,
+	},
+}
+
+// GetMembershipProof returns an ICS23 existence proof for the given key. An error is returned
+// if the key does not exist.
+func (t *ImmutableTree) GetMembershipProof(key []byte) (*CommitmentProof, error) {
+	exist, err := t.createExistenceProof(key)
+	if err != nil {
+		return nil, err
+	}
+	return &CommitmentProof{
+		Proof: &CommitmentProof_Exist{Exist: exist},
+	}, nil
+}
+
+// GetNonMembershipProof returns an ICS23 non-existence proof for the given key, consisting of
+// existence proofs for its neighbouring keys. An error is returned if the key exists, or if the
+// tree is empty since absence in an empty tree can't be expressed as an ICS23 proof.
+func (t *ImmutableTree) GetNonMembershipProof(key []byte) (*CommitmentProof, error) {
+	nonexist, err := t.createNonExistenceProof(key)
+	if err != nil {
+		return nil, err
+	}
+	return &CommitmentProof{
+		Proof: &CommitmentProof_Nonexist{Nonexist: nonexist},
+	}, nil
+}
+
+// GetBatchProof returns an ICS23 batch proof for the given keys, containing an existence proof
+// for each existing key and a non-existence proof for each absent key.
+func (t *ImmutableTree) GetBatchProof(keys [][]byte) (*CommitmentProof, error) {
+	entries := make([]*BatchEntry, 0, len(keys))
+	for _, key := range keys {
+		if t.Has(key) {
+			exist, err := t.createExistenceProof(key)
+			if err != nil {
+				return nil, err
+			}
+			entries = append(entries, &BatchEntry{Proof: &BatchEntry_Exist{Exist: exist}})
+		} else {
+			nonexist, err := t.createNonExistenceProof(key)
+			if err != nil {
+				return nil, err
+			}
+			entries = append(entries, &BatchEntry{Proof: &BatchEntry_Nonexist{Nonexist: nonexist}})
+		}
+	}
+	return &CommitmentProof{
+		Proof: &CommitmentProof_Batch{Batch: &BatchProof{Entries: entries}},
+	}, nil
+}
+
+func (t *ImmutableTree) createExistenceProof(key []byte) (*ExistenceProof, error) {
+	if t.root == nil {
+		return nil, errors.Wrap(ErrInvalidInputs, "tree is empty")
+	}
+	t.root.hashWithCount() // Ensure that all hashes are calculated.
+
+	path, node, err := t.root.PathToLeaf(t, key)
+	if err != nil {
+		return nil, errors.Wrapf(err, "cannot prove existence of key %X", key)
+	}
+	leaf, err := convertLeafOp(node.version)
+	if err != nil {
+		return nil, err
+	}
+	inners, err := convertInnerOps(path)
+	if err != nil {
+		return nil, err
+	}
+	return &ExistenceProof{
+		Key:   node.key,
+		Value: node.value,
+		Leaf:  leaf,
+		Path:  inners,
+	}, nil
+}
+
+func (t *ImmutableTree) createNonExistenceProof(key []byte) (*NonExistenceProof, error) {
+	if t.root == nil {
+		return nil, errors.Wrap(ErrInvalidInputs, "cannot prove absence in an empty tree")
+	}
+	index, value := t.Get(key)
+	if value != nil {
+		return nil, errors.Errorf("cannot prove absence of existing key %X", key)
+	}
+
+	nonexist := &NonExistenceProof{Key: key}
+	if index > 0 {
+		leftKey, _ := t.GetByIndex(index - 1)
+		left, err := t.createExistenceProof(leftKey)
+		if err != nil {
+			return nil, err
+		}
+		nonexist.Left = left
+	}
+	if index < t.root.size {
+		rightKey, _ := t.GetByIndex(index)
+		right, err := t.createExistenceProof(rightKey)
+		if err != nil {
+			return nil, err
+		}
+		nonexist.Right = right
+	}
+	return nonexist, nil
+}
+
+// convertLeafOp returns the LeafOp of a leaf node, whose prefix contains the node height, size
+// and version.
+func convertLeafOp(version int64) (*LeafOp, error) {
+	buf := new(bytes.Buffer)
+	err := encoding.EncodeVarint(buf, int64(0))
+	if err == nil {
+		err = encoding.EncodeVarint(buf, 1)
+	}
+	if err == nil {
+		err = encoding.EncodeVarint(buf, version)
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "encoding leaf op")
+	}
+	return &LeafOp{
+		Hash:         HashOp_SHA256,
+		PrehashValue: HashOp_SHA256,
+		Length:       LengthOp_VAR_PROTO,
+		Prefix:       buf.Bytes(),
+	}, nil
+}
+
+// convertInnerOps converts a PathToLeaf, ordered from the root, to ICS23 InnerOps ordered from
+// the leaf. The child hash is placed between the prefix and suffix, which contain the node
+// height, size and version as well as the sibling hash.
+func convertInnerOps(path PathToLeaf) ([]*InnerOp, error) {
+	steps := make([]*InnerOp, 0, len(path))
+	for i := len(path) - 1; i >= 0; i-- {
+		pin := path[i]
+		prefix := new(bytes.Buffer)
+		suffix := new(bytes.Buffer)
+
+		err := encoding.EncodeVarint(prefix, int64(pin.Height))
+		if err == nil {
+			err = encoding.EncodeVarint(prefix, pin.Size)
+		}
+		if err == nil {
+			err = encoding.EncodeVarint(prefix, pin.Version)
+		}
+		if len(pin.Left) == 0 {
+			// The child is on the left, so the sibling hash goes into the suffix.
+			if err == nil {
+				err = encoding.EncodeUvarint(prefix, sha256.Size)
+			}
+			if err == nil {
+				err = encoding.EncodeByteSlice(suffix, pin.Right)
+			}
+		} else {
+			if err == nil {
+				err = encoding.EncodeByteSlice(prefix, pin.Left)
+			}
+			if err == nil {
+				err = encoding.EncodeUvarint(prefix, sha256.Size)
+			}
+		}
+		if err != nil {
+			return nil, errors.Wrapf(err, "encoding inner op %d", i)
+		}
+
+		steps = append(steps, &InnerOp{
+			Hash:   HashOp_SHA256,
+			Prefix: prefix.Bytes(),
+			Suffix: suffix.Bytes(),
+		})
+	}
+	return steps, nil
+}
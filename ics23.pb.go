@@ -0,0 +1,3192 @@
+// Code generated by protoc-gen-gogo. DO NOT EDIT.
+// source: iavl/ics23.proto
+
+package iavl
+
+import (
+	fmt "fmt"
+	proto "github.com/gogo/protobuf/proto"
+	io "io"
+	math "math"
+	math_bits "math/bits"
+)
+
+// Reference imports to suppress errors if they are not otherwise used.
+var _ = proto.Marshal
+var _ = fmt.Errorf
+var _ = math.Inf
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the proto package it is being compiled against.
+// A compilation error at this line likely means your copy of the
+// proto package needs to be updated.
+const _ = proto.GoGoProtoPackageIsVersion3 // please upgrade the proto package
+
+// HashOp is the hash function applied by a LeafOp or InnerOp.
+type HashOp int32
+
+const (
+	// NO_HASH is the default if no data passed. Note this is an illegal argument some places.
+	HashOp_NO_HASH   HashOp = 0
+	HashOp_SHA256    HashOp = 1
+	HashOp_SHA512    HashOp = 2
+	HashOp_KECCAK    HashOp = 3
+	HashOp_RIPEMD160 HashOp = 4
+	HashOp_BITCOIN   HashOp = 5
+)
+
+var HashOp_name = map[int32]string{
+	0: "NO_HASH",
+	1: "SHA256",
+	2: "SHA512",
+	3: "KECCAK",
+	4: "RIPEMD160",
+	5: "BITCOIN",
+}
+
+var HashOp_value = map[string]int32{
+	"NO_HASH":   0,
+	"SHA256":    1,
+	"SHA512":    2,
+	"KECCAK":    3,
+	"RIPEMD160": 4,
+	"BITCOIN":   5,
+}
+
+func (x HashOp) String() string {
+	return proto.EnumName(HashOp_name, int32(x))
+}
+
+func (HashOp) EnumDescriptor() ([]byte, []int) {
+	return fileDescriptor_6b41c07bafd858b9, []int{0}
+}
+
+// LengthOp defines how to process the key and value of the LeafOp to include length information.
+// After encoding the length with the given algorithm, the length will be prepended to the key and
+// value bytes.
+type LengthOp int32
+
+const (
+	// NO_PREFIX don't include any length info
+	LengthOp_NO_PREFIX LengthOp = 0
+	// VAR_PROTO uses protobuf (and go-amino) varint encoding of the length
+	LengthOp_VAR_PROTO LengthOp = 1
+	// VAR_RLP uses rlp int encoding of the length
+	LengthOp_VAR_RLP LengthOp = 2
+	// FIXED32_BIG uses big-endian encoding of the length as a 32 bit integer
+	LengthOp_FIXED32_BIG LengthOp = 3
+	// FIXED32_LITTLE uses little-endian encoding of the length as a 32 bit integer
+	LengthOp_FIXED32_LITTLE LengthOp = 4
+	// FIXED64_BIG uses big-endian encoding of the length as a 64 bit integer
+	LengthOp_FIXED64_BIG LengthOp = 5
+	// FIXED64_LITTLE uses little-endian encoding of the length as a 64 bit integer
+	LengthOp_FIXED64_LITTLE LengthOp = 6
+	// REQUIRE_32_BYTES is like NONE, but will fail if the input is not exactly 32 bytes (sha256 output)
+	LengthOp_REQUIRE_32_BYTES LengthOp = 7
+	// REQUIRE_64_BYTES is like NONE, but will fail if the input is not exactly 64 bytes (sha512 output)
+	LengthOp_REQUIRE_64_BYTES LengthOp = 8
+)
+
+var LengthOp_name = map[int32]string{
+	0: "NO_PREFIX",
+	1: "VAR_PROTO",
+	2: "VAR_RLP",
+	3: "FIXED32_BIG",
+	4: "FIXED32_LITTLE",
+	5: "FIXED64_BIG",
+	6: "FIXED64_LITTLE",
+	7: "REQUIRE_32_BYTES",
+	8: "REQUIRE_64_BYTES",
+}
+
+var LengthOp_value = map[string]int32{
+	"NO_PREFIX":        0,
+	"VAR_PROTO":        1,
+	"VAR_RLP":          2,
+	"FIXED32_BIG":      3,
+	"FIXED32_LITTLE":   4,
+	"FIXED64_BIG":      5,
+	"FIXED64_LITTLE":   6,
+	"REQUIRE_32_BYTES": 7,
+	"REQUIRE_64_BYTES": 8,
+}
+
+func (x LengthOp) String() string {
+	return proto.EnumName(LengthOp_name, int32(x))
+}
+
+func (LengthOp) EnumDescriptor() ([]byte, []int) {
+	return fileDescriptor_6b41c07bafd858b9, []int{1}
+}
+
+// ExistenceProof takes a key and a value and a set of steps to perform on it. The result of
+// performing all these steps provides a "root hash", which can be compared to the value in a
+// header.
+//
+// The leaf op is applied to the key and value first, and each inner op is then applied to the
+// result of the previous step, from the leaf towards the root.
+type ExistenceProof struct {
+	Key   []byte     `protobuf:"bytes,1,opt,name=key,proto3" json:"key,omitempty"`
+	Value []byte     `protobuf:"bytes,2,opt,name=value,proto3" json:"value,omitempty"`
+	Leaf  *LeafOp    `protobuf:"bytes,3,opt,name=leaf,proto3" json:"leaf,omitempty"`
+	Path  []*InnerOp `protobuf:"bytes,4,rep,name=path,proto3" json:"path,omitempty"`
+}
+
+func (m *ExistenceProof) Reset()         { *m = ExistenceProof{} }
+func (m *ExistenceProof) String() string { return proto.CompactTextString(m) }
+func (*ExistenceProof) ProtoMessage()    {}
+func (*ExistenceProof) Descriptor() ([]byte, []int) {
+	return fileDescriptor_6b41c07bafd858b9, []int{0}
+}
+func (m *ExistenceProof) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *ExistenceProof) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_ExistenceProof.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
+		}
+		return b[:n], nil
+	}
+}
+func (m *ExistenceProof) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ExistenceProof.Merge(m, src)
+}
+func (m *ExistenceProof) XXX_Size() int {
+	return m.Size()
+}
+func (m *ExistenceProof) XXX_DiscardUnknown() {
+	xxx_messageInfo_ExistenceProof.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ExistenceProof proto.InternalMessageInfo
+
+func (m *ExistenceProof) GetKey() []byte {
+	if m != nil {
+		return m.Key
+	}
+	return nil
+}
+
+func (m *ExistenceProof) GetValue() []byte {
+	if m != nil {
+		return m.Value
+	}
+	return nil
+}
+
+func (m *ExistenceProof) GetLeaf() *LeafOp {
+	if m != nil {
+		return m.Leaf
+	}
+	return nil
+}
+
+func (m *ExistenceProof) GetPath() []*InnerOp {
+	if m != nil {
+		return m.Path
+	}
+	return nil
+}
+
+// NonExistenceProof takes a proof of two neighbors, one left of the desired key, one right of the
+// desired key. If both proofs are valid AND they are neighbors, then there is no valid proof for
+// the given key. Left or right may be missing if the key is beyond the leftmost or rightmost key.
+type NonExistenceProof struct {
+	Key   []byte          `protobuf:"bytes,1,opt,name=key,proto3" json:"key,omitempty"`
+	Left  *ExistenceProof `protobuf:"bytes,2,opt,name=left,proto3" json:"left,omitempty"`
+	Right *ExistenceProof `protobuf:"bytes,3,opt,name=right,proto3" json:"right,omitempty"`
+}
+
+func (m *NonExistenceProof) Reset()         { *m = NonExistenceProof{} }
+func (m *NonExistenceProof) String() string { return proto.CompactTextString(m) }
+func (*NonExistenceProof) ProtoMessage()    {}
+func (*NonExistenceProof) Descriptor() ([]byte, []int) {
+	return fileDescriptor_6b41c07bafd858b9, []int{1}
+}
+func (m *NonExistenceProof) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *NonExistenceProof) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_NonExistenceProof.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
+		}
+		return b[:n], nil
+	}
+}
+func (m *NonExistenceProof) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_NonExistenceProof.Merge(m, src)
+}
+func (m *NonExistenceProof) XXX_Size() int {
+	return m.Size()
+}
+func (m *NonExistenceProof) XXX_DiscardUnknown() {
+	xxx_messageInfo_NonExistenceProof.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_NonExistenceProof proto.InternalMessageInfo
+
+func (m *NonExistenceProof) GetKey() []byte {
+	if m != nil {
+		return m.Key
+	}
+	return nil
+}
+
+func (m *NonExistenceProof) GetLeft() *ExistenceProof {
+	if m != nil {
+		return m.Left
+	}
+	return nil
+}
+
+func (m *NonExistenceProof) GetRight() *ExistenceProof {
+	if m != nil {
+		return m.Right
+	}
+	return nil
+}
+
+// CommitmentProof is either an ExistenceProof, a NonExistenceProof, or a BatchProof.
+type CommitmentProof struct {
+	// Types that are valid to be assigned to Proof:
+	//	*CommitmentProof_Exist
+	//	*CommitmentProof_Nonexist
+	//	*CommitmentProof_Batch
+	Proof isCommitmentProof_Proof `protobuf_oneof:"proof"`
+}
+
+func (m *CommitmentProof) Reset()         { *m = CommitmentProof{} }
+func (m *CommitmentProof) String() string { return proto.CompactTextString(m) }
+func (*CommitmentProof) ProtoMessage()    {}
+func (*CommitmentProof) Descriptor() ([]byte, []int) {
+	return fileDescriptor_6b41c07bafd858b9, []int{2}
+}
+func (m *CommitmentProof) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *CommitmentProof) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_CommitmentProof.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
+		}
+		return b[:n], nil
+	}
+}
+func (m *CommitmentProof) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_CommitmentProof.Merge(m, src)
+}
+func (m *CommitmentProof) XXX_Size() int {
+	return m.Size()
+}
+func (m *CommitmentProof) XXX_DiscardUnknown() {
+	xxx_messageInfo_CommitmentProof.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_CommitmentProof proto.InternalMessageInfo
+
+type isCommitmentProof_Proof interface {
+	isCommitmentProof_Proof()
+	MarshalTo([]byte) (int, error)
+	Size() int
+}
+
+type CommitmentProof_Exist struct {
+	Exist *ExistenceProof `protobuf:"bytes,1,opt,name=exist,proto3,oneof" json:"exist,omitempty"`
+}
+type CommitmentProof_Nonexist struct {
+	Nonexist *NonExistenceProof `protobuf:"bytes,2,opt,name=nonexist,proto3,oneof" json:"nonexist,omitempty"`
+}
+type CommitmentProof_Batch struct {
+	Batch *BatchProof `protobuf:"bytes,3,opt,name=batch,proto3,oneof" json:"batch,omitempty"`
+}
+
+func (*CommitmentProof_Exist) isCommitmentProof_Proof()    {}
+func (*CommitmentProof_Nonexist) isCommitmentProof_Proof() {}
+func (*CommitmentProof_Batch) isCommitmentProof_Proof()    {}
+
+func (m *CommitmentProof) GetProof() isCommitmentProof_Proof {
+	if m != nil {
+		return m.Proof
+	}
+	return nil
+}
+
+func (m *CommitmentProof) GetExist() *ExistenceProof {
+	if x, ok := m.GetProof().(*CommitmentProof_Exist); ok {
+		return x.Exist
+	}
+	return nil
+}
+
+func (m *CommitmentProof) GetNonexist() *NonExistenceProof {
+	if x, ok := m.GetProof().(*CommitmentProof_Nonexist); ok {
+		return x.Nonexist
+	}
+	return nil
+}
+
+func (m *CommitmentProof) GetBatch() *BatchProof {
+	if x, ok := m.GetProof().(*CommitmentProof_Batch); ok {
+		return x.Batch
+	}
+	return nil
+}
+
+// XXX_OneofWrappers is for the internal use of the proto package.
+func (*CommitmentProof) XXX_OneofWrappers() []interface{} {
+	return []interface{}{
+		(*CommitmentProof_Exist)(nil),
+		(*CommitmentProof_Nonexist)(nil),
+		(*CommitmentProof_Batch)(nil),
+	}
+}
+
+// LeafOp represents the raw key-value data we wish to prove, and must be flexible to represent
+// the internal transformation from the original key-value pairs into the basis hash.
+//
+// The output is hash(prefix || length(prehash_key(key)) || key || length(prehash_value(value)) ||
+// prehash_value(value)), where length and prehashing are skipped when NO_PREFIX or NO_HASH.
+type LeafOp struct {
+	Hash         HashOp   `protobuf:"varint,1,opt,name=hash,proto3,enum=iavl.HashOp" json:"hash,omitempty"`
+	PrehashKey   HashOp   `protobuf:"varint,2,opt,name=prehash_key,json=prehashKey,proto3,enum=iavl.HashOp" json:"prehash_key,omitempty"`
+	PrehashValue HashOp   `protobuf:"varint,3,opt,name=prehash_value,json=prehashValue,proto3,enum=iavl.HashOp" json:"prehash_value,omitempty"`
+	Length       LengthOp `protobuf:"varint,4,opt,name=length,proto3,enum=iavl.LengthOp" json:"length,omitempty"`
+	// prefix is a fixed bytes that may optionally be included at the beginning to differentiate a
+	// leaf node from an inner node.
+	Prefix []byte `protobuf:"bytes,5,opt,name=prefix,proto3" json:"prefix,omitempty"`
+}
+
+func (m *LeafOp) Reset()         { *m = LeafOp{} }
+func (m *LeafOp) String() string { return proto.CompactTextString(m) }
+func (*LeafOp) ProtoMessage()    {}
+func (*LeafOp) Descriptor() ([]byte, []int) {
+	return fileDescriptor_6b41c07bafd858b9, []int{3}
+}
+func (m *LeafOp) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *LeafOp) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_LeafOp.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
+		}
+		return b[:n], nil
+	}
+}
+func (m *LeafOp) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_LeafOp.Merge(m, src)
+}
+func (m *LeafOp) XXX_Size() int {
+	return m.Size()
+}
+func (m *LeafOp) XXX_DiscardUnknown() {
+	xxx_messageInfo_LeafOp.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_LeafOp proto.InternalMessageInfo
+
+func (m *LeafOp) GetHash() HashOp {
+	if m != nil {
+		return m.Hash
+	}
+	return HashOp_NO_HASH
+}
+
+func (m *LeafOp) GetPrehashKey() HashOp {
+	if m != nil {
+		return m.PrehashKey
+	}
+	return HashOp_NO_HASH
+}
+
+func (m *LeafOp) GetPrehashValue() HashOp {
+	if m != nil {
+		return m.PrehashValue
+	}
+	return HashOp_NO_HASH
+}
+
+func (m *LeafOp) GetLength() LengthOp {
+	if m != nil {
+		return m.Length
+	}
+	return LengthOp_NO_PREFIX
+}
+
+func (m *LeafOp) GetPrefix() []byte {
+	if m != nil {
+		return m.Prefix
+	}
+	return nil
+}
+
+// InnerOp represents a merkle-proof step that is not a leaf. It represents concatenating two
+// children and hashing them to provide the next result.
+//
+// The result of the previous step is passed in, so the signature of this op is:
+// hash(prefix || child || suffix), where the sibling hashes and any node metadata are part of the
+// prefix and suffix.
+type InnerOp struct {
+	Hash   HashOp `protobuf:"varint,1,opt,name=hash,proto3,enum=iavl.HashOp" json:"hash,omitempty"`
+	Prefix []byte `protobuf:"bytes,2,opt,name=prefix,proto3" json:"prefix,omitempty"`
+	Suffix []byte `protobuf:"bytes,3,opt,name=suffix,proto3" json:"suffix,omitempty"`
+}
+
+func (m *InnerOp) Reset()         { *m = InnerOp{} }
+func (m *InnerOp) String() string { return proto.CompactTextString(m) }
+func (*InnerOp) ProtoMessage()    {}
+func (*InnerOp) Descriptor() ([]byte, []int) {
+	return fileDescriptor_6b41c07bafd858b9, []int{4}
+}
+func (m *InnerOp) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *InnerOp) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_InnerOp.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
+		}
+		return b[:n], nil
+	}
+}
+func (m *InnerOp) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_InnerOp.Merge(m, src)
+}
+func (m *InnerOp) XXX_Size() int {
+	return m.Size()
+}
+func (m *InnerOp) XXX_DiscardUnknown() {
+	xxx_messageInfo_InnerOp.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_InnerOp proto.InternalMessageInfo
+
+func (m *InnerOp) GetHash() HashOp {
+	if m != nil {
+		return m.Hash
+	}
+	return HashOp_NO_HASH
+}
+
+func (m *InnerOp) GetPrefix() []byte {
+	if m != nil {
+		return m.Prefix
+	}
+	return nil
+}
+
+func (m *InnerOp) GetSuffix() []byte {
+	if m != nil {
+		return m.Suffix
+	}
+	return nil
+}
+
+// ProofSpec defines what the expected parameters are for a given proof type. This can be stored
+// in the client and used to validate any incoming proofs.
+type ProofSpec struct {
+	// any field in the ExistenceProof must be the same as in this spec. Except Prefix, which is
+	// just the first bytes of prefix (spec can be longer)
+	LeafSpec  *LeafOp    `protobuf:"bytes,1,opt,name=leaf_spec,json=leafSpec,proto3" json:"leaf_spec,omitempty"`
+	InnerSpec *InnerSpec `protobuf:"bytes,2,opt,name=inner_spec,json=innerSpec,proto3" json:"inner_spec,omitempty"`
+	// max_depth (if > 0) is the maximum number of InnerOps allowed (mainly for fixed-depth tries)
+	MaxDepth int32 `protobuf:"varint,3,opt,name=max_depth,json=maxDepth,proto3" json:"max_depth,omitempty"`
+	// min_depth (if > 0) is the minimum number of InnerOps allowed (mainly for fixed-depth tries)
+	MinDepth int32 `protobuf:"varint,4,opt,name=min_depth,json=minDepth,proto3" json:"min_depth,omitempty"`
+}
+
+func (m *ProofSpec) Reset()         { *m = ProofSpec{} }
+func (m *ProofSpec) String() string { return proto.CompactTextString(m) }
+func (*ProofSpec) ProtoMessage()    {}
+func (*ProofSpec) Descriptor() ([]byte, []int) {
+	return fileDescriptor_6b41c07bafd858b9, []int{5}
+}
+func (m *ProofSpec) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *ProofSpec) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_ProofSpec.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
+		}
+		return b[:n], nil
+	}
+}
+func (m *ProofSpec) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ProofSpec.Merge(m, src)
+}
+func (m *ProofSpec) XXX_Size() int {
+	return m.Size()
+}
+func (m *ProofSpec) XXX_DiscardUnknown() {
+	xxx_messageInfo_ProofSpec.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ProofSpec proto.InternalMessageInfo
+
+func (m *ProofSpec) GetLeafSpec() *LeafOp {
+	if m != nil {
+		return m.LeafSpec
+	}
+	return nil
+}
+
+func (m *ProofSpec) GetInnerSpec() *InnerSpec {
+	if m != nil {
+		return m.InnerSpec
+	}
+	return nil
+}
+
+func (m *ProofSpec) GetMaxDepth() int32 {
+	if m != nil {
+		return m.MaxDepth
+	}
+	return 0
+}
+
+func (m *ProofSpec) GetMinDepth() int32 {
+	if m != nil {
+		return m.MinDepth
+	}
+	return 0
+}
+
+// InnerSpec contains all store-specific structure info to determine if two proofs from a given
+// store are neighbors.
+//
+// This enables:
+//
+//	isLeftMost(spec: InnerSpec, op: InnerOp)
+//	isRightMost(spec: InnerSpec, op: InnerOp)
+//	isLeftNeighbor(spec: InnerSpec, left: InnerOp, right: InnerOp)
+type InnerSpec struct {
+	// Child order is the ordering of the children node, must count from 0. IAVL trees use
+	// [0, 1] (left then right).
+	ChildOrder      []int32 `protobuf:"varint,1,rep,packed,name=child_order,json=childOrder,proto3" json:"child_order,omitempty"`
+	ChildSize       int32   `protobuf:"varint,2,opt,name=child_size,json=childSize,proto3" json:"child_size,omitempty"`
+	MinPrefixLength int32   `protobuf:"varint,3,opt,name=min_prefix_length,json=minPrefixLength,proto3" json:"min_prefix_length,omitempty"`
+	MaxPrefixLength int32   `protobuf:"varint,4,opt,name=max_prefix_length,json=maxPrefixLength,proto3" json:"max_prefix_length,omitempty"`
+	// empty child is the prehash image that is used when one child is nil (eg. 20 bytes of 0). It is
+	// not used by IAVL trees, and not supported by the verifier in this package.
+	EmptyChild []byte `protobuf:"bytes,5,opt,name=empty_child,json=emptyChild,proto3" json:"empty_child,omitempty"`
+	// hash is the algorithm that must be used for each InnerOp
+	Hash HashOp `protobuf:"varint,6,opt,name=hash,proto3,enum=iavl.HashOp" json:"hash,omitempty"`
+}
+
+func (m *InnerSpec) Reset()         { *m = InnerSpec{} }
+func (m *InnerSpec) String() string { return proto.CompactTextString(m) }
+func (*InnerSpec) ProtoMessage()    {}
+func (*InnerSpec) Descriptor() ([]byte, []int) {
+	return fileDescriptor_6b41c07bafd858b9, []int{6}
+}
+func (m *InnerSpec) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *InnerSpec) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_InnerSpec.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
+		}
+		return b[:n], nil
+	}
+}
+func (m *InnerSpec) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_InnerSpec.Merge(m, src)
+}
+func (m *InnerSpec) XXX_Size() int {
+	return m.Size()
+}
+func (m *InnerSpec) XXX_DiscardUnknown() {
+	xxx_messageInfo_InnerSpec.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_InnerSpec proto.InternalMessageInfo
+
+func (m *InnerSpec) GetChildOrder() []int32 {
+	if m != nil {
+		return m.ChildOrder
+	}
+	return nil
+}
+
+func (m *InnerSpec) GetChildSize() int32 {
+	if m != nil {
+		return m.ChildSize
+	}
+	return 0
+}
+
+func (m *InnerSpec) GetMinPrefixLength() int32 {
+	if m != nil {
+		return m.MinPrefixLength
+	}
+	return 0
+}
+
+func (m *InnerSpec) GetMaxPrefixLength() int32 {
+	if m != nil {
+		return m.MaxPrefixLength
+	}
+	return 0
+}
+
+func (m *InnerSpec) GetEmptyChild() []byte {
+	if m != nil {
+		return m.EmptyChild
+	}
+	return nil
+}
+
+func (m *InnerSpec) GetHash() HashOp {
+	if m != nil {
+		return m.Hash
+	}
+	return HashOp_NO_HASH
+}
+
+// BatchProof is a group of existence and non-existence proofs.
+type BatchProof struct {
+	Entries []*BatchEntry `protobuf:"bytes,1,rep,name=entries,proto3" json:"entries,omitempty"`
+}
+
+func (m *BatchProof) Reset()         { *m = BatchProof{} }
+func (m *BatchProof) String() string { return proto.CompactTextString(m) }
+func (*BatchProof) ProtoMessage()    {}
+func (*BatchProof) Descriptor() ([]byte, []int) {
+	return fileDescriptor_6b41c07bafd858b9, []int{7}
+}
+func (m *BatchProof) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *BatchProof) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_BatchProof.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
+		}
+		return b[:n], nil
+	}
+}
+func (m *BatchProof) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_BatchProof.Merge(m, src)
+}
+func (m *BatchProof) XXX_Size() int {
+	return m.Size()
+}
+func (m *BatchProof) XXX_DiscardUnknown() {
+	xxx_messageInfo_BatchProof.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_BatchProof proto.InternalMessageInfo
+
+func (m *BatchProof) GetEntries() []*BatchEntry {
+	if m != nil {
+		return m.Entries
+	}
+	return nil
+}
+
+// BatchEntry is a single item in a batch proof.
+type BatchEntry struct {
+	// Types that are valid to be assigned to Proof:
+	//	*BatchEntry_Exist
+	//	*BatchEntry_Nonexist
+	Proof isBatchEntry_Proof `protobuf_oneof:"proof"`
+}
+
+func (m *BatchEntry) Reset()         { *m = BatchEntry{} }
+func (m *BatchEntry) String() string { return proto.CompactTextString(m) }
+func (*BatchEntry) ProtoMessage()    {}
+func (*BatchEntry) Descriptor() ([]byte, []int) {
+	return fileDescriptor_6b41c07bafd858b9, []int{8}
+}
+func (m *BatchEntry) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *BatchEntry) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_BatchEntry.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
+		}
+		return b[:n], nil
+	}
+}
+func (m *BatchEntry) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_BatchEntry.Merge(m, src)
+}
+func (m *BatchEntry) XXX_Size() int {
+	return m.Size()
+}
+func (m *BatchEntry) XXX_DiscardUnknown() {
+	xxx_messageInfo_BatchEntry.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_BatchEntry proto.InternalMessageInfo
+
+type isBatchEntry_Proof interface {
+	isBatchEntry_Proof()
+	MarshalTo([]byte) (int, error)
+	Size() int
+}
+
+type BatchEntry_Exist struct {
+	Exist *ExistenceProof `protobuf:"bytes,1,opt,name=exist,proto3,oneof" json:"exist,omitempty"`
+}
+type BatchEntry_Nonexist struct {
+	Nonexist *NonExistenceProof `protobuf:"bytes,2,opt,name=nonexist,proto3,oneof" json:"nonexist,omitempty"`
+}
+
+func (*BatchEntry_Exist) isBatchEntry_Proof()    {}
+func (*BatchEntry_Nonexist) isBatchEntry_Proof() {}
+
+func (m *BatchEntry) GetProof() isBatchEntry_Proof {
+	if m != nil {
+		return m.Proof
+	}
+	return nil
+}
+
+func (m *BatchEntry) GetExist() *ExistenceProof {
+	if x, ok := m.GetProof().(*BatchEntry_Exist); ok {
+		return x.Exist
+	}
+	return nil
+}
+
+func (m *BatchEntry) GetNonexist() *NonExistenceProof {
+	if x, ok := m.GetProof().(*BatchEntry_Nonexist); ok {
+		return x.Nonexist
+	}
+	return nil
+}
+
+// XXX_OneofWrappers is for the internal use of the proto package.
+func (*BatchEntry) XXX_OneofWrappers() []interface{} {
+	return []interface{}{
+		(*BatchEntry_Exist)(nil),
+		(*BatchEntry_Nonexist)(nil),
+	}
+}
+
+func init() {
+	proto.RegisterEnum("iavl.HashOp", HashOp_name, HashOp_value)
+	proto.RegisterEnum("iavl.LengthOp", LengthOp_name, LengthOp_value)
+	proto.RegisterType((*ExistenceProof)(nil), "iavl.ExistenceProof")
+	proto.RegisterType((*NonExistenceProof)(nil), "iavl.NonExistenceProof")
+	proto.RegisterType((*CommitmentProof)(nil), "iavl.CommitmentProof")
+	proto.RegisterType((*LeafOp)(nil), "iavl.LeafOp")
+	proto.RegisterType((*InnerOp)(nil), "iavl.InnerOp")
+	proto.RegisterType((*ProofSpec)(nil), "iavl.ProofSpec")
+	proto.RegisterType((*InnerSpec)(nil), "iavl.InnerSpec")
+	proto.RegisterType((*BatchProof)(nil), "iavl.BatchProof")
+	proto.RegisterType((*BatchEntry)(nil), "iavl.BatchEntry")
+}
+
+func init() { proto.RegisterFile("iavl/ics23.proto", fileDescriptor_6b41c07bafd858b9) }
+
+var fileDescriptor_6b41c07bafd858b9 = []byte{
+	// 805 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xbc, 0x95, 0xcf, 0x6f, 0xe3, 0x44,
+	0x14, 0xc7, 0xe3, 0xf8, 0x47, 0x92, 0xe7, 0xfe, 0x98, 0x1d, 0x55, 0x60, 0x09, 0x91, 0x0d, 0x3e,
+	0xa0, 0x10, 0x41, 0xa1, 0x69, 0x1b, 0x71, 0x6d, 0x52, 0x2f, 0xb1, 0x5a, 0xea, 0x30, 0xc9, 0xae,
+	0x16, 0x38, 0x58, 0xde, 0x74, 0xb2, 0xb1, 0x48, 0x6c, 0xcb, 0xf6, 0xae, 0xd2, 0x22, 0xfe, 0x07,
+	0xfe, 0x04, 0xae, 0x88, 0x7f, 0x84, 0x03, 0x87, 0x3d, 0x72, 0x44, 0xad, 0xc4, 0xdf, 0x81, 0xde,
+	0x8c, 0x9d, 0x36, 0xb0, 0x0b, 0x9c, 0xf6, 0x36, 0xef, 0xfb, 0x3e, 0x6f, 0xe6, 0xf9, 0x7d, 0x67,
+	0x64, 0x20, 0x61, 0xf0, 0x72, 0xf1, 0x69, 0x38, 0xcd, 0xba, 0x87, 0xfb, 0x49, 0x1a, 0xe7, 0x31,
+	0xd5, 0x50, 0xb1, 0x7f, 0x80, 0x1d, 0x67, 0x15, 0x66, 0x39, 0x8f, 0xa6, 0x7c, 0x94, 0xc6, 0xf1,
+	0x8c, 0x12, 0x50, 0xbf, 0xe3, 0x57, 0x96, 0xd2, 0x52, 0xda, 0x5b, 0x0c, 0x97, 0x74, 0x0f, 0xf4,
+	0x97, 0xc1, 0xe2, 0x05, 0xb7, 0xaa, 0x42, 0x93, 0x01, 0x6d, 0x81, 0xb6, 0xe0, 0xc1, 0xcc, 0x52,
+	0x5b, 0x4a, 0xdb, 0xec, 0x6e, 0xed, 0xe3, 0x76, 0xfb, 0xe7, 0x3c, 0x98, 0x79, 0x09, 0x13, 0x19,
+	0xfa, 0x01, 0x68, 0x49, 0x90, 0xcf, 0x2d, 0xad, 0xa5, 0xb6, 0xcd, 0xee, 0xb6, 0x24, 0xdc, 0x28,
+	0xe2, 0x29, 0x22, 0x98, 0xb2, 0xbf, 0x87, 0x07, 0x17, 0x71, 0xf4, 0x9f, 0x1d, 0xb4, 0xf1, 0xac,
+	0x59, 0x2e, 0x1a, 0x30, 0xbb, 0x7b, 0x72, 0xa7, 0xcd, 0x2a, 0x26, 0x08, 0xda, 0x01, 0x3d, 0x0d,
+	0x9f, 0xcf, 0x73, 0x4b, 0xfd, 0x17, 0x54, 0x22, 0xf6, 0xcf, 0x0a, 0xec, 0x0e, 0xe2, 0xe5, 0x32,
+	0xcc, 0x97, 0x3c, 0xca, 0xe5, 0xd9, 0x1f, 0x83, 0xce, 0x11, 0xb6, 0x94, 0x37, 0xd7, 0x0f, 0x2b,
+	0x4c, 0x42, 0xf4, 0x18, 0xea, 0x51, 0x1c, 0xc9, 0x02, 0xd9, 0xdb, 0xbb, 0xb2, 0xe0, 0x1f, 0x1f,
+	0x35, 0xac, 0xb0, 0x35, 0x4a, 0xdb, 0xa0, 0x3f, 0x0b, 0xf2, 0xe9, 0xbc, 0x68, 0x92, 0xc8, 0x9a,
+	0x3e, 0x4a, 0xeb, 0x03, 0x04, 0xd0, 0xaf, 0x81, 0x9e, 0xa0, 0x62, 0xff, 0xa6, 0x80, 0x21, 0x87,
+	0x8b, 0x83, 0x9f, 0x07, 0xd9, 0x5c, 0x74, 0xb8, 0x53, 0x0e, 0x7e, 0x18, 0x64, 0x73, 0x9c, 0x2a,
+	0x66, 0xe8, 0x27, 0x60, 0x26, 0x29, 0xc7, 0xa5, 0x8f, 0x83, 0xac, 0xbe, 0x06, 0x84, 0x02, 0x38,
+	0xe3, 0x57, 0xf4, 0x00, 0xb6, 0x4b, 0x5c, 0xfa, 0xac, 0xbe, 0xa6, 0x60, 0xab, 0x40, 0x9e, 0x08,
+	0xf3, 0x3f, 0x04, 0x63, 0xc1, 0xa3, 0xe7, 0xc2, 0x5c, 0x64, 0x77, 0x4a, 0xfb, 0x51, 0xf3, 0x12,
+	0x56, 0x64, 0xe9, 0x3b, 0x60, 0x24, 0x29, 0x9f, 0x85, 0x2b, 0x4b, 0x17, 0x6e, 0x16, 0x91, 0xfd,
+	0x2d, 0xd4, 0x8a, 0x8b, 0xf0, 0x3f, 0x3e, 0xe7, 0x6e, 0x93, 0xea, 0xfd, 0x4d, 0x50, 0xcf, 0x5e,
+	0xcc, 0x50, 0x57, 0xa5, 0x2e, 0x23, 0xfb, 0x27, 0x05, 0x1a, 0x62, 0x8e, 0xe3, 0x84, 0x4f, 0xe9,
+	0x47, 0xd0, 0xc0, 0xdb, 0xe8, 0x67, 0x09, 0x9f, 0x16, 0xae, 0x6e, 0x5e, 0xd6, 0x3a, 0xa6, 0x05,
+	0xba, 0x0f, 0x10, 0x62, 0x57, 0x92, 0x95, 0x86, 0xee, 0xde, 0xbb, 0xb6, 0x08, 0xb1, 0x46, 0x58,
+	0x2e, 0xe9, 0x7b, 0xd0, 0x58, 0x06, 0x2b, 0xff, 0x92, 0x27, 0xb9, 0xf4, 0x52, 0x67, 0xf5, 0x65,
+	0xb0, 0x3a, 0xc5, 0x58, 0x24, 0xc3, 0xa8, 0x48, 0x6a, 0x45, 0x32, 0x8c, 0x44, 0xd2, 0xfe, 0x53,
+	0x81, 0xc6, 0x7a, 0x4b, 0xfa, 0x10, 0xcc, 0xe9, 0x3c, 0x5c, 0x5c, 0xfa, 0x71, 0x7a, 0xc9, 0x53,
+	0x4b, 0x69, 0xa9, 0x6d, 0x9d, 0x81, 0x90, 0x3c, 0x54, 0xe8, 0xfb, 0x20, 0x23, 0x3f, 0x0b, 0xaf,
+	0xe5, 0x33, 0xd4, 0x59, 0x43, 0x28, 0xe3, 0xf0, 0x9a, 0xd3, 0x0e, 0x3c, 0xc0, 0xa3, 0xe4, 0x58,
+	0xfc, 0xc2, 0x18, 0xd9, 0xcf, 0xee, 0x32, 0x8c, 0x46, 0x42, 0x97, 0xde, 0x08, 0x36, 0x58, 0xfd,
+	0x8d, 0xd5, 0x0a, 0x36, 0x58, 0x6d, 0xb0, 0x0f, 0xc1, 0xe4, 0xcb, 0x24, 0xbf, 0xf2, 0xc5, 0x51,
+	0x85, 0x85, 0x20, 0xa4, 0x01, 0x2a, 0x6b, 0xef, 0x8c, 0x37, 0x79, 0x67, 0x7f, 0x0e, 0x70, 0x77,
+	0xaf, 0x69, 0x07, 0x6a, 0x3c, 0xca, 0xd3, 0x90, 0x67, 0xe2, 0x23, 0x37, 0xaf, 0xbe, 0x13, 0xe5,
+	0xe9, 0x15, 0x2b, 0x01, 0xfb, 0x1a, 0xe0, 0x4e, 0x7e, 0x2b, 0xef, 0x72, 0xfd, 0xda, 0x3a, 0x8f,
+	0xc1, 0x90, 0x5f, 0x41, 0x4d, 0xa8, 0x5d, 0x78, 0xfe, 0xf0, 0x64, 0x3c, 0x24, 0x15, 0x0a, 0x60,
+	0x8c, 0x87, 0x27, 0xdd, 0xe3, 0x1e, 0x51, 0x8a, 0xf5, 0xf1, 0x41, 0x97, 0x54, 0x71, 0x7d, 0xe6,
+	0x0c, 0x06, 0x27, 0x67, 0x44, 0xa5, 0xdb, 0xd0, 0x60, 0xee, 0xc8, 0xf9, 0xf2, 0xf4, 0xa0, 0xf7,
+	0x19, 0xd1, 0xb0, 0xbe, 0xef, 0x4e, 0x06, 0x9e, 0x7b, 0x41, 0xf4, 0xce, 0x2f, 0x0a, 0xd4, 0xcb,
+	0x27, 0x82, 0xe0, 0x85, 0xe7, 0x8f, 0x98, 0xf3, 0xc8, 0x7d, 0x4a, 0x2a, 0x18, 0x3e, 0x39, 0x61,
+	0xfe, 0x88, 0x79, 0x13, 0x8f, 0x28, 0x58, 0x87, 0x21, 0x3b, 0x1f, 0x91, 0x2a, 0xdd, 0x05, 0xf3,
+	0x91, 0xfb, 0xd4, 0x39, 0x3d, 0xec, 0xfa, 0x7d, 0xf7, 0x0b, 0xa2, 0x52, 0x0a, 0x3b, 0xa5, 0x70,
+	0xee, 0x4e, 0x26, 0xe7, 0x0e, 0xd1, 0xd6, 0x50, 0xef, 0x48, 0x40, 0xfa, 0x1a, 0xea, 0x1d, 0x95,
+	0x90, 0x41, 0xf7, 0x80, 0x30, 0xe7, 0xab, 0xc7, 0x2e, 0x73, 0x7c, 0xdc, 0xec, 0xeb, 0x89, 0x33,
+	0x26, 0xb5, 0xfb, 0x6a, 0xef, 0xa8, 0x50, 0xeb, 0xfd, 0xe6, 0xaf, 0x37, 0x4d, 0xe5, 0xd5, 0x4d,
+	0x53, 0xf9, 0xe3, 0xa6, 0xa9, 0xfc, 0x78, 0xdb, 0xac, 0xbc, 0xba, 0x6d, 0x56, 0x7e, 0xbf, 0x6d,
+	0x56, 0xbe, 0x11, 0xbf, 0x8e, 0x67, 0x86, 0xf8, 0x8f, 0x1c, 0xfe, 0x35, 0x00, 0xf0, 0x2c, 0xc3,
+	0x14, 0x5b, 0x06, 0x00, 0x00,
+}
+
+func (m *ExistenceProof) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *ExistenceProof) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *ExistenceProof) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if len(m.Path) > 0 {
+		for iNdEx := len(m.Path) - 1; iNdEx >= 0; iNdEx-- {
+			{
+				size, err := m.Path[iNdEx].MarshalToSizedBuffer(dAtA[:i])
+				if err != nil {
+					return 0, err
+				}
+				i -= size
+				i = encodeVarintIcs23(dAtA, i, uint64(size))
+			}
+			i--
+			dAtA[i] = 0x22
+		}
+	}
+	if m.Leaf != nil {
+		{
+			size, err := m.Leaf.MarshalToSizedBuffer(dAtA[:i])
+			if err != nil {
+				return 0, err
+			}
+			i -= size
+			i = encodeVarintIcs23(dAtA, i, uint64(size))
+		}
+		i--
+		dAtA[i] = 0x1a
+	}
+	if len(m.Value) > 0 {
+		i -= len(m.Value)
+		copy(dAtA[i:], m.Value)
+		i = encodeVarintIcs23(dAtA, i, uint64(len(m.Value)))
+		i--
+		dAtA[i] = 0x12
+	}
+	if len(m.Key) > 0 {
+		i -= len(m.Key)
+		copy(dAtA[i:], m.Key)
+		i = encodeVarintIcs23(dAtA, i, uint64(len(m.Key)))
+		i--
+		dAtA[i] = 0xa
+	}
+	return len(dAtA) - i, nil
+}
+
+func (m *NonExistenceProof) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *NonExistenceProof) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *NonExistenceProof) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if m.Right != nil {
+		{
+			size, err := m.Right.MarshalToSizedBuffer(dAtA[:i])
+			if err != nil {
+				return 0, err
+			}
+			i -= size
+			i = encodeVarintIcs23(dAtA, i, uint64(size))
+		}
+		i--
+		dAtA[i] = 0x1a
+	}
+	if m.Left != nil {
+		{
+			size, err := m.Left.MarshalToSizedBuffer(dAtA[:i])
+			if err != nil {
+				return 0, err
+			}
+			i -= size
+			i = encodeVarintIcs23(dAtA, i, uint64(size))
+		}
+		i--
+		dAtA[i] = 0x12
+	}
+	if len(m.Key) > 0 {
+		i -= len(m.Key)
+		copy(dAtA[i:], m.Key)
+		i = encodeVarintIcs23(dAtA, i, uint64(len(m.Key)))
+		i--
+		dAtA[i] = 0xa
+	}
+	return len(dAtA) - i, nil
+}
+
+func (m *CommitmentProof) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *CommitmentProof) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *CommitmentProof) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if m.Proof != nil {
+		{
+			size := m.Proof.Size()
+			i -= size
+			if _, err := m.Proof.MarshalTo(dAtA[i:]); err != nil {
+				return 0, err
+			}
+		}
+	}
+	return len(dAtA) - i, nil
+}
+
+func (m *CommitmentProof_Exist) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *CommitmentProof_Exist) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	if m.Exist != nil {
+		{
+			size, err := m.Exist.MarshalToSizedBuffer(dAtA[:i])
+			if err != nil {
+				return 0, err
+			}
+			i -= size
+			i = encodeVarintIcs23(dAtA, i, uint64(size))
+		}
+		i--
+		dAtA[i] = 0xa
+	}
+	return len(dAtA) - i, nil
+}
+func (m *CommitmentProof_Nonexist) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *CommitmentProof_Nonexist) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	if m.Nonexist != nil {
+		{
+			size, err := m.Nonexist.MarshalToSizedBuffer(dAtA[:i])
+			if err != nil {
+				return 0, err
+			}
+			i -= size
+			i = encodeVarintIcs23(dAtA, i, uint64(size))
+		}
+		i--
+		dAtA[i] = 0x12
+	}
+	return len(dAtA) - i, nil
+}
+func (m *CommitmentProof_Batch) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *CommitmentProof_Batch) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	if m.Batch != nil {
+		{
+			size, err := m.Batch.MarshalToSizedBuffer(dAtA[:i])
+			if err != nil {
+				return 0, err
+			}
+			i -= size
+			i = encodeVarintIcs23(dAtA, i, uint64(size))
+		}
+		i--
+		dAtA[i] = 0x1a
+	}
+	return len(dAtA) - i, nil
+}
+func (m *LeafOp) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *LeafOp) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *LeafOp) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if len(m.Prefix) > 0 {
+		i -= len(m.Prefix)
+		copy(dAtA[i:], m.Prefix)
+		i = encodeVarintIcs23(dAtA, i, uint64(len(m.Prefix)))
+		i--
+		dAtA[i] = 0x2a
+	}
+	if m.Length != 0 {
+		i = encodeVarintIcs23(dAtA, i, uint64(m.Length))
+		i--
+		dAtA[i] = 0x20
+	}
+	if m.PrehashValue != 0 {
+		i = encodeVarintIcs23(dAtA, i, uint64(m.PrehashValue))
+		i--
+		dAtA[i] = 0x18
+	}
+	if m.PrehashKey != 0 {
+		i = encodeVarintIcs23(dAtA, i, uint64(m.PrehashKey))
+		i--
+		dAtA[i] = 0x10
+	}
+	if m.Hash != 0 {
+		i = encodeVarintIcs23(dAtA, i, uint64(m.Hash))
+		i--
+		dAtA[i] = 0x8
+	}
+	return len(dAtA) - i, nil
+}
+
+func (m *InnerOp) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *InnerOp) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *InnerOp) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if len(m.Suffix) > 0 {
+		i -= len(m.Suffix)
+		copy(dAtA[i:], m.Suffix)
+		i = encodeVarintIcs23(dAtA, i, uint64(len(m.Suffix)))
+		i--
+		dAtA[i] = 0x1a
+	}
+	if len(m.Prefix) > 0 {
+		i -= len(m.Prefix)
+		copy(dAtA[i:], m.Prefix)
+		i = encodeVarintIcs23(dAtA, i, uint64(len(m.Prefix)))
+		i--
+		dAtA[i] = 0x12
+	}
+	if m.Hash != 0 {
+		i = encodeVarintIcs23(dAtA, i, uint64(m.Hash))
+		i--
+		dAtA[i] = 0x8
+	}
+	return len(dAtA) - i, nil
+}
+
+func (m *ProofSpec) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *ProofSpec) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *ProofSpec) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if m.MinDepth != 0 {
+		i = encodeVarintIcs23(dAtA, i, uint64(m.MinDepth))
+		i--
+		dAtA[i] = 0x20
+	}
+	if m.MaxDepth != 0 {
+		i = encodeVarintIcs23(dAtA, i, uint64(m.MaxDepth))
+		i--
+		dAtA[i] = 0x18
+	}
+	if m.InnerSpec != nil {
+		{
+			size, err := m.InnerSpec.MarshalToSizedBuffer(dAtA[:i])
+			if err != nil {
+				return 0, err
+			}
+			i -= size
+			i = encodeVarintIcs23(dAtA, i, uint64(size))
+		}
+		i--
+		dAtA[i] = 0x12
+	}
+	if m.LeafSpec != nil {
+		{
+			size, err := m.LeafSpec.MarshalToSizedBuffer(dAtA[:i])
+			if err != nil {
+				return 0, err
+			}
+			i -= size
+			i = encodeVarintIcs23(dAtA, i, uint64(size))
+		}
+		i--
+		dAtA[i] = 0xa
+	}
+	return len(dAtA) - i, nil
+}
+
+func (m *InnerSpec) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *InnerSpec) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *InnerSpec) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if m.Hash != 0 {
+		i = encodeVarintIcs23(dAtA, i, uint64(m.Hash))
+		i--
+		dAtA[i] = 0x30
+	}
+	if len(m.EmptyChild) > 0 {
+		i -= len(m.EmptyChild)
+		copy(dAtA[i:], m.EmptyChild)
+		i = encodeVarintIcs23(dAtA, i, uint64(len(m.EmptyChild)))
+		i--
+		dAtA[i] = 0x2a
+	}
+	if m.MaxPrefixLength != 0 {
+		i = encodeVarintIcs23(dAtA, i, uint64(m.MaxPrefixLength))
+		i--
+		dAtA[i] = 0x20
+	}
+	if m.MinPrefixLength != 0 {
+		i = encodeVarintIcs23(dAtA, i, uint64(m.MinPrefixLength))
+		i--
+		dAtA[i] = 0x18
+	}
+	if m.ChildSize != 0 {
+		i = encodeVarintIcs23(dAtA, i, uint64(m.ChildSize))
+		i--
+		dAtA[i] = 0x10
+	}
+	if len(m.ChildOrder) > 0 {
+		dAtA10 := make([]byte, len(m.ChildOrder)*10)
+		var j9 int
+		for _, num1 := range m.ChildOrder {
+			num := uint64(num1)
+			for num >= 1<<7 {
+				dAtA10[j9] = uint8(uint64(num)&0x7f | 0x80)
+				num >>= 7
+				j9++
+			}
+			dAtA10[j9] = uint8(num)
+			j9++
+		}
+		i -= j9
+		copy(dAtA[i:], dAtA10[:j9])
+		i = encodeVarintIcs23(dAtA, i, uint64(j9))
+		i--
+		dAtA[i] = 0xa
+	}
+	return len(dAtA) - i, nil
+}
+
+func (m *BatchProof) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *BatchProof) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *BatchProof) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if len(m.Entries) > 0 {
+		for iNdEx := len(m.Entries) - 1; iNdEx >= 0; iNdEx-- {
+			{
+				size, err := m.Entries[iNdEx].MarshalToSizedBuffer(dAtA[:i])
+				if err != nil {
+					return 0, err
+				}
+				i -= size
+				i = encodeVarintIcs23(dAtA, i, uint64(size))
+			}
+			i--
+			dAtA[i] = 0xa
+		}
+	}
+	return len(dAtA) - i, nil
+}
+
+func (m *BatchEntry) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *BatchEntry) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *BatchEntry) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if m.Proof != nil {
+		{
+			size := m.Proof.Size()
+			i -= size
+			if _, err := m.Proof.MarshalTo(dAtA[i:]); err != nil {
+				return 0, err
+			}
+		}
+	}
+	return len(dAtA) - i, nil
+}
+
+func (m *BatchEntry_Exist) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *BatchEntry_Exist) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	if m.Exist != nil {
+		{
+			size, err := m.Exist.MarshalToSizedBuffer(dAtA[:i])
+			if err != nil {
+				return 0, err
+			}
+			i -= size
+			i = encodeVarintIcs23(dAtA, i, uint64(size))
+		}
+		i--
+		dAtA[i] = 0xa
+	}
+	return len(dAtA) - i, nil
+}
+func (m *BatchEntry_Nonexist) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *BatchEntry_Nonexist) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	if m.Nonexist != nil {
+		{
+			size, err := m.Nonexist.MarshalToSizedBuffer(dAtA[:i])
+			if err != nil {
+				return 0, err
+			}
+			i -= size
+			i = encodeVarintIcs23(dAtA, i, uint64(size))
+		}
+		i--
+		dAtA[i] = 0x12
+	}
+	return len(dAtA) - i, nil
+}
+func encodeVarintIcs23(dAtA []byte, offset int, v uint64) int {
+	offset -= sovIcs23(v)
+	base := offset
+	for v >= 1<<7 {
+		dAtA[offset] = uint8(v&0x7f | 0x80)
+		v >>= 7
+		offset++
+	}
+	dAtA[offset] = uint8(v)
+	return base
+}
+func (m *ExistenceProof) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	l = len(m.Key)
+	if l > 0 {
+		n += 1 + l + sovIcs23(uint64(l))
+	}
+	l = len(m.Value)
+	if l > 0 {
+		n += 1 + l + sovIcs23(uint64(l))
+	}
+	if m.Leaf != nil {
+		l = m.Leaf.Size()
+		n += 1 + l + sovIcs23(uint64(l))
+	}
+	if len(m.Path) > 0 {
+		for _, e := range m.Path {
+			l = e.Size()
+			n += 1 + l + sovIcs23(uint64(l))
+		}
+	}
+	return n
+}
+
+func (m *NonExistenceProof) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	l = len(m.Key)
+	if l > 0 {
+		n += 1 + l + sovIcs23(uint64(l))
+	}
+	if m.Left != nil {
+		l = m.Left.Size()
+		n += 1 + l + sovIcs23(uint64(l))
+	}
+	if m.Right != nil {
+		l = m.Right.Size()
+		n += 1 + l + sovIcs23(uint64(l))
+	}
+	return n
+}
+
+func (m *CommitmentProof) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if m.Proof != nil {
+		n += m.Proof.Size()
+	}
+	return n
+}
+
+func (m *CommitmentProof_Exist) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if m.Exist != nil {
+		l = m.Exist.Size()
+		n += 1 + l + sovIcs23(uint64(l))
+	}
+	return n
+}
+func (m *CommitmentProof_Nonexist) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if m.Nonexist != nil {
+		l = m.Nonexist.Size()
+		n += 1 + l + sovIcs23(uint64(l))
+	}
+	return n
+}
+func (m *CommitmentProof_Batch) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if m.Batch != nil {
+		l = m.Batch.Size()
+		n += 1 + l + sovIcs23(uint64(l))
+	}
+	return n
+}
+func (m *LeafOp) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if m.Hash != 0 {
+		n += 1 + sovIcs23(uint64(m.Hash))
+	}
+	if m.PrehashKey != 0 {
+		n += 1 + sovIcs23(uint64(m.PrehashKey))
+	}
+	if m.PrehashValue != 0 {
+		n += 1 + sovIcs23(uint64(m.PrehashValue))
+	}
+	if m.Length != 0 {
+		n += 1 + sovIcs23(uint64(m.Length))
+	}
+	l = len(m.Prefix)
+	if l > 0 {
+		n += 1 + l + sovIcs23(uint64(l))
+	}
+	return n
+}
+
+func (m *InnerOp) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if m.Hash != 0 {
+		n += 1 + sovIcs23(uint64(m.Hash))
+	}
+	l = len(m.Prefix)
+	if l > 0 {
+		n += 1 + l + sovIcs23(uint64(l))
+	}
+	l = len(m.Suffix)
+	if l > 0 {
+		n += 1 + l + sovIcs23(uint64(l))
+	}
+	return n
+}
+
+func (m *ProofSpec) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if m.LeafSpec != nil {
+		l = m.LeafSpec.Size()
+		n += 1 + l + sovIcs23(uint64(l))
+	}
+	if m.InnerSpec != nil {
+		l = m.InnerSpec.Size()
+		n += 1 + l + sovIcs23(uint64(l))
+	}
+	if m.MaxDepth != 0 {
+		n += 1 + sovIcs23(uint64(m.MaxDepth))
+	}
+	if m.MinDepth != 0 {
+		n += 1 + sovIcs23(uint64(m.MinDepth))
+	}
+	return n
+}
+
+func (m *InnerSpec) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if len(m.ChildOrder) > 0 {
+		l = 0
+		for _, e := range m.ChildOrder {
+			l += sovIcs23(uint64(e))
+		}
+		n += 1 + sovIcs23(uint64(l)) + l
+	}
+	if m.ChildSize != 0 {
+		n += 1 + sovIcs23(uint64(m.ChildSize))
+	}
+	if m.MinPrefixLength != 0 {
+		n += 1 + sovIcs23(uint64(m.MinPrefixLength))
+	}
+	if m.MaxPrefixLength != 0 {
+		n += 1 + sovIcs23(uint64(m.MaxPrefixLength))
+	}
+	l = len(m.EmptyChild)
+	if l > 0 {
+		n += 1 + l + sovIcs23(uint64(l))
+	}
+	if m.Hash != 0 {
+		n += 1 + sovIcs23(uint64(m.Hash))
+	}
+	return n
+}
+
+func (m *BatchProof) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if len(m.Entries) > 0 {
+		for _, e := range m.Entries {
+			l = e.Size()
+			n += 1 + l + sovIcs23(uint64(l))
+		}
+	}
+	return n
+}
+
+func (m *BatchEntry) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if m.Proof != nil {
+		n += m.Proof.Size()
+	}
+	return n
+}
+
+func (m *BatchEntry_Exist) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if m.Exist != nil {
+		l = m.Exist.Size()
+		n += 1 + l + sovIcs23(uint64(l))
+	}
+	return n
+}
+func (m *BatchEntry_Nonexist) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if m.Nonexist != nil {
+		l = m.Nonexist.Size()
+		n += 1 + l + sovIcs23(uint64(l))
+	}
+	return n
+}
+
+func sovIcs23(x uint64) (n int) {
+	return (math_bits.Len64(x|1) + 6) / 7
+}
+func sozIcs23(x uint64) (n int) {
+	return sovIcs23(uint64((x << 1) ^ uint64((int64(x) >> 63))))
+}
+func (m *ExistenceProof) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowIcs23
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: ExistenceProof: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: ExistenceProof: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Key", wireType)
+			}
+			var byteLen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowIcs23
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				byteLen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if byteLen < 0 {
+				return ErrInvalidLengthIcs23
+			}
+			postIndex := iNdEx + byteLen
+			if postIndex < 0 {
+				return ErrInvalidLengthIcs23
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Key = append(m.Key[:0], dAtA[iNdEx:postIndex]...)
+			if m.Key == nil {
+				m.Key = []byte{}
+			}
+			iNdEx = postIndex
+		case 2:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Value", wireType)
+			}
+			var byteLen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowIcs23
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				byteLen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if byteLen < 0 {
+				return ErrInvalidLengthIcs23
+			}
+			postIndex := iNdEx + byteLen
+			if postIndex < 0 {
+				return ErrInvalidLengthIcs23
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Value = append(m.Value[:0], dAtA[iNdEx:postIndex]...)
+			if m.Value == nil {
+				m.Value = []byte{}
+			}
+			iNdEx = postIndex
+		case 3:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Leaf", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowIcs23
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthIcs23
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthIcs23
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			if m.Leaf == nil {
+				m.Leaf = &LeafOp{}
+			}
+			if err := m.Leaf.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		case 4:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Path", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowIcs23
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthIcs23
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthIcs23
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Path = append(m.Path, &InnerOp{})
+			if err := m.Path[len(m.Path)-1].Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		default:
+			iNdEx = preIndex
+			skippy, err := skipIcs23(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if skippy < 0 {
+				return ErrInvalidLengthIcs23
+			}
+			if (iNdEx + skippy) < 0 {
+				return ErrInvalidLengthIcs23
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *NonExistenceProof) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowIcs23
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: NonExistenceProof: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: NonExistenceProof: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Key", wireType)
+			}
+			var byteLen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowIcs23
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				byteLen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if byteLen < 0 {
+				return ErrInvalidLengthIcs23
+			}
+			postIndex := iNdEx + byteLen
+			if postIndex < 0 {
+				return ErrInvalidLengthIcs23
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Key = append(m.Key[:0], dAtA[iNdEx:postIndex]...)
+			if m.Key == nil {
+				m.Key = []byte{}
+			}
+			iNdEx = postIndex
+		case 2:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Left", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowIcs23
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthIcs23
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthIcs23
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			if m.Left == nil {
+				m.Left = &ExistenceProof{}
+			}
+			if err := m.Left.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		case 3:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Right", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowIcs23
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthIcs23
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthIcs23
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			if m.Right == nil {
+				m.Right = &ExistenceProof{}
+			}
+			if err := m.Right.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		default:
+			iNdEx = preIndex
+			skippy, err := skipIcs23(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if skippy < 0 {
+				return ErrInvalidLengthIcs23
+			}
+			if (iNdEx + skippy) < 0 {
+				return ErrInvalidLengthIcs23
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *CommitmentProof) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowIcs23
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: CommitmentProof: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: CommitmentProof: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Exist", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowIcs23
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthIcs23
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthIcs23
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			v := &ExistenceProof{}
+			if err := v.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			m.Proof = &CommitmentProof_Exist{v}
+			iNdEx = postIndex
+		case 2:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Nonexist", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowIcs23
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthIcs23
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthIcs23
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			v := &NonExistenceProof{}
+			if err := v.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			m.Proof = &CommitmentProof_Nonexist{v}
+			iNdEx = postIndex
+		case 3:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Batch", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowIcs23
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthIcs23
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthIcs23
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			v := &BatchProof{}
+			if err := v.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			m.Proof = &CommitmentProof_Batch{v}
+			iNdEx = postIndex
+		default:
+			iNdEx = preIndex
+			skippy, err := skipIcs23(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if skippy < 0 {
+				return ErrInvalidLengthIcs23
+			}
+			if (iNdEx + skippy) < 0 {
+				return ErrInvalidLengthIcs23
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *LeafOp) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowIcs23
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: LeafOp: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: LeafOp: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Hash", wireType)
+			}
+			m.Hash = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowIcs23
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.Hash |= HashOp(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 2:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field PrehashKey", wireType)
+			}
+			m.PrehashKey = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowIcs23
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.PrehashKey |= HashOp(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 3:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field PrehashValue", wireType)
+			}
+			m.PrehashValue = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowIcs23
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.PrehashValue |= HashOp(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 4:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Length", wireType)
+			}
+			m.Length = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowIcs23
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.Length |= LengthOp(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 5:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Prefix", wireType)
+			}
+			var byteLen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowIcs23
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				byteLen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if byteLen < 0 {
+				return ErrInvalidLengthIcs23
+			}
+			postIndex := iNdEx + byteLen
+			if postIndex < 0 {
+				return ErrInvalidLengthIcs23
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Prefix = append(m.Prefix[:0], dAtA[iNdEx:postIndex]...)
+			if m.Prefix == nil {
+				m.Prefix = []byte{}
+			}
+			iNdEx = postIndex
+		default:
+			iNdEx = preIndex
+			skippy, err := skipIcs23(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if skippy < 0 {
+				return ErrInvalidLengthIcs23
+			}
+			if (iNdEx + skippy) < 0 {
+				return ErrInvalidLengthIcs23
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *InnerOp) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowIcs23
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: InnerOp: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: InnerOp: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Hash", wireType)
+			}
+			m.Hash = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowIcs23
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.Hash |= HashOp(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 2:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Prefix", wireType)
+			}
+			var byteLen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowIcs23
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				byteLen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if byteLen < 0 {
+				return ErrInvalidLengthIcs23
+			}
+			postIndex := iNdEx + byteLen
+			if postIndex < 0 {
+				return ErrInvalidLengthIcs23
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Prefix = append(m.Prefix[:0], dAtA[iNdEx:postIndex]...)
+			if m.Prefix == nil {
+				m.Prefix = []byte{}
+			}
+			iNdEx = postIndex
+		case 3:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Suffix", wireType)
+			}
+			var byteLen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowIcs23
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				byteLen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if byteLen < 0 {
+				return ErrInvalidLengthIcs23
+			}
+			postIndex := iNdEx + byteLen
+			if postIndex < 0 {
+				return ErrInvalidLengthIcs23
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Suffix = append(m.Suffix[:0], dAtA[iNdEx:postIndex]...)
+			if m.Suffix == nil {
+				m.Suffix = []byte{}
+			}
+			iNdEx = postIndex
+		default:
+			iNdEx = preIndex
+			skippy, err := skipIcs23(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if skippy < 0 {
+				return ErrInvalidLengthIcs23
+			}
+			if (iNdEx + skippy) < 0 {
+				return ErrInvalidLengthIcs23
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *ProofSpec) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowIcs23
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: ProofSpec: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: ProofSpec: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field LeafSpec", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowIcs23
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthIcs23
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthIcs23
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			if m.LeafSpec == nil {
+				m.LeafSpec = &LeafOp{}
+			}
+			if err := m.LeafSpec.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		case 2:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field InnerSpec", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowIcs23
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthIcs23
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthIcs23
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			if m.InnerSpec == nil {
+				m.InnerSpec = &InnerSpec{}
+			}
+			if err := m.InnerSpec.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		case 3:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field MaxDepth", wireType)
+			}
+			m.MaxDepth = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowIcs23
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.MaxDepth |= int32(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 4:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field MinDepth", wireType)
+			}
+			m.MinDepth = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowIcs23
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.MinDepth |= int32(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		default:
+			iNdEx = preIndex
+			skippy, err := skipIcs23(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if skippy < 0 {
+				return ErrInvalidLengthIcs23
+			}
+			if (iNdEx + skippy) < 0 {
+				return ErrInvalidLengthIcs23
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *InnerSpec) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowIcs23
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: InnerSpec: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: InnerSpec: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType == 0 {
+				var v int32
+				for shift := uint(0); ; shift += 7 {
+					if shift >= 64 {
+						return ErrIntOverflowIcs23
+					}
+					if iNdEx >= l {
+						return io.ErrUnexpectedEOF
+					}
+					b := dAtA[iNdEx]
+					iNdEx++
+					v |= int32(b&0x7F) << shift
+					if b < 0x80 {
+						break
+					}
+				}
+				m.ChildOrder = append(m.ChildOrder, v)
+			} else if wireType == 2 {
+				var packedLen int
+				for shift := uint(0); ; shift += 7 {
+					if shift >= 64 {
+						return ErrIntOverflowIcs23
+					}
+					if iNdEx >= l {
+						return io.ErrUnexpectedEOF
+					}
+					b := dAtA[iNdEx]
+					iNdEx++
+					packedLen |= int(b&0x7F) << shift
+					if b < 0x80 {
+						break
+					}
+				}
+				if packedLen < 0 {
+					return ErrInvalidLengthIcs23
+				}
+				postIndex := iNdEx + packedLen
+				if postIndex < 0 {
+					return ErrInvalidLengthIcs23
+				}
+				if postIndex > l {
+					return io.ErrUnexpectedEOF
+				}
+				var elementCount int
+				var count int
+				for _, integer := range dAtA[iNdEx:postIndex] {
+					if integer < 128 {
+						count++
+					}
+				}
+				elementCount = count
+				if elementCount != 0 && len(m.ChildOrder) == 0 {
+					m.ChildOrder = make([]int32, 0, elementCount)
+				}
+				for iNdEx < postIndex {
+					var v int32
+					for shift := uint(0); ; shift += 7 {
+						if shift >= 64 {
+							return ErrIntOverflowIcs23
+						}
+						if iNdEx >= l {
+							return io.ErrUnexpectedEOF
+						}
+						b := dAtA[iNdEx]
+						iNdEx++
+						v |= int32(b&0x7F) << shift
+						if b < 0x80 {
+							break
+						}
+					}
+					m.ChildOrder = append(m.ChildOrder, v)
+				}
+			} else {
+				return fmt.Errorf("proto: wrong wireType = %d for field ChildOrder", wireType)
+			}
+		case 2:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field ChildSize", wireType)
+			}
+			m.ChildSize = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowIcs23
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.ChildSize |= int32(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 3:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field MinPrefixLength", wireType)
+			}
+			m.MinPrefixLength = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowIcs23
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.MinPrefixLength |= int32(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 4:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field MaxPrefixLength", wireType)
+			}
+			m.MaxPrefixLength = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowIcs23
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.MaxPrefixLength |= int32(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 5:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field EmptyChild", wireType)
+			}
+			var byteLen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowIcs23
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				byteLen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if byteLen < 0 {
+				return ErrInvalidLengthIcs23
+			}
+			postIndex := iNdEx + byteLen
+			if postIndex < 0 {
+				return ErrInvalidLengthIcs23
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.EmptyChild = append(m.EmptyChild[:0], dAtA[iNdEx:postIndex]...)
+			if m.EmptyChild == nil {
+				m.EmptyChild = []byte{}
+			}
+			iNdEx = postIndex
+		case 6:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Hash", wireType)
+			}
+			m.Hash = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowIcs23
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.Hash |= HashOp(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		default:
+			iNdEx = preIndex
+			skippy, err := skipIcs23(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if skippy < 0 {
+				return ErrInvalidLengthIcs23
+			}
+			if (iNdEx + skippy) < 0 {
+				return ErrInvalidLengthIcs23
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *BatchProof) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowIcs23
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: BatchProof: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: BatchProof: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Entries", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowIcs23
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthIcs23
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthIcs23
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Entries = append(m.Entries, &BatchEntry{})
+			if err := m.Entries[len(m.Entries)-1].Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		default:
+			iNdEx = preIndex
+			skippy, err := skipIcs23(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if skippy < 0 {
+				return ErrInvalidLengthIcs23
+			}
+			if (iNdEx + skippy) < 0 {
+				return ErrInvalidLengthIcs23
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *BatchEntry) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowIcs23
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: BatchEntry: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: BatchEntry: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Exist", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowIcs23
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthIcs23
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthIcs23
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			v := &ExistenceProof{}
+			if err := v.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			m.Proof = &BatchEntry_Exist{v}
+			iNdEx = postIndex
+		case 2:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Nonexist", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowIcs23
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthIcs23
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthIcs23
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			v := &NonExistenceProof{}
+			if err := v.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			m.Proof = &BatchEntry_Nonexist{v}
+			iNdEx = postIndex
+		default:
+			iNdEx = preIndex
+			skippy, err := skipIcs23(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if skippy < 0 {
+				return ErrInvalidLengthIcs23
+			}
+			if (iNdEx + skippy) < 0 {
+				return ErrInvalidLengthIcs23
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func skipIcs23(dAtA []byte) (n int, err error) {
+	l := len(dAtA)
+	iNdEx := 0
+	depth := 0
+	for iNdEx < l {
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return 0, ErrIntOverflowIcs23
+			}
+			if iNdEx >= l {
+				return 0, io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= (uint64(b) & 0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		wireType := int(wire & 0x7)
+		switch wireType {
+		case 0:
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return 0, ErrIntOverflowIcs23
+				}
+				if iNdEx >= l {
+					return 0, io.ErrUnexpectedEOF
+				}
+				iNdEx++
+				if dAtA[iNdEx-1] < 0x80 {
+					break
+				}
+			}
+		case 1:
+			iNdEx += 8
+		case 2:
+			var length int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return 0, ErrIntOverflowIcs23
+				}
+				if iNdEx >= l {
+					return 0, io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				length |= (int(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if length < 0 {
+				return 0, ErrInvalidLengthIcs23
+			}
+			iNdEx += length
+		case 3:
+			depth++
+		case 4:
+			if depth == 0 {
+				return 0, ErrUnexpectedEndOfGroupIcs23
+			}
+			depth--
+		case 5:
+			iNdEx += 4
+		default:
+			return 0, fmt.Errorf("proto: illegal wireType %d", wireType)
+		}
+		if iNdEx < 0 {
+			return 0, ErrInvalidLengthIcs23
+		}
+		if depth == 0 {
+			return iNdEx, nil
+		}
+	}
+	return 0, io.ErrUnexpectedEOF
+}
+
+var (
+	ErrInvalidLengthIcs23        = fmt.Errorf("proto: negative length found during unmarshaling")
+	ErrIntOverflowIcs23          = fmt.Errorf("proto: integer overflow")
+	ErrUnexpectedEndOfGroupIcs23 = fmt.Errorf("proto: unexpected end of group")
+)
@@ -0,0 +1,131 @@
+package iavl
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func setupICS23Tree(t *testing.T) *MutableTree {
+	tree, err := getTestTree(0)
+	require.NoError(t, err)
+	for i := 0; i < 200; i += 2 {
+		tree.Set([]byte(fmt.Sprintf("key-%03d", i)), []byte(fmt.Sprintf("value-%d", i)))
+	}
+	_, _, err = tree.SaveVersion()
+	require.NoError(t, err)
+	return tree
+}
+
+func TestICS23_Membership(t *testing.T) {
+	tree := setupICS23Tree(t)
+	root := tree.Hash()
+
+	for _, i := range []int{0, 2, 100, 150, 198} {
+		key := []byte(fmt.Sprintf("key-%03d", i))
+		value := []byte(fmt.Sprintf("value-%d", i))
+		proof, err := tree.GetMembershipProof(key)
+		require.NoError(t, err)
+
+		// Round-trip through the wire format.
+		bz, err := proof.Marshal()
+		require.NoError(t, err)
+		proof = &CommitmentProof{}
+		require.NoError(t, proof.Unmarshal(bz))
+
+		assert.NoError(t, VerifyMembership(IavlSpec, root, proof, key, value), "key %s", key)
+		assert.Error(t, VerifyMembership(IavlSpec, root, proof, key, []byte("other")), "key %s", key)
+		assert.Error(t, VerifyMembership(IavlSpec, []byte("foo"), proof, key, value), "key %s", key)
+		assert.Error(t, VerifyNonMembership(IavlSpec, root, proof, key), "key %s", key)
+	}
+
+	_, err := tree.GetMembershipProof([]byte("key-001"))
+	require.Error(t, err)
+}
+
+func TestICS23_NonMembership(t *testing.T) {
+	tree := setupICS23Tree(t)
+	root := tree.Hash()
+
+	for _, key := range []string{"a", "key-001", "key-101", "key-197", "key-199", "z"} {
+		proof, err := tree.GetNonMembershipProof([]byte(key))
+		require.NoError(t, err)
+		assert.NoError(t, VerifyNonMembership(IavlSpec, root, proof, []byte(key)), "key %s", key)
+		assert.Error(t, VerifyNonMembership(IavlSpec, []byte("foo"), proof, []byte(key)), "key %s", key)
+	}
+
+	_, err := tree.GetNonMembershipProof([]byte("key-002"))
+	require.Error(t, err)
+
+	// Neighbours which are not adjacent must be rejected.
+	proof, err := tree.GetNonMembershipProof([]byte("key-101"))
+	require.NoError(t, err)
+	farLeft, err := tree.createExistenceProof([]byte("key-096"))
+	require.NoError(t, err)
+	proof.GetNonexist().Left = farLeft
+	require.Error(t, VerifyNonMembership(IavlSpec, root, proof, []byte("key-101")))
+
+	// So must a missing neighbour, unless the other one is at the edge of the tree.
+	proof, err = tree.GetNonMembershipProof([]byte("key-101"))
+	require.NoError(t, err)
+	proof.GetNonexist().Left = nil
+	require.Error(t, VerifyNonMembership(IavlSpec, root, proof, []byte("key-101")))
+	proof, err = tree.GetNonMembershipProof([]byte("key-101"))
+	require.NoError(t, err)
+	proof.GetNonexist().Right = nil
+	require.Error(t, VerifyNonMembership(IavlSpec, root, proof, []byte("key-101")))
+}
+
+func TestICS23_Batch(t *testing.T) {
+	tree := setupICS23Tree(t)
+	root := tree.Hash()
+
+	keys := [][]byte{[]byte("key-010"), []byte("key-011"), []byte("key-120"), []byte("zzz")}
+	proof, err := tree.GetBatchProof(keys)
+	require.NoError(t, err)
+	require.Len(t, proof.GetBatch().Entries, len(keys))
+
+	require.NoError(t, BatchVerifyMembership(IavlSpec, root, proof, map[string][]byte{
+		"key-010": []byte("value-10"),
+		"key-120": []byte("value-120"),
+	}))
+	require.NoError(t, BatchVerifyNonMembership(IavlSpec, root, proof, [][]byte{[]byte("key-011"), []byte("zzz")}))
+
+	require.Error(t, BatchVerifyMembership(IavlSpec, root, proof, map[string][]byte{
+		"key-012": []byte("value-12"),
+	}))
+	require.Error(t, BatchVerifyNonMembership(IavlSpec, root, proof, [][]byte{[]byte("key-010")}))
+}
+
+func TestICS23_Spec(t *testing.T) {
+	tree := setupICS23Tree(t)
+	root := tree.Hash()
+	key, value := []byte("key-050"), []byte("value-50")
+
+	// The leaf prefix must encode a leaf node.
+	proof, err := tree.GetMembershipProof(key)
+	require.NoError(t, err)
+	proof.GetExist().Leaf.Prefix = append([]byte{0}, proof.GetExist().Leaf.Prefix[2:]...)
+	require.Error(t, VerifyMembership(IavlSpec, root, proof, key, value))
+
+	// Hash ops must match the spec.
+	proof, err = tree.GetMembershipProof(key)
+	require.NoError(t, err)
+	proof.GetExist().Path[0].Hash = HashOp_SHA512
+	require.Error(t, VerifyMembership(IavlSpec, root, proof, key, value))
+
+	// Inner ops must not look like leaf ops.
+	proof, err = tree.GetMembershipProof(key)
+	require.NoError(t, err)
+	proof.GetExist().Path[0].Prefix[0] = 0
+	require.Error(t, VerifyMembership(IavlSpec, root, proof, key, value))
+
+	empty, err := getTestTree(0)
+	require.NoError(t, err)
+	_, err = empty.GetMembershipProof(key)
+	require.Error(t, err)
+	_, err = empty.GetNonMembershipProof(key)
+	require.Error(t, err)
+}
@@ -0,0 +1,463 @@
+package iavl
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/binary"
+
+	"github.com/gogo/protobuf/proto"
+	"github.com/pkg/errors"
+
+	"github.com/tendermint/iavl/internal/encoding"
+)
+
+// VerifyMembership verifies that the ICS23 proof proves the key to have the given value under
+// the root. The proof may be an existence proof or a batch proof containing one for the key.
+func VerifyMembership(spec *ProofSpec, root []byte, proof *CommitmentProof, key, value []byte) error {
+	exist := getExistProofForKey(proof, key)
+	if exist == nil {
+		return errors.Wrapf(ErrInvalidProof, "no existence proof for key %X", key)
+	}
+	return exist.Verify(spec, root, key, value)
+}
+
+// VerifyNonMembership verifies that the ICS23 proof proves the key to be absent under the root.
+// The proof may be a non-existence proof or a batch proof containing one for the key.
+func VerifyNonMembership(spec *ProofSpec, root []byte, proof *CommitmentProof, key []byte) error {
+	nonexist := getNonExistProofForKey(proof, key)
+	if nonexist == nil {
+		return errors.Wrapf(ErrInvalidProof, "no non-existence proof for key %X", key)
+	}
+	return nonexist.Verify(spec, root, key)
+}
+
+// BatchVerifyMembership verifies that the ICS23 proof proves all given key/value pairs.
+func BatchVerifyMembership(spec *ProofSpec, root []byte, proof *CommitmentProof, items map[string][]byte) error {
+	for key, value := range items {
+		if err := VerifyMembership(spec, root, proof, []byte(key), value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// BatchVerifyNonMembership verifies that the ICS23 proof proves all given keys to be absent.
+func BatchVerifyNonMembership(spec *ProofSpec, root []byte, proof *CommitmentProof, keys [][]byte) error {
+	for _, key := range keys {
+		if err := VerifyNonMembership(spec, root, proof, key); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func getExistProofForKey(proof *CommitmentProof, key []byte) *ExistenceProof {
+	switch p := proof.GetProof().(type) {
+	case *CommitmentProof_Exist:
+		if bytes.Equal(p.Exist.GetKey(), key) {
+			return p.Exist
+		}
+	case *CommitmentProof_Batch:
+		for _, entry := range p.Batch.GetEntries() {
+			if exist := entry.GetExist(); exist != nil && bytes.Equal(exist.Key, key) {
+				return exist
+			}
+		}
+	}
+	return nil
+}
+
+func getNonExistProofForKey(proof *CommitmentProof, key []byte) *NonExistenceProof {
+	switch p := proof.GetProof().(type) {
+	case *CommitmentProof_Nonexist:
+		if bytes.Equal(p.Nonexist.GetKey(), key) {
+			return p.Nonexist
+		}
+	case *CommitmentProof_Batch:
+		for _, entry := range p.Batch.GetEntries() {
+			if nonexist := entry.GetNonexist(); nonexist != nil && bytes.Equal(nonexist.Key, key) {
+				return nonexist
+			}
+		}
+	}
+	return nil
+}
+
+//----------------------------------------
+
+// Verify verifies that the proof is valid for the spec, and proves the key to have the given
+// value under the root.
+func (p *ExistenceProof) Verify(spec *ProofSpec, root, key, value []byte) error {
+	if err := p.CheckAgainstSpec(spec); err != nil {
+		return err
+	}
+	if !bytes.Equal(key, p.Key) {
+		return errors.Wrapf(ErrInvalidProof, "proof is for key %X, not %X", p.Key, key)
+	}
+	if !bytes.Equal(value, p.Value) {
+		return errors.Wrap(ErrInvalidProof, "value not same")
+	}
+	calc, err := p.Calculate()
+	if err != nil {
+		return err
+	}
+	if !bytes.Equal(root, calc) {
+		return errors.Wrap(ErrInvalidRoot, "root hash doesn't match")
+	}
+	return nil
+}
+
+// Calculate computes the root hash of the proof, without checking it against a spec.
+func (p *ExistenceProof) Calculate() ([]byte, error) {
+	if p.GetLeaf() == nil {
+		return nil, errors.Wrap(ErrInvalidProof, "existence proof must have a leaf op")
+	}
+	res, err := p.Leaf.Apply(p.Key, p.Value)
+	if err != nil {
+		return nil, err
+	}
+	for _, step := range p.Path {
+		res, err = step.Apply(res)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return res, nil
+}
+
+// CheckAgainstSpec checks that the leaf and inner ops of the proof match the spec. For the
+// IavlSpec, the node heights, sizes and versions encoded in the op prefixes are checked as well.
+func (p *ExistenceProof) CheckAgainstSpec(spec *ProofSpec) error {
+	if spec.GetLeafSpec() == nil || spec.GetInnerSpec() == nil {
+		return errors.Wrap(ErrInvalidInputs, "spec must have leaf and inner specs")
+	}
+	if p.GetLeaf() == nil {
+		return errors.Wrap(ErrInvalidProof, "existence proof must have a leaf op")
+	}
+	isIavl := proto.Equal(spec, IavlSpec)
+
+	if err := p.Leaf.checkAgainstSpec(spec); err != nil {
+		return err
+	}
+	if isIavl {
+		if err := validateIavlPrefix(p.Leaf.Prefix, 0); err != nil {
+			return errors.Wrap(err, "leaf op")
+		}
+	}
+	if spec.MinDepth > 0 && len(p.Path) < int(spec.MinDepth) {
+		return errors.Wrapf(ErrInvalidProof, "inner ops depth %d below minimum %d", len(p.Path), spec.MinDepth)
+	}
+	if spec.MaxDepth > 0 && len(p.Path) > int(spec.MaxDepth) {
+		return errors.Wrapf(ErrInvalidProof, "inner ops depth %d above maximum %d", len(p.Path), spec.MaxDepth)
+	}
+	for i, step := range p.Path {
+		if err := step.checkAgainstSpec(spec); err != nil {
+			return errors.Wrapf(err, "inner op %d", i)
+		}
+		if isIavl {
+			if err := validateIavlPrefix(step.Prefix, i+1); err != nil {
+				return errors.Wrapf(err, "inner op %d", i)
+			}
+		}
+	}
+	return nil
+}
+
+// validateIavlPrefix checks the height, size and version at the start of an IAVL op prefix. The
+// height must be at least minHeight, and leaf nodes (minHeight 0) must have height 0 and size 1.
+func validateIavlPrefix(prefix []byte, minHeight int) error {
+	height, n, err := encoding.DecodeInt8(prefix)
+	if err != nil || int(height) < minHeight || (minHeight == 0 && height != 0) {
+		return errors.Wrap(ErrInvalidProof, "invalid height in prefix")
+	}
+	prefix = prefix[n:]
+	size, n, err := encoding.DecodeVarint(prefix)
+	if err != nil || size < 1 || (minHeight == 0 && size != 1) || (minHeight > 0 && size < 2) {
+		return errors.Wrap(ErrInvalidProof, "invalid size in prefix")
+	}
+	prefix = prefix[n:]
+	version, _, err := encoding.DecodeVarint(prefix)
+	if err != nil || version < 1 {
+		return errors.Wrap(ErrInvalidProof, "invalid version in prefix")
+	}
+	return nil
+}
+
+// Verify verifies that the proof is valid for the spec, and proves the key to be absent under the
+// root: the left and right neighbours must exist, surround the key, and be adjacent in the tree.
+// A missing neighbour must be replaced by the other one being the leftmost or rightmost leaf.
+func (p *NonExistenceProof) Verify(spec *ProofSpec, root, key []byte) error {
+	if p.Left == nil && p.Right == nil {
+		return errors.Wrap(ErrInvalidProof, "both left and right neighbours are missing")
+	}
+	if p.Left != nil {
+		if err := p.Left.Verify(spec, root, p.Left.Key, p.Left.Value); err != nil {
+			return errors.Wrap(err, "left neighbour")
+		}
+		if bytes.Compare(p.Left.Key, key) >= 0 {
+			return errors.Wrap(ErrInvalidProof, "left neighbour key isn't before key")
+		}
+	}
+	if p.Right != nil {
+		if err := p.Right.Verify(spec, root, p.Right.Key, p.Right.Value); err != nil {
+			return errors.Wrap(err, "right neighbour")
+		}
+		if bytes.Compare(key, p.Right.Key) >= 0 {
+			return errors.Wrap(ErrInvalidProof, "right neighbour key isn't after key")
+		}
+	}
+
+	inner := spec.InnerSpec
+	switch {
+	case p.Left == nil:
+		if !isLeftMost(inner, p.Right.Path) {
+			return errors.Wrap(ErrInvalidProof, "left neighbour missing, but right isn't leftmost")
+		}
+	case p.Right == nil:
+		if !isRightMost(inner, p.Left.Path) {
+			return errors.Wrap(ErrInvalidProof, "right neighbour missing, but left isn't rightmost")
+		}
+	default:
+		if !isLeftNeighbor(inner, p.Left.Path, p.Right.Path) {
+			return errors.Wrap(ErrInvalidProof, "left and right neighbours aren't adjacent")
+		}
+	}
+	return nil
+}
+
+//----------------------------------------
+
+// Apply computes the leaf hash of the key and value.
+func (op *LeafOp) Apply(key, value []byte) ([]byte, error) {
+	if len(key) == 0 {
+		return nil, errors.Wrap(ErrInvalidInputs, "leaf op needs key")
+	}
+	if len(value) == 0 {
+		return nil, errors.Wrap(ErrInvalidInputs, "leaf op needs value")
+	}
+	pkey, err := prepareLeafData(op.PrehashKey, op.Length, key)
+	if err != nil {
+		return nil, errors.Wrap(err, "prehash key")
+	}
+	pvalue, err := prepareLeafData(op.PrehashValue, op.Length, value)
+	if err != nil {
+		return nil, errors.Wrap(err, "prehash value")
+	}
+	data := make([]byte, 0, len(op.Prefix)+len(pkey)+len(pvalue))
+	data = append(data, op.Prefix...)
+	data = append(data, pkey...)
+	data = append(data, pvalue...)
+	return doHash(op.Hash, data)
+}
+
+func (op *LeafOp) checkAgainstSpec(spec *ProofSpec) error {
+	lspec := spec.LeafSpec
+	if op.Hash != lspec.Hash {
+		return errors.Wrapf(ErrInvalidProof, "unexpected leaf hash op %v", op.Hash)
+	}
+	if op.PrehashKey != lspec.PrehashKey {
+		return errors.Wrapf(ErrInvalidProof, "unexpected leaf prehash key op %v", op.PrehashKey)
+	}
+	if op.PrehashValue != lspec.PrehashValue {
+		return errors.Wrapf(ErrInvalidProof, "unexpected leaf prehash value op %v", op.PrehashValue)
+	}
+	if op.Length != lspec.Length {
+		return errors.Wrapf(ErrInvalidProof, "unexpected leaf length op %v", op.Length)
+	}
+	if !bytes.HasPrefix(op.Prefix, lspec.Prefix) {
+		return errors.Wrapf(ErrInvalidProof, "leaf prefix %X doesn't start with %X", op.Prefix, lspec.Prefix)
+	}
+	return nil
+}
+
+// Apply computes the hash of an inner node from the hash of one of its children.
+func (op *InnerOp) Apply(child []byte) ([]byte, error) {
+	if len(child) == 0 {
+		return nil, errors.Wrap(ErrInvalidInputs, "inner op needs child value")
+	}
+	data := make([]byte, 0, len(op.Prefix)+len(child)+len(op.Suffix))
+	data = append(data, op.Prefix...)
+	data = append(data, child...)
+	data = append(data, op.Suffix...)
+	return doHash(op.Hash, data)
+}
+
+func (op *InnerOp) checkAgainstSpec(spec *ProofSpec) error {
+	ispec := spec.InnerSpec
+	if op.Hash != ispec.Hash {
+		return errors.Wrapf(ErrInvalidProof, "unexpected inner hash op %v", op.Hash)
+	}
+	leafPrefix := spec.LeafSpec.Prefix
+	if len(leafPrefix) > 0 && bytes.HasPrefix(op.Prefix, leafPrefix) {
+		return errors.Wrap(ErrInvalidProof, "inner prefix starts with leaf prefix")
+	}
+	if len(op.Prefix) < int(ispec.MinPrefixLength) {
+		return errors.Wrap(ErrInvalidProof, "inner prefix too short")
+	}
+	maxLeftChildBytes := (len(ispec.ChildOrder) - 1) * int(ispec.ChildSize)
+	if len(op.Prefix) > int(ispec.MaxPrefixLength)+maxLeftChildBytes {
+		return errors.Wrap(ErrInvalidProof, "inner prefix too long")
+	}
+	if ispec.ChildSize > 0 && len(op.Suffix)%int(ispec.ChildSize) != 0 {
+		return errors.Wrap(ErrInvalidProof, "inner suffix is not a multiple of the child size")
+	}
+	return nil
+}
+
+//----------------------------------------
+// Neighbour checks. Paths are ordered from the leaf, so the root-most op is last.
+
+// isLeftMost returns true if the path only goes through the leftmost child of each node.
+func isLeftMost(spec *InnerSpec, path []*InnerOp) bool {
+	minPrefix, maxPrefix, suffix, ok := getPadding(spec, 0)
+	if !ok {
+		return false
+	}
+	for _, step := range path {
+		if !hasPadding(step, minPrefix, maxPrefix, suffix) {
+			return false
+		}
+	}
+	return true
+}
+
+// isRightMost returns true if the path only goes through the rightmost child of each node.
+func isRightMost(spec *InnerSpec, path []*InnerOp) bool {
+	minPrefix, maxPrefix, suffix, ok := getPadding(spec, int32(len(spec.ChildOrder)-1))
+	if !ok {
+		return false
+	}
+	for _, step := range path {
+		if !hasPadding(step, minPrefix, maxPrefix, suffix) {
+			return false
+		}
+	}
+	return true
+}
+
+// isLeftNeighbor returns true if the leaves of the left and right paths are adjacent: below their
+// common ancestor the paths must branch into adjacent children, with the left path going right
+// and the right path going left from there on.
+func isLeftNeighbor(spec *InnerSpec, left, right []*InnerOp) bool {
+	top, topRight := len(left)-1, len(right)-1
+	for top >= 0 && topRight >= 0 &&
+		bytes.Equal(left[top].Prefix, right[topRight].Prefix) &&
+		bytes.Equal(left[top].Suffix, right[topRight].Suffix) {
+		top--
+		topRight--
+	}
+	if top < 0 || topRight < 0 {
+		return false
+	}
+
+	leftBranch, ok := orderFromPadding(spec, left[top])
+	if !ok {
+		return false
+	}
+	rightBranch, ok := orderFromPadding(spec, right[topRight])
+	if !ok || rightBranch != leftBranch+1 {
+		return false
+	}
+	return isRightMost(spec, left[:top]) && isLeftMost(spec, right[:topRight])
+}
+
+// getPadding returns the prefix length bounds and the suffix length of an op whose child is at
+// the given branch.
+func getPadding(spec *InnerSpec, branch int32) (minPrefix, maxPrefix, suffix int, ok bool) {
+	idx := -1
+	for i, b := range spec.ChildOrder {
+		if b == branch {
+			idx = i
+			break
+		}
+	}
+	if idx < 0 {
+		return 0, 0, 0, false
+	}
+	prefix := idx * int(spec.ChildSize)
+	minPrefix = prefix + int(spec.MinPrefixLength)
+	maxPrefix = prefix + int(spec.MaxPrefixLength)
+	suffix = (len(spec.ChildOrder) - 1 - idx) * int(spec.ChildSize)
+	return minPrefix, maxPrefix, suffix, true
+}
+
+func hasPadding(op *InnerOp, minPrefix, maxPrefix, suffix int) bool {
+	return len(op.Prefix) >= minPrefix && len(op.Prefix) <= maxPrefix && len(op.Suffix) == suffix
+}
+
+// orderFromPadding returns the branch of the child of the op.
+func orderFromPadding(spec *InnerSpec, op *InnerOp) (int32, bool) {
+	for branch := int32(0); branch < int32(len(spec.ChildOrder)); branch++ {
+		minPrefix, maxPrefix, suffix, ok := getPadding(spec, branch)
+		if ok && hasPadding(op, minPrefix, maxPrefix, suffix) {
+			return branch, true
+		}
+	}
+	return 0, false
+}
+
+//----------------------------------------
+
+func prepareLeafData(hashOp HashOp, lengthOp LengthOp, data []byte) ([]byte, error) {
+	if hashOp != HashOp_NO_HASH {
+		var err error
+		data, err = doHash(hashOp, data)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return doLengthOp(lengthOp, data)
+}
+
+func doHash(hashOp HashOp, data []byte) ([]byte, error) {
+	switch hashOp {
+	case HashOp_SHA256:
+		hash := sha256.Sum256(data)
+		return hash[:], nil
+	case HashOp_SHA512:
+		hash := sha512.Sum512(data)
+		return hash[:], nil
+	default:
+		return nil, errors.Errorf("unsupported hash op %v", hashOp)
+	}
+}
+
+func doLengthOp(lengthOp LengthOp, data []byte) ([]byte, error) {
+	switch lengthOp {
+	case LengthOp_NO_PREFIX:
+		return data, nil
+	case LengthOp_VAR_PROTO:
+		buf := make([]byte, binary.MaxVarintLen64, binary.MaxVarintLen64+len(data))
+		n := binary.PutUvarint(buf, uint64(len(data)))
+		return append(buf[:n], data...), nil
+	case LengthOp_REQUIRE_32_BYTES:
+		if len(data) != 32 {
+			return nil, errors.Errorf("data was %d bytes, not 32", len(data))
+		}
+		return data, nil
+	case LengthOp_REQUIRE_64_BYTES:
+		if len(data) != 64 {
+			return nil, errors.Errorf("data was %d bytes, not 64", len(data))
+		}
+		return data, nil
+	case LengthOp_FIXED32_BIG:
+		buf := make([]byte, 4, 4+len(data))
+		binary.BigEndian.PutUint32(buf, uint32(len(data)))
+		return append(buf, data...), nil
+	case LengthOp_FIXED32_LITTLE:
+		buf := make([]byte, 4, 4+len(data))
+		binary.LittleEndian.PutUint32(buf, uint32(len(data)))
+		return append(buf, data...), nil
+	case LengthOp_FIXED64_BIG:
+		buf := make([]byte, 8, 8+len(data))
+		binary.BigEndian.PutUint64(buf, uint64(len(data)))
+		return append(buf, data...), nil
+	case LengthOp_FIXED64_LITTLE:
+		buf := make([]byte, 8, 8+len(data))
+		binary.LittleEndian.PutUint64(buf, uint64(len(data)))
+		return append(buf, data...), nil
+	default:
+		return nil, errors.Errorf("unsupported length op %v", lengthOp)
+	}
+}
@@ -1,6 +1,7 @@
 package iavl
 
 import (
+	"context"
 	"fmt"
 	"strings"
 
@@ -148,7 +149,108 @@ func (t *ImmutableTree) hashWithCount() ([]byte, int64) {
 // Export returns an iterator that exports tree nodes as ExportNodes. These nodes can be
 // imported with MutableTree.Import() to recreate an identical tree.
 func (t *ImmutableTree) Export() *Exporter {
-	return newExporter(t)
+	return newExporter(context.Background(), t)
+}
+
+// ExportCtx is like Export, but the export is aborted when the context is cancelled or its
+// deadline expires, in which case Exporter.Next() returns the context error.
+func (t *ImmutableTree) ExportCtx(ctx context.Context) *Exporter {
+	return newExporter(ctx, t)
+}
+
+// ExportParallel is like ExportCtx, but splits the tree at splitDepth and reads the subtrees
+// below it concurrently with the given number of workers, which speeds up exports bound by
+// database reads. Nodes are still exported in the same order as Export(). Each subtree being
+// read buffers a bounded number of nodes, so memory use grows with the number of workers, not
+// the size of the tree.
+//
+// A splitDepth of 0 or fewer than 2 workers exports sequentially. A splitDepth of a few levels
+// more than log2(workers) gives enough subtrees to balance the work.
+func (t *ImmutableTree) ExportParallel(ctx context.Context, splitDepth uint8, workers int) *Exporter {
+	return newParallelExporter(ctx, t, splitDepth, workers)
+}
+
+// ExportRange returns an iterator that exports the nodes of a new balanced tree containing only
+// the leaves with keys between start (inclusive) and end (exclusive), along with the root hash
+// the tree will have once imported, or nil if the range is empty. Either key may be nil to leave
+// the range open on that side.
+//
+// Leaves keep their versions, and inner nodes have the highest version of their children, so the
+// nodes can be imported with MutableTree.Import() into an empty tree at the version of this tree.
+// The root hash is computed before returning, which reads the range once more than the export.
+func (t *ImmutableTree) ExportRange(start, end []byte) (*Exporter, []byte) {
+	return t.ExportRangeCtx(context.Background(), start, end)
+}
+
+// ExportRangeCtx is like ExportRange, but computing the root hash and the export are aborted when
+// the context is cancelled or its deadline expires, in which case the root hash is nil and
+// Exporter.Next() returns the context error.
+func (t *ImmutableTree) ExportRangeCtx(ctx context.Context, start, end []byte) (*Exporter, []byte) {
+	lo, hi := t.rangeIndexes(start, end)
+	var rootHash []byte
+	if lo < hi {
+		root, _, stopped := buildBalancedTree(lo, hi, t.rangeLeaves(lo), func(*Node) bool {
+			return ctx.Err() != nil
+		})
+		if !stopped {
+			rootHash = root.hash
+		}
+	}
+	return newRangeExporter(ctx, t, lo, hi, rootHash), rootHash
+}
+
+// rangeIndexes returns the indexes of the leaves with keys between start (inclusive) and end
+// (exclusive), from lo (inclusive) to hi (exclusive).
+func (t *ImmutableTree) rangeIndexes(start, end []byte) (lo, hi int64) {
+	if t.root == nil {
+		return 0, 0
+	}
+	hi = t.root.size
+	if start != nil {
+		lo, _ = t.root.get(t, start)
+	}
+	if end != nil {
+		hi, _ = t.root.get(t, end)
+	}
+	if hi < lo {
+		hi = lo
+	}
+	return lo, hi
+}
+
+// rangeLeaves returns a function which returns copies of the leaves of the tree in order, starting
+// at index lo, for a tree built by ExportRange(). The index it is called with is ignored, since
+// buildBalancedTree() requests the leaves in order.
+func (t *ImmutableTree) rangeLeaves(lo int64) func(int64) *Node {
+	// The stack holds the subtrees to the right of the path to the next leaf, nearest last.
+	stack := []*Node{}
+	node := t.root
+	for !node.isLeaf() {
+		left := node.getLeftNode(t)
+		if lo < left.size {
+			stack = append(stack, node.getRightNode(t))
+			node = left
+		} else {
+			lo -= left.size
+			node = node.getRightNode(t)
+		}
+	}
+	stack = append(stack, node)
+
+	return func(int64) *Node {
+		node := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		for !node.isLeaf() {
+			stack = append(stack, node.getRightNode(t))
+			node = node.getLeftNode(t)
+		}
+		return &Node{
+			key:     node.key,
+			value:   node.value,
+			version: node.version,
+			size:    1,
+		}
+	}
 }
 
 // Get returns the index and value of the specified key if it exists, or nil and the next index
@@ -183,6 +285,12 @@ func (t *ImmutableTree) Iterate(fn func(key []byte, value []byte) bool) (stopped
 	})
 }
 
+// IterateCtx is like Iterate, but stops the traversal when the context is cancelled or its
+// deadline expires, in which case the context error is returned.
+func (t *ImmutableTree) IterateCtx(ctx context.Context, fn func(key []byte, value []byte) bool) (stopped bool, err error) {
+	return t.IterateRangeCtx(ctx, nil, nil, true, fn)
+}
+
 // IterateRange makes a callback for all nodes with key between start and end non-inclusive.
 // If either are nil, then it is open on that side (nil, nil is the same as Iterate). The keys and
 // values must not be modified, since they may point to data stored within IAVL.
@@ -198,6 +306,27 @@ func (t *ImmutableTree) IterateRange(start, end []byte, ascending bool, fn func(
 	})
 }
 
+// IterateRangeCtx is like IterateRange, but stops the traversal when the context is cancelled
+// or its deadline expires, in which case the context error is returned.
+func (t *ImmutableTree) IterateRangeCtx(ctx context.Context, start, end []byte, ascending bool, fn func(key []byte, value []byte) bool) (stopped bool, err error) {
+	if err := ctx.Err(); err != nil {
+		return true, err
+	}
+	if t.root == nil {
+		return false, nil
+	}
+	stopped = t.root.traverseInRange(t, start, end, ascending, false, 0, false, func(node *Node, _ uint8) bool {
+		if err = ctx.Err(); err != nil {
+			return true
+		}
+		if node.height == 0 {
+			return fn(node.key, node.value)
+		}
+		return false
+	})
+	return stopped, err
+}
+
 // IterateRangeInclusive makes a callback for all nodes with key between start and end inclusive.
 // If either are nil, then it is open on that side (nil, nil is the same as Iterate). The keys and
 // values must not be modified, since they may point to data stored within IAVL.
@@ -2,10 +2,14 @@ package iavl
 
 import (
 	"bytes"
+	"context"
+	"sort"
 
 	"github.com/pkg/errors"
 
 	db "github.com/tendermint/tm-db"
+
+	"github.com/tendermint/iavl/internal/encoding"
 )
 
 // maxBatchSize is the maximum size of the import batch before flushing it to the database
@@ -14,46 +18,176 @@ const maxBatchSize = 10000
 // ErrNoImport is returned when calling methods on a closed importer
 var ErrNoImport = errors.New("no import in progress")
 
-// Importer imports data into an empty MutableTree. It is created by MutableTree.Import(). Users
-// must call Close() when done.
+// ErrRootHashMismatch is returned by Importer.Commit() when the imported tree does not have the
+// root hash given in ImportOptions.
+var ErrRootHashMismatch = errors.New("imported root hash does not match expected root hash")
+
+// ImportOptions are options for MutableTree.ImportWithOptions().
+type ImportOptions struct {
+	// ExpectedRootHash is the root hash of the tree being imported. If set, Commit() fails and
+	// removes all imported nodes from the database when the imported nodes do not form a tree or
+	// it has a different root hash, so that untrusted data is never made visible. An empty non-nil
+	// hash expects an empty tree.
+	ExpectedRootHash []byte
+
+	// AboveLatestVersion imports the tree as a new version above the latest version of a
+	// non-empty database, e.g. to state sync a recent snapshot on top of existing history. The
+	// tree must not have unsaved changes. Existing versions remain available until they are
+	// deleted or pruned, and the nodes of the latest version which are not part of the imported
+	// tree are recorded as orphans of it.
+	AboveLatestVersion bool
+}
+
+// Importer imports data into an empty MutableTree, or as a new version of a non-empty one with
+// ImportOptions.AboveLatestVersion. It is created by MutableTree.Import(). Users must call Close()
+// when done.
 //
 // ExportNodes must be imported in the order returned by Exporter, i.e. depth-first post-order (LRN).
 //
+// Whenever nodes are flushed to the database, a checkpoint of the import is written along with
+// them. If the import is aborted, e.g. by a crash, it can be resumed from the last checkpoint with
+// MutableTree.ResumeImport(), or the flushed nodes can be removed with MutableTree.CleanupImports().
+//
 // Importer is not concurrency-safe, it is the caller's responsibility to ensure the tree is not
 // modified while performing an import.
 type Importer struct {
+	ctx       context.Context
 	tree      *MutableTree
 	version   int64
 	batch     db.Batch
 	batchSize uint32
 	stack     []*Node
+	leftmost  [][]byte // the leftmost key of each subtree on the stack
+	added     uint64   // number of nodes added, including those restored from a checkpoint
+	opts      ImportOptions
+
+	// baseVersion is the latest version of the database when the import started. Nodes with a
+	// version up to it may be shared with existing versions, and are only removed by a purge if
+	// they are not part of any.
+	baseVersion int64
 }
 
-// newImporter creates a new Importer for an empty MutableTree.
+// newImporter creates a new Importer for an empty MutableTree, or for a non-empty one with
+// ImportOptions.AboveLatestVersion.
 //
 // version should correspond to the version that was initially exported. It must be greater than
-// or equal to the highest ExportNode version number given.
-func newImporter(tree *MutableTree, version int64) (*Importer, error) {
-	if version < 0 {
-		return nil, errors.New("imported version cannot be negative")
+// or equal to the highest ExportNode version number given. Once the context is cancelled, Add()
+// and Commit() return the context error.
+func newImporter(ctx context.Context, tree *MutableTree, version int64, opts ImportOptions) (*Importer, error) {
+	baseVersion, err := validateImport(tree, version, opts.AboveLatestVersion)
+	if err != nil {
+		return nil, err
 	}
-	if tree.ndb.latestVersion > 0 {
-		return nil, errors.Errorf("found database at version %d, must be 0", tree.ndb.latestVersion)
+	pending, err := tree.ndb.getImportCheckpointVersions()
+	if err != nil {
+		return nil, err
 	}
-	if !tree.IsEmpty() {
-		return nil, errors.New("tree must be empty")
+	if len(pending) > 0 {
+		return nil, errors.Errorf("found aborted import of version %v, it must be resumed or cleaned up",
+			pending[0])
 	}
 
 	return &Importer{
-		tree:    tree,
-		version: version,
-		batch:   tree.ndb.snapshotDB.NewBatch(),
-		stack:   make([]*Node, 0, 8),
+		ctx:         ctx,
+		tree:        tree,
+		version:     version,
+		batch:       tree.ndb.snapshotDB.NewBatch(),
+		stack:       make([]*Node, 0, 8),
+		leftmost:    make([][]byte, 0, 8),
+		opts:        opts,
+		baseVersion: baseVersion,
 	}, nil
 }
 
+// resumeImporter creates a new Importer which continues an aborted import of the given version
+// from its last checkpoint. The import mode is restored from the checkpoint.
+func resumeImporter(ctx context.Context, tree *MutableTree, version int64) (*Importer, error) {
+	bz, err := tree.ndb.snapshotDB.Get(tree.ndb.importKey(version))
+	if err != nil {
+		return nil, err
+	}
+	if bz == nil {
+		return nil, errors.Errorf("no checkpoint found for import of version %v", version)
+	}
+	added, baseVersion, hashes, err := decodeImportCheckpoint(bz)
+	if err != nil {
+		return nil, errors.Wrapf(err, "decoding checkpoint for import of version %v", version)
+	}
+	opts := ImportOptions{AboveLatestVersion: baseVersion > 0}
+	if _, err = validateImport(tree, version, opts.AboveLatestVersion); err != nil {
+		return nil, err
+	}
+	if err = checkImportBase(tree, version, baseVersion); err != nil {
+		return nil, err
+	}
+
+	stack := make([]*Node, 0, len(hashes))
+	leftmost := make([][]byte, 0, len(hashes))
+	for _, hash := range hashes {
+		node, err := loadImportNode(tree.ndb, hash)
+		if err == nil {
+			var key []byte
+			if key, err = importLeftmostKey(tree.ndb, node); err == nil {
+				stack = append(stack, node)
+				leftmost = append(leftmost, key)
+			}
+		}
+		if err != nil {
+			return nil, errors.Wrapf(err, "checkpoint for import of version %v is damaged, it must be cleaned up",
+				version)
+		}
+	}
+	return &Importer{
+		ctx:         ctx,
+		tree:        tree,
+		version:     version,
+		batch:       tree.ndb.snapshotDB.NewBatch(),
+		stack:       stack,
+		leftmost:    leftmost,
+		added:       added,
+		opts:        opts,
+		baseVersion: baseVersion,
+	}, nil
+}
+
+// validateImport checks that the tree can be imported into at the given version, and returns the
+// latest version of the database which the import is based on.
+func validateImport(tree *MutableTree, version int64, aboveLatest bool) (int64, error) {
+	if version < 0 {
+		return 0, errors.New("imported version cannot be negative")
+	}
+	latest := tree.ndb.getLatestVersion()
+	if !aboveLatest {
+		if latest > 0 {
+			return 0, errors.Errorf("found database at version %d, must be 0", latest)
+		}
+		if !tree.IsEmpty() {
+			return 0, errors.New("tree must be empty")
+		}
+		return 0, nil
+	}
+	if version <= latest {
+		return 0, errors.Errorf("imported version %v must be greater than latest version %v", version, latest)
+	}
+	if !bytes.Equal(tree.WorkingHash(), tree.lastSaved.Hash()) {
+		return 0, errors.New("tree has unsaved changes")
+	}
+	return latest, nil
+}
+
+// checkImportBase checks that no versions have been saved since an aborted import started, since
+// they may share nodes with it.
+func checkImportBase(tree *MutableTree, version int64, baseVersion int64) error {
+	if latest := tree.ndb.getLatestVersion(); latest != baseVersion {
+		return errors.Errorf("found database at version %v, but aborted import of version %v started at version %v",
+			latest, version, baseVersion)
+	}
+	return nil
+}
+
 // Close frees all resources. It is safe to call multiple times. Uncommitted nodes may already have
-// been flushed to the database, but will not be visible.
+// been flushed to the database along with a checkpoint, but will not be visible. The import can
+// then be resumed with MutableTree.ResumeImport(), or removed with MutableTree.CleanupImports().
 func (i *Importer) Close() {
 	if i.batch != nil {
 		i.batch.Close()
@@ -63,17 +197,57 @@ func (i *Importer) Close() {
 }
 
 // Add adds an ExportNode to the import. ExportNodes must be added in the order returned by
-// Exporter, i.e. depth-first post-order (LRN). Nodes are periodically flushed to the database,
-// but the imported version is not visible until Commit() is called.
+// Exporter, i.e. depth-first post-order (LRN). Nodes are periodically flushed to the database
+// along with a checkpoint, but the imported version is not visible until Commit() is called. If
+// flushing fails, the import must be resumed from the last checkpoint.
 func (i *Importer) Add(exportNode *ExportNode) error {
 	if i.tree == nil {
 		return ErrNoImport
 	}
+	if err := i.ctx.Err(); err != nil {
+		return err
+	}
+
+	// We don't modify the stack until we've verified the built node, to avoid leaving the
+	// importer in an inconsistent state when we return an error.
+	node, leftmost, children, err := i.buildNode(i.stack, i.leftmost, exportNode)
+	if err != nil {
+		return err
+	}
+	return i.addNode(node, leftmost, children)
+}
+
+// addNode writes a node built by buildNode(), and replaces its children on the stack with it.
+func (i *Importer) addNode(node *Node, leftmost []byte, children int) error {
+	var buf bytes.Buffer
+	err := node.writeBytes(&buf)
+	if err != nil {
+		return err
+	}
+
+	i.batch.Set(i.tree.ndb.nodeKey(node.hash), buf.Bytes())
+	i.batchSize++
+
+	// Update the stack now that we know there were no errors
+	i.stack = append(i.stack[:len(i.stack)-children], node)
+	i.leftmost = append(i.leftmost[:len(i.leftmost)-children], leftmost)
+	i.added++
+
+	if i.batchSize >= maxBatchSize {
+		return i.flush()
+	}
+	return nil
+}
+
+// buildNode builds and verifies the node of an ExportNode added on top of the given stack and its
+// leftmost keys, without modifying them. It returns the node, its leftmost key, and the number of
+// children it takes from the top of the stack.
+func (i *Importer) buildNode(stack []*Node, leftmost [][]byte, exportNode *ExportNode) (*Node, []byte, int, error) {
 	if exportNode == nil {
-		return errors.New("node cannot be nil")
+		return nil, nil, 0, errors.New("node cannot be nil")
 	}
 	if exportNode.Version > i.version {
-		return errors.Errorf("node version %v can't be greater than import version %v",
+		return nil, nil, 0, errors.Errorf("node version %v can't be greater than import version %v",
 			exportNode.Version, i.version)
 	}
 
@@ -88,19 +262,19 @@ func (i *Importer) Add(exportNode *ExportNode) error {
 	// children while constructing right children. When all children are built, the parent can
 	// be constructed and the resolved children can be discarded from the stack. Using a stack
 	// ensures that we can handle additional unresolved left children while building a right branch.
-	//
-	// We don't modify the stack until we've verified the built node, to avoid leaving the
-	// importer in an inconsistent state when we return an error.
-	stackSize := len(i.stack)
+	stackSize := len(stack)
+	children := 0
 	switch {
-	case stackSize >= 2 && i.stack[stackSize-1].height < node.height && i.stack[stackSize-2].height < node.height:
-		node.leftNode = i.stack[stackSize-2]
+	case stackSize >= 2 && stack[stackSize-1].height < node.height && stack[stackSize-2].height < node.height:
+		node.leftNode = stack[stackSize-2]
 		node.leftHash = node.leftNode.hash
-		node.rightNode = i.stack[stackSize-1]
+		node.rightNode = stack[stackSize-1]
 		node.rightHash = node.rightNode.hash
-	case stackSize >= 1 && i.stack[stackSize-1].height < node.height:
-		node.leftNode = i.stack[stackSize-1]
+		children = 2
+	case stackSize >= 1 && stack[stackSize-1].height < node.height:
+		node.leftNode = stack[stackSize-1]
 		node.leftHash = node.leftNode.hash
+		children = 1
 	}
 
 	if node.height == 0 {
@@ -113,40 +287,58 @@ func (i *Importer) Add(exportNode *ExportNode) error {
 		node.size += node.rightNode.size
 	}
 
-	node._hash()
-	err := node.validate()
-	if err != nil {
-		return err
+	if err := node.validate(); err != nil {
+		return nil, nil, 0, err
+	}
+	if node.height > 0 && (node.leftHash == nil || node.rightHash == nil) {
+		return nil, nil, 0, errors.New("inner node must have two children")
 	}
+	// The node hash does not cover the keys of inner nodes, so they must be checked against the
+	// leaves: the key of an inner node is the leftmost key of its right subtree.
+	nodeLeftmost := node.key
+	if node.height > 0 {
+		if !bytes.Equal(node.key, leftmost[stackSize-1]) {
+			return nil, nil, 0, errors.Errorf("inner node key %X does not match leftmost key %X of its right subtree",
+				node.key, leftmost[stackSize-1])
+		}
+		nodeLeftmost = leftmost[stackSize-2]
+	}
+	node._hash()
+	return node, nodeLeftmost, children, nil
+}
 
-	var buf bytes.Buffer
-	err = node.writeBytes(&buf)
+// flush writes the batch to the database along with a checkpoint of the import, so that the
+// stack can be restored by ResumeImport(). The nodes on the stack are always part of the batch
+// or of an earlier one.
+func (i *Importer) flush() error {
+	i.batch.Set(i.tree.ndb.importKey(i.version), encodeImportCheckpoint(i.added, i.baseVersion, i.stack))
+	err := i.batch.Write()
 	if err != nil {
 		return err
 	}
+	i.batch.Close()
+	i.batch = i.tree.ndb.snapshotDB.NewBatch()
+	i.batchSize = 0
+	return nil
+}
 
-	i.batch.Set(i.tree.ndb.nodeKey(node.hash), buf.Bytes())
-	i.batchSize++
-	if i.batchSize >= maxBatchSize {
-		err = i.batch.Write()
-		if err != nil {
-			return err
-		}
-		i.batch.Close()
-		i.batch = i.tree.ndb.snapshotDB.NewBatch()
-		i.batchSize = 0
-	}
-
-	// Update the stack now that we know there were no errors
-	switch {
-	case node.leftHash != nil && node.rightHash != nil:
-		i.stack = i.stack[:stackSize-2]
-	case node.leftHash != nil || node.rightHash != nil:
-		i.stack = i.stack[:stackSize-1]
+// purge discards the pending batch and removes all flushed nodes and the checkpoint from the
+// database, leaving it as it was before the import. It closes the importer.
+func (i *Importer) purge() error {
+	if i.tree == nil {
+		return nil
 	}
-	i.stack = append(i.stack, node)
+	i.batch.Close()
+	i.batch = nil
+	err := deleteImport(i.tree.ndb, i.version, i.baseVersion, i.stack, i.leftmost)
+	i.Close()
+	return err
+}
 
-	return nil
+// Progress returns the number of nodes added to the import. For a resumed import, it includes
+// the nodes added before the checkpoint, so the caller should skip that many exported nodes.
+func (i *Importer) Progress() uint64 {
+	return i.added
 }
 
 // Commit finalizes the import by flushing any outstanding nodes to the database, making the
@@ -156,28 +348,325 @@ func (i *Importer) Commit() error {
 	if i.tree == nil {
 		return ErrNoImport
 	}
+	if err := i.ctx.Err(); err != nil {
+		return err
+	}
 
+	rootHash := []byte{}
+	var err error
 	switch len(i.stack) {
 	case 0:
-		i.batch.Set(i.tree.ndb.rootKey(i.version), []byte{})
 	case 1:
-		i.batch.Set(i.tree.ndb.rootKey(i.version), i.stack[0].hash)
+		rootHash = i.stack[0].hash
 	default:
-		return errors.Errorf("invalid node structure, found stack size %v when committing",
-			len(i.stack))
+		err = errors.Errorf("invalid node structure, found stack size %v when committing", len(i.stack))
+	}
+	if err == nil && i.opts.ExpectedRootHash != nil && !bytes.Equal(rootHash, i.opts.ExpectedRootHash) {
+		err = errors.Wrapf(ErrRootHashMismatch, "got %X, expected %X", rootHash, i.opts.ExpectedRootHash)
 	}
-
-	err := i.batch.WriteSync()
 	if err != nil {
+		// A verified import must leave the database unchanged if it fails.
+		if i.opts.ExpectedRootHash != nil {
+			if purgeErr := i.purge(); purgeErr != nil {
+				return errors.Wrapf(purgeErr, "removing imported nodes after error: %v", err)
+			}
+		}
 		return err
 	}
-	i.tree.ndb.resetLatestVersion(i.version)
+	var orphans map[string]int64
+	if i.baseVersion > 0 {
+		var root *Node
+		if len(i.stack) == 1 {
+			root = i.stack[0]
+		}
+		if orphans, err = i.baseOrphans(root); err != nil {
+			return err
+		}
+	}
+	i.batch.Set(i.tree.ndb.rootKey(i.version), rootHash)
+	i.batch.Delete(i.tree.ndb.importKey(i.version))
 
-	_, err = i.tree.LoadVersion(i.version)
+	err = i.batch.WriteSync()
 	if err != nil {
 		return err
 	}
 
+	// The import is now committed, so close the importer to ensure that it can't be purged even
+	// if loading the version fails.
+	tree := i.tree
 	i.Close()
+	tree.ndb.resetLatestVersion(i.version)
+
+	// Orphans must only be saved once the new version exists, since they allow pruning the nodes
+	// of the base version.
+	if len(orphans) > 0 {
+		if err = tree.ndb.SaveOrphans(i.version, orphans); err != nil {
+			return err
+		}
+		if err = tree.ndb.Commit(); err != nil {
+			return err
+		}
+	}
+
+	_, err = tree.LoadVersion(i.version)
+	return err
+}
+
+// baseOrphans returns the nodes of the base version which are not part of the imported tree with
+// the given root, by hash with their version.
+func (i *Importer) baseOrphans(root *Node) (map[string]int64, error) {
+	orphans := map[string]int64{}
+	baseHash, err := i.tree.ndb.getRoot(i.baseVersion)
+	if err != nil {
+		return nil, err
+	}
+	if len(baseHash) == 0 {
+		return orphans, nil
+	}
+	base := &ImmutableTree{root: i.tree.ndb.GetNode(baseHash), ndb: i.tree.ndb, version: i.baseVersion}
+	imported := &ImmutableTree{root: root, ndb: i.tree.ndb, version: i.version}
+	deltaOrphans(base, imported, func(node *Node) bool {
+		orphans[string(node.hash)] = node.version
+		return true
+	})
+	return orphans, nil
+}
+
+// CleanupImports removes the checkpoints of all aborted imports, along with the nodes they
+// flushed to the database. Nodes which are part of existing versions are kept. Since versions
+// saved after an import was aborted may share nodes with it, cleanup fails if any were.
+func (tree *MutableTree) CleanupImports() error {
+	versions, err := tree.ndb.getImportCheckpointVersions()
+	if err != nil {
+		return err
+	}
+
+	for _, version := range versions {
+		bz, err := tree.ndb.snapshotDB.Get(tree.ndb.importKey(version))
+		if err != nil {
+			return err
+		}
+		_, baseVersion, hashes, err := decodeImportCheckpoint(bz)
+		if err != nil {
+			return errors.Wrapf(err, "decoding checkpoint for import of version %v", version)
+		}
+		if err = checkImportBase(tree, version, baseVersion); err != nil {
+			return err
+		}
+		// Damaged nodes can't be traversed, so their descendants are left behind as unreachable
+		// nodes, which can be removed by GarbageCollect().
+		stack := make([]*Node, 0, len(hashes))
+		leftmost := make([][]byte, 0, len(hashes))
+		for _, hash := range hashes {
+			node, err := loadImportNode(tree.ndb, hash)
+			if errors.Cause(err) == errDamagedImportNode {
+				continue
+			}
+			if err != nil {
+				return err
+			}
+			key, err := importLeftmostKey(tree.ndb, node)
+			if err != nil && errors.Cause(err) != errDamagedImportNode {
+				return err
+			}
+			stack = append(stack, node)
+			leftmost = append(leftmost, key)
+		}
+		if err = deleteImport(tree.ndb, version, baseVersion, stack, leftmost); err != nil {
+			return err
+		}
+	}
 	return nil
 }
+
+// deleteImport deletes the nodes of an import from the database, along with its checkpoint. All
+// imported nodes are descendants of the nodes on the importer stack, whose leftmost keys are
+// given. Nodes with a version up to baseVersion may be part of existing versions, in which case
+// they are kept along with their descendants, as are such nodes whose leftmost key is unknown
+// (nil). Damaged nodes are skipped. The checkpoint is deleted last, so that an interrupted
+// deletion can be retried.
+func deleteImport(ndb *nodeDB, version int64, baseVersion int64, stack []*Node, leftmost [][]byte) error {
+	existing, err := existingTrees(ndb, baseVersion)
+	if err != nil {
+		return err
+	}
+
+	batch := ndb.snapshotDB.NewBatch()
+	defer func() { batch.Close() }()
+	var batchSize uint32
+
+	type pendingNode struct {
+		node   *Node
+		minKey []byte
+	}
+	pending := make([]pendingNode, 0, len(stack))
+	for i, node := range stack {
+		pending = append(pending, pendingNode{node: node, minKey: leftmost[i]})
+	}
+	for len(pending) > 0 {
+		node, minKey := pending[len(pending)-1].node, pending[len(pending)-1].minKey
+		pending = pending[:len(pending)-1]
+		if node.version <= baseVersion && (minKey == nil || inExistingTrees(existing, node, minKey)) {
+			continue
+		}
+		if !node.isLeaf() {
+			// Nodes which have not been flushed yet are only reachable through their parent.
+			left, right := node.leftNode, node.rightNode
+			if left == nil {
+				left, err = loadImportNode(ndb, node.leftHash)
+				if err != nil && errors.Cause(err) != errDamagedImportNode {
+					return err
+				}
+			}
+			if right == nil {
+				right, err = loadImportNode(ndb, node.rightHash)
+				if err != nil && errors.Cause(err) != errDamagedImportNode {
+					return err
+				}
+			}
+			if left != nil {
+				pending = append(pending, pendingNode{node: left, minKey: minKey})
+			}
+			if right != nil {
+				pending = append(pending, pendingNode{node: right, minKey: node.key})
+			}
+		}
+		ndb.mtx.Lock()
+		ndb.uncacheNode(node.hash)
+		ndb.mtx.Unlock()
+		batch.Delete(ndb.nodeKey(node.hash))
+		batchSize++
+		if batchSize >= maxBatchSize {
+			if err := batch.Write(); err != nil {
+				return err
+			}
+			batch.Close()
+			batch = ndb.snapshotDB.NewBatch()
+			batchSize = 0
+		}
+	}
+	if err := batch.Write(); err != nil {
+		return err
+	}
+	return ndb.snapshotDB.DeleteSync(ndb.importKey(version))
+}
+
+// existingTrees returns the existing non-empty versions up to baseVersion, latest first.
+func existingTrees(ndb *nodeDB, baseVersion int64) ([]*ImmutableTree, error) {
+	if baseVersion == 0 {
+		return nil, nil
+	}
+	roots, err := ndb.getRoots()
+	if err != nil {
+		return nil, err
+	}
+	trees := make([]*ImmutableTree, 0, len(roots))
+	for version, hash := range roots {
+		if version <= baseVersion && len(hash) > 0 {
+			root, err := loadImportNode(ndb, hash)
+			if err != nil {
+				return nil, errors.Wrapf(err, "loading root of version %v", version)
+			}
+			trees = append(trees, &ImmutableTree{root: root, ndb: ndb, version: version})
+		}
+	}
+	sort.Slice(trees, func(i, j int) bool { return trees[i].version > trees[j].version })
+	return trees, nil
+}
+
+// errDamagedImportNode is returned by loadImportNode() when a node is missing or corrupt.
+var errDamagedImportNode = errors.New("damaged node")
+
+// loadImportNode loads a node of an aborted import from the database. Unlike nodeDB.GetNode(),
+// it returns an error wrapping errDamagedImportNode if the node is missing or corrupt instead of
+// panicking, since a damaged checkpoint may refer to such nodes.
+func loadImportNode(ndb *nodeDB, hash []byte) (*Node, error) {
+	bz, err := ndb.snapshotDB.Get(ndb.nodeKey(hash))
+	if err != nil {
+		return nil, err
+	}
+	if bz == nil {
+		return nil, errors.Wrapf(errDamagedImportNode, "node %X is missing", hash)
+	}
+	node, err := MakeNode(bz)
+	if err != nil {
+		return nil, errors.Wrapf(errDamagedImportNode, "decoding node %X: %v", hash, err)
+	}
+	node.hash = hash
+	node.saved = true
+	node.persisted = true
+	return node, nil
+}
+
+// importLeftmostKey returns the leftmost key of a node loaded by loadImportNode().
+func importLeftmostKey(ndb *nodeDB, node *Node) ([]byte, error) {
+	for !node.isLeaf() {
+		var err error
+		if node, err = loadImportNode(ndb, node.leftHash); err != nil {
+			return nil, err
+		}
+	}
+	return node.key, nil
+}
+
+// inExistingTrees returns true if the node, with the given leftmost key, is part of any of the
+// trees. Only trees with a version no lower than the node's version can contain it.
+func inExistingTrees(trees []*ImmutableTree, node *Node, minKey []byte) bool {
+	for _, tree := range trees {
+		if tree.version < node.version {
+			break
+		}
+		if findSubtree(tree, node.hash, node.height, minKey) != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// encodeImportCheckpoint encodes an import checkpoint: the number of nodes added and the base
+// version, followed by the hashes of the nodes on the stack.
+func encodeImportCheckpoint(added uint64, baseVersion int64, stack []*Node) []byte {
+	var buf bytes.Buffer
+	// Writes to a bytes.Buffer can't fail.
+	_ = encoding.EncodeUvarint(&buf, added)
+	_ = encoding.EncodeVarint(&buf, baseVersion)
+	_ = encoding.EncodeUvarint(&buf, uint64(len(stack)))
+	for _, node := range stack {
+		_ = encoding.EncodeByteSlice(&buf, node.hash)
+	}
+	return buf.Bytes()
+}
+
+func decodeImportCheckpoint(bz []byte) (uint64, int64, [][]byte, error) {
+	added, n, err := encoding.DecodeUvarint(bz)
+	if err != nil {
+		return 0, 0, nil, errors.Wrap(err, "decoding node count")
+	}
+	bz = bz[n:]
+	baseVersion, n, err := encoding.DecodeVarint(bz)
+	if err != nil {
+		return 0, 0, nil, errors.Wrap(err, "decoding base version")
+	}
+	bz = bz[n:]
+	count, n, err := encoding.DecodeUvarint(bz)
+	if err != nil {
+		return 0, 0, nil, errors.Wrap(err, "decoding stack size")
+	}
+	bz = bz[n:]
+	if count > uint64(len(bz)) {
+		return 0, 0, nil, errors.Errorf("stack size %v exceeds checkpoint size", count)
+	}
+	hashes := make([][]byte, 0, count)
+	for j := uint64(0); j < count; j++ {
+		hash, n, err := encoding.DecodeByteSlice(bz)
+		if err != nil {
+			return 0, 0, nil, errors.Wrap(err, "decoding stack hash")
+		}
+		bz = bz[n:]
+		hashes = append(hashes, hash)
+	}
+	if len(bz) > 0 {
+		return 0, 0, nil, errors.Errorf("%v trailing bytes", len(bz))
+	}
+	return added, baseVersion, hashes, nil
+}
@@ -1,8 +1,12 @@
 package iavl
 
 import (
+	"context"
+	"fmt"
+	"math/rand"
 	"testing"
 
+	"github.com/pkg/errors"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
@@ -142,6 +146,33 @@ func TestImporter_Add(t *testing.T) {
 	}
 }
 
+func TestImporter_Add_InnerKey(t *testing.T) {
+	tree := setupExportTreeSized(t, 100)
+	exported := exportAll(t, tree.Export())
+
+	// Inner node keys are not covered by the node hashes, so changing one must be detected.
+	for i, node := range exported {
+		if node.Height == 0 {
+			continue
+		}
+		nodes := make([]*ExportNode, len(exported))
+		copy(nodes, exported)
+		changed := *node
+		changed.Key = append(append([]byte{}, node.Key...), 0)
+		nodes[i] = &changed
+
+		newTree, err := NewMutableTree(db.NewMemDB(), 0)
+		require.NoError(t, err)
+		importer, err := newTree.ImportWithOptions(tree.Version(), ImportOptions{ExpectedRootHash: tree.Hash()})
+		require.NoError(t, err)
+		for _, n := range nodes[:i] {
+			require.NoError(t, importer.Add(n))
+		}
+		require.Error(t, importer.Add(nodes[i]), "inner node %v", i)
+		importer.Close()
+	}
+}
+
 func TestImporter_Add_Closed(t *testing.T) {
 	tree, err := NewMutableTree(db.NewMemDB(), 0)
 	require.NoError(t, err)
@@ -200,6 +231,26 @@ func TestImporter_Commit_Closed(t *testing.T) {
 	require.Equal(t, ErrNoImport, err)
 }
 
+func TestImporter_Cancel(t *testing.T) {
+	tree, err := NewMutableTree(db.NewMemDB(), 0)
+	require.NoError(t, err)
+	ctx, cancel := context.WithCancel(context.Background())
+	importer, err := tree.ImportCtx(ctx, 1)
+	require.NoError(t, err)
+	defer importer.Close()
+
+	err = importer.Add(&ExportNode{Key: []byte("key"), Value: []byte("value"), Version: 1, Height: 0})
+	require.NoError(t, err)
+
+	cancel()
+	err = importer.Add(&ExportNode{Key: []byte("other"), Value: []byte("value"), Version: 1, Height: 0})
+	require.Equal(t, context.Canceled, err)
+	err = importer.Commit()
+	require.Equal(t, context.Canceled, err)
+	require.False(t, tree.Has([]byte("key")))
+	require.EqualValues(t, 0, tree.Version())
+}
+
 func TestImporter_Commit_Empty(t *testing.T) {
 	tree, err := NewMutableTree(db.NewMemDB(), 0)
 	require.NoError(t, err)
@@ -244,3 +295,386 @@ func BenchmarkImport(b *testing.B) {
 		require.NoError(b, err)
 	}
 }
+
+// countKeys counts the database keys with the given prefix.
+func countKeys(db db.DB, prefix []byte) int {
+	count := 0
+	traversePrefixFromDB(db, prefix, func(k, v []byte) {
+		count++
+	})
+	return count
+}
+
+func TestImporter_Resume(t *testing.T) {
+	tree := setupExportTreeSized(t, 12000)
+	exported := exportAll(t, tree.Export())
+	require.Greater(t, len(exported), 2*maxBatchSize)
+
+	// Abort the import between two checkpoints, as if the process crashed.
+	memDB := db.NewMemDB()
+	newTree, err := NewMutableTree(memDB, 0)
+	require.NoError(t, err)
+	importer, err := newTree.Import(tree.Version())
+	require.NoError(t, err)
+	for _, node := range exported[:maxBatchSize+maxBatchSize/2] {
+		require.NoError(t, importer.Add(node))
+	}
+	require.EqualValues(t, maxBatchSize+maxBatchSize/2, importer.Progress())
+	importer.Close()
+
+	newTree, err = NewMutableTree(memDB, 0)
+	require.NoError(t, err)
+	_, err = newTree.Import(tree.Version())
+	require.Error(t, err)
+	_, err = newTree.ResumeImport(tree.Version() + 1)
+	require.Error(t, err)
+
+	importer, err = newTree.ResumeImport(tree.Version())
+	require.NoError(t, err)
+	defer importer.Close()
+	require.EqualValues(t, maxBatchSize, importer.Progress())
+	for _, node := range exported[importer.Progress():] {
+		require.NoError(t, importer.Add(node))
+	}
+	require.EqualValues(t, len(exported), importer.Progress())
+	require.NoError(t, importer.Commit())
+
+	require.Equal(t, tree.Hash(), newTree.Hash())
+	require.Equal(t, tree.Size(), newTree.Size())
+	require.Zero(t, countKeys(memDB, importKeyFormat.Key()))
+	require.Equal(t, len(exported), countKeys(memDB, nodeKeyFormat.Key()))
+}
+
+func TestImporter_CleanupImports(t *testing.T) {
+	tree := setupExportTreeSized(t, 6000)
+	exported := exportAll(t, tree.Export())
+
+	memDB := db.NewMemDB()
+	newTree, err := NewMutableTree(memDB, 0)
+	require.NoError(t, err)
+	importer, err := newTree.Import(tree.Version())
+	require.NoError(t, err)
+	for _, node := range exported[:maxBatchSize+1] {
+		require.NoError(t, importer.Add(node))
+	}
+	importer.Close()
+	require.Equal(t, maxBatchSize, countKeys(memDB, nodeKeyFormat.Key()))
+	require.Equal(t, 1, countKeys(memDB, importKeyFormat.Key()))
+
+	newTree, err = NewMutableTree(memDB, 0)
+	require.NoError(t, err)
+	require.NoError(t, newTree.CleanupImports())
+	require.Zero(t, countKeys(memDB, nodeKeyFormat.Key()))
+	require.Zero(t, countKeys(memDB, importKeyFormat.Key()))
+	_, err = newTree.ResumeImport(tree.Version())
+	require.Error(t, err)
+
+	importer, err = newTree.Import(tree.Version())
+	require.NoError(t, err)
+	defer importer.Close()
+	for _, node := range exported {
+		require.NoError(t, importer.Add(node))
+	}
+	require.NoError(t, importer.Commit())
+	require.Equal(t, tree.Hash(), newTree.Hash())
+
+	// Without aborted imports, cleanup does nothing.
+	require.NoError(t, newTree.CleanupImports())
+	require.Equal(t, tree.Hash(), newTree.Hash())
+}
+
+func TestImporter_DamagedCheckpoint(t *testing.T) {
+	tree := setupExportTreeSized(t, 6000)
+	exported := exportAll(t, tree.Export())
+
+	memDB := db.NewMemDB()
+	newTree, err := NewMutableTree(memDB, 0)
+	require.NoError(t, err)
+	importer, err := newTree.Import(tree.Version())
+	require.NoError(t, err)
+	for _, node := range exported[:maxBatchSize+1] {
+		require.NoError(t, importer.Add(node))
+	}
+	importer.Close()
+
+	// Remove a node on the checkpointed stack, as if it was lost.
+	bz, err := memDB.Get(importKeyFormat.Key(tree.Version()))
+	require.NoError(t, err)
+	_, _, hashes, err := decodeImportCheckpoint(bz)
+	require.NoError(t, err)
+	require.Greater(t, len(hashes), 1)
+	require.NoError(t, memDB.Delete(nodeKeyFormat.Key(hashes[0])))
+
+	newTree, err = NewMutableTree(memDB, 0)
+	require.NoError(t, err)
+	_, err = newTree.ResumeImport(tree.Version())
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "is missing")
+
+	// Cleanup removes what it can reach, and leaves the rest to garbage collection.
+	require.NoError(t, newTree.CleanupImports())
+	require.Zero(t, countKeys(memDB, importKeyFormat.Key()))
+	require.NotZero(t, countKeys(memDB, nodeKeyFormat.Key()))
+	_, err = GarbageCollect(memDB, GCOptions{})
+	require.NoError(t, err)
+	require.Zero(t, countKeys(memDB, nodeKeyFormat.Key()))
+
+	newTree, err = NewMutableTree(memDB, 0)
+	require.NoError(t, err)
+	importer, err = newTree.Import(tree.Version())
+	require.NoError(t, err)
+	defer importer.Close()
+	for _, node := range exported {
+		require.NoError(t, importer.Add(node))
+	}
+	require.NoError(t, importer.Commit())
+	require.Equal(t, tree.Hash(), newTree.Hash())
+}
+
+// dumpDB returns all keys and values of the database.
+func dumpDB(t *testing.T, db db.DB) map[string]string {
+	itr, err := db.Iterator(nil, nil)
+	require.NoError(t, err)
+	defer itr.Close()
+	contents := map[string]string{}
+	for ; itr.Valid(); itr.Next() {
+		contents[string(itr.Key())] = string(itr.Value())
+	}
+	return contents
+}
+
+func TestImporter_ExpectedRootHash(t *testing.T) {
+	tree := setupExportTreeSized(t, 6000)
+	exported := exportAll(t, tree.Export())
+	require.Greater(t, len(exported), maxBatchSize)
+
+	memDB := db.NewMemDB()
+	newTree, err := NewMutableTree(memDB, 0)
+	require.NoError(t, err)
+	before := dumpDB(t, memDB)
+
+	importNodes := func(opts ImportOptions) error {
+		importer, err := newTree.ImportWithOptions(tree.Version(), opts)
+		require.NoError(t, err)
+		defer importer.Close()
+		for _, node := range exported {
+			require.NoError(t, importer.Add(node))
+		}
+		return importer.Commit()
+	}
+
+	// A mismatch removes all imported nodes, including flushed ones.
+	wrongHash := append([]byte{}, tree.Hash()...)
+	wrongHash[0] ^= 0x01
+	for _, hash := range [][]byte{wrongHash, {}} {
+		err = importNodes(ImportOptions{ExpectedRootHash: hash})
+		require.True(t, errors.Is(err, ErrRootHashMismatch), "unexpected error %v", err)
+		require.Equal(t, before, dumpDB(t, memDB))
+		require.EqualValues(t, 0, newTree.Version())
+		require.Empty(t, newTree.AvailableVersions())
+	}
+
+	// So does an incomplete import, whose nodes do not form a tree.
+	importer, err := newTree.ImportWithOptions(tree.Version(), ImportOptions{ExpectedRootHash: tree.Hash()})
+	require.NoError(t, err)
+	for _, node := range exported[:maxBatchSize+1] {
+		require.NoError(t, importer.Add(node))
+	}
+	require.Greater(t, len(importer.stack), 1)
+	require.Error(t, importer.Commit())
+	importer.Close()
+	require.Equal(t, before, dumpDB(t, memDB))
+
+	require.NoError(t, importNodes(ImportOptions{ExpectedRootHash: tree.Hash()}))
+	require.Equal(t, tree.Hash(), newTree.Hash())
+	require.EqualValues(t, tree.Version(), newTree.Version())
+
+	// An empty non-nil hash expects an empty tree.
+	emptyTree, err := NewMutableTree(db.NewMemDB(), 0)
+	require.NoError(t, err)
+	importer, err = emptyTree.ImportWithOptions(1, ImportOptions{ExpectedRootHash: []byte{}})
+	require.NoError(t, err)
+	require.NoError(t, importer.Commit())
+	require.EqualValues(t, 1, emptyTree.Version())
+}
+
+// importAll imports the given nodes with the given options.
+func importAll(t *testing.T, tree *MutableTree, version int64, nodes []*ExportNode, opts ImportOptions) error {
+	importer, err := tree.ImportWithOptions(version, opts)
+	require.NoError(t, err)
+	defer importer.Close()
+	for _, node := range nodes {
+		require.NoError(t, importer.Add(node))
+	}
+	return importer.Commit()
+}
+
+func TestImporter_AboveLatestVersion(t *testing.T) {
+	// Both trees have the same history up to version 3.
+	tree := setupDeltaTree(t, 8)
+	target, err := tree.GetImmutable(8)
+	require.NoError(t, err)
+	exported := exportAll(t, target.Export())
+
+	memDB := db.NewMemDB()
+	newTree, err := NewMutableTree(memDB, 0)
+	require.NoError(t, err)
+	r := rand.New(rand.NewSource(5))
+	for v := 1; v <= 3; v++ {
+		for i := 0; i < 64; i++ {
+			key := []byte(fmt.Sprintf("key%03d", r.Intn(512)))
+			if r.Intn(4) == 0 {
+				newTree.Remove(key)
+			} else {
+				newTree.Set(key, []byte(fmt.Sprintf("value%v-%v", v, i)))
+			}
+		}
+		_, _, err = newTree.SaveVersion()
+		require.NoError(t, err)
+	}
+
+	_, err = newTree.Import(8)
+	require.Error(t, err)
+	_, err = newTree.ImportWithOptions(3, ImportOptions{AboveLatestVersion: true})
+	require.Error(t, err)
+
+	// A mismatching import leaves the database unchanged, including shared nodes.
+	before := dumpDB(t, memDB)
+	err = importAll(t, newTree, 8, exported, ImportOptions{AboveLatestVersion: true, ExpectedRootHash: []byte{1}})
+	require.True(t, errors.Is(err, ErrRootHashMismatch), "unexpected error %v", err)
+	require.Equal(t, before, dumpDB(t, memDB))
+
+	require.NoError(t, importAll(t, newTree, 8, exported,
+		ImportOptions{AboveLatestVersion: true, ExpectedRootHash: target.Hash()}))
+	require.EqualValues(t, 8, newTree.Version())
+	require.Equal(t, []int{1, 2, 3, 8}, newTree.AvailableVersions())
+	requireSameTree(t, target, newTree.ImmutableTree)
+	for v := int64(1); v <= 3; v++ {
+		expect, err := tree.GetImmutable(v)
+		require.NoError(t, err)
+		actual, err := newTree.GetImmutable(v)
+		require.NoError(t, err)
+		requireSameTree(t, expect, actual)
+	}
+
+	// Deleting the old versions removes all of their nodes which are not in the new version.
+	for v := int64(1); v <= 3; v++ {
+		require.NoError(t, newTree.DeleteVersion(v))
+	}
+	requireSameTree(t, target, newTree.ImmutableTree)
+	require.Equal(t, len(exported), countKeys(memDB, nodeKeyFormat.Key()))
+
+	// New versions can be saved on top of the imported one.
+	newTree.Set([]byte("new"), []byte("value"))
+	_, version, err := newTree.SaveVersion()
+	require.NoError(t, err)
+	require.EqualValues(t, 9, version)
+}
+
+func TestImporter_AboveLatestVersion_Unrelated(t *testing.T) {
+	tree := setupDeltaTree(t, 8)
+	target, err := tree.GetImmutable(8)
+	require.NoError(t, err)
+	exported := exportAll(t, target.Export())
+
+	// The imported tree has nodes with versions up to the latest version, which are not part of
+	// any existing version.
+	memDB := db.NewMemDB()
+	newTree, err := NewMutableTree(memDB, 0)
+	require.NoError(t, err)
+	for v := 1; v <= 3; v++ {
+		newTree.Set([]byte(fmt.Sprintf("other%v", v)), []byte{byte(v)})
+		_, _, err = newTree.SaveVersion()
+		require.NoError(t, err)
+	}
+	before := dumpDB(t, memDB)
+
+	err = importAll(t, newTree, 8, exported, ImportOptions{AboveLatestVersion: true, ExpectedRootHash: []byte{1}})
+	require.True(t, errors.Is(err, ErrRootHashMismatch), "unexpected error %v", err)
+	require.Equal(t, before, dumpDB(t, memDB))
+
+	// The same goes for cleaning up an aborted import.
+	importer, err := newTree.ImportWithOptions(8, ImportOptions{AboveLatestVersion: true})
+	require.NoError(t, err)
+	for _, node := range exported {
+		require.NoError(t, importer.Add(node))
+	}
+	require.NoError(t, importer.flush())
+	importer.Close()
+	require.NotEqual(t, before, dumpDB(t, memDB))
+	require.NoError(t, newTree.CleanupImports())
+	require.Equal(t, before, dumpDB(t, memDB))
+}
+
+func TestImporter_AboveLatestVersion_Resume(t *testing.T) {
+	tree, err := NewMutableTree(db.NewMemDB(), 0)
+	require.NoError(t, err)
+	r := rand.New(rand.NewSource(7))
+	for i := 0; i < 8000; i++ {
+		tree.Set([]byte(fmt.Sprintf("key%05d", r.Intn(100000))), []byte(fmt.Sprintf("value%v", i)))
+	}
+	_, _, err = tree.SaveVersion()
+	require.NoError(t, err)
+	base := exportAll(t, tree.ImmutableTree.Export())
+	for i := 0; i < 100; i++ {
+		tree.Set([]byte(fmt.Sprintf("key%05d", r.Intn(100000))), []byte(fmt.Sprintf("value%v", i)))
+	}
+	_, _, err = tree.SaveVersion()
+	require.NoError(t, err)
+	exported := exportAll(t, tree.ImmutableTree.Export())
+	require.Greater(t, len(exported), maxBatchSize)
+
+	memDB := db.NewMemDB()
+	newTree, err := NewMutableTree(memDB, 0)
+	require.NoError(t, err)
+	require.NoError(t, importAll(t, newTree, 1, base, ImportOptions{}))
+	before := dumpDB(t, memDB)
+
+	abort := func() {
+		importer, err := newTree.ImportWithOptions(2, ImportOptions{AboveLatestVersion: true})
+		require.NoError(t, err)
+		for _, node := range exported[:maxBatchSize+1] {
+			require.NoError(t, importer.Add(node))
+		}
+		importer.Close()
+	}
+
+	// Cleaning up an aborted import keeps the nodes shared with the existing version.
+	abort()
+	require.NotEqual(t, before, dumpDB(t, memDB))
+	require.NoError(t, newTree.CleanupImports())
+	require.Equal(t, before, dumpDB(t, memDB))
+
+	abort()
+	newTree, err = NewMutableTree(memDB, 0)
+	require.NoError(t, err)
+	_, err = newTree.Load()
+	require.NoError(t, err)
+	importer, err := newTree.ResumeImport(2)
+	require.NoError(t, err)
+	defer importer.Close()
+	require.EqualValues(t, maxBatchSize, importer.Progress())
+	for _, node := range exported[importer.Progress():] {
+		require.NoError(t, importer.Add(node))
+	}
+	require.NoError(t, importer.Commit())
+	require.Equal(t, tree.Hash(), newTree.Hash())
+	require.Equal(t, []int{1, 2}, newTree.AvailableVersions())
+
+	// Versions saved after an import was aborted may share nodes with it.
+	abortTree, err := NewMutableTree(db.NewMemDB(), 0)
+	require.NoError(t, err)
+	require.NoError(t, importAll(t, abortTree, 1, base, ImportOptions{}))
+	importer, err = abortTree.ImportWithOptions(2, ImportOptions{AboveLatestVersion: true})
+	require.NoError(t, err)
+	for _, node := range exported[:maxBatchSize+1] {
+		require.NoError(t, importer.Add(node))
+	}
+	importer.Close()
+	abortTree.Set([]byte("key"), []byte("value"))
+	_, _, err = abortTree.SaveVersion()
+	require.NoError(t, err)
+	require.Error(t, abortTree.CleanupImports())
+	_, err = abortTree.ResumeImport(2)
+	require.Error(t, err)
+}
@@ -0,0 +1,129 @@
+// Package encoding implements the binary encoding primitives used for IAVL nodes and proofs.
+// They reproduce the encoding of go-amino byte-for-byte, since node hashes and stored nodes
+// depend on it.
+package encoding
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"math/bits"
+)
+
+// EncodeVarint writes a zigzag-encoded signed varint.
+func EncodeVarint(w io.Writer, i int64) error {
+	var buf [binary.MaxVarintLen64]byte
+	n := binary.PutVarint(buf[:], i)
+	_, err := w.Write(buf[:n])
+	return err
+}
+
+// EncodeUvarint writes an unsigned varint.
+func EncodeUvarint(w io.Writer, u uint64) error {
+	var buf [binary.MaxVarintLen64]byte
+	n := binary.PutUvarint(buf[:], u)
+	_, err := w.Write(buf[:n])
+	return err
+}
+
+// EncodeByteSlice writes a byte slice prefixed with its length as an unsigned varint.
+func EncodeByteSlice(w io.Writer, bz []byte) error {
+	err := EncodeUvarint(w, uint64(len(bz)))
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(bz)
+	return err
+}
+
+// EncodeInt32 writes a little-endian fixed-size int32.
+func EncodeInt32(w io.Writer, i int32) error {
+	var buf [4]byte
+	binary.LittleEndian.PutUint32(buf[:], uint32(i))
+	_, err := w.Write(buf[:])
+	return err
+}
+
+// DecodeVarint decodes a zigzag-encoded signed varint, returning the number of bytes read.
+func DecodeVarint(bz []byte) (int64, int, error) {
+	i, n := binary.Varint(bz)
+	if n == 0 {
+		return i, n, errors.New("buffer too small")
+	} else if n < 0 {
+		// value larger than 64 bits (overflow), and -n is the number of bytes read
+		return i, -n, errors.New("EOF decoding varint")
+	}
+	return i, n, nil
+}
+
+// DecodeUvarint decodes an unsigned varint, returning the number of bytes read.
+func DecodeUvarint(bz []byte) (uint64, int, error) {
+	u, n := binary.Uvarint(bz)
+	if n == 0 {
+		return u, n, errors.New("buffer too small")
+	} else if n < 0 {
+		// value larger than 64 bits (overflow), and -n is the number of bytes read
+		return u, -n, errors.New("EOF decoding uvarint")
+	}
+	return u, n, nil
+}
+
+// DecodeInt8 decodes a zigzag-encoded signed varint which must fit in an int8.
+func DecodeInt8(bz []byte) (int8, int, error) {
+	i, n, err := DecodeVarint(bz)
+	if err != nil {
+		return 0, n, err
+	}
+	if i < math.MinInt8 || i > math.MaxInt8 {
+		return 0, n, errors.New("EOF decoding int8")
+	}
+	return int8(i), n, nil
+}
+
+// DecodeInt32 decodes a little-endian fixed-size int32.
+func DecodeInt32(bz []byte) (int32, int, error) {
+	if len(bz) < 4 {
+		return 0, 0, errors.New("EOF decoding int32")
+	}
+	return int32(binary.LittleEndian.Uint32(bz[:4])), 4, nil
+}
+
+// DecodeByteSlice decodes a length-prefixed byte slice, returning a copy of it along with the
+// number of bytes read.
+func DecodeByteSlice(bz []byte) ([]byte, int, error) {
+	count, n, err := DecodeUvarint(bz)
+	if err != nil {
+		return nil, n, err
+	}
+	bz = bz[n:]
+	if int(count) < 0 {
+		return nil, n, fmt.Errorf("invalid negative length %v decoding []byte", count)
+	}
+	if len(bz) < int(count) {
+		return nil, n, fmt.Errorf("insufficient bytes decoding []byte of length %v", count)
+	}
+	bz2 := make([]byte, count)
+	copy(bz2, bz[:count])
+	return bz2, n + int(count), nil
+}
+
+// VarintSize returns the encoded size of a signed varint.
+func VarintSize(i int64) int {
+	var buf [binary.MaxVarintLen64]byte
+	return binary.PutVarint(buf[:], i)
+}
+
+// UvarintSize returns the encoded size of an unsigned varint.
+func UvarintSize(u uint64) int {
+	if u == 0 {
+		return 1
+	}
+	return (bits.Len64(u) + 6) / 7
+}
+
+// ByteSliceSize returns the encoded size of a length-prefixed byte slice.
+func ByteSliceSize(bz []byte) int {
+	return UvarintSize(uint64(len(bz))) + len(bz)
+}
@@ -0,0 +1,83 @@
+package encoding
+
+import (
+	"bytes"
+	"encoding/hex"
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// The expected encodings are the ones produced by go-amino.
+func TestEncode(t *testing.T) {
+	testcases := map[string]struct {
+		encode func(*bytes.Buffer) error
+		size   int
+		expect string
+	}{
+		"varint 0":       {func(b *bytes.Buffer) error { return EncodeVarint(b, 0) }, VarintSize(0), "00"},
+		"varint 1":       {func(b *bytes.Buffer) error { return EncodeVarint(b, 1) }, VarintSize(1), "02"},
+		"varint -1":      {func(b *bytes.Buffer) error { return EncodeVarint(b, -1) }, VarintSize(-1), "01"},
+		"varint 300":     {func(b *bytes.Buffer) error { return EncodeVarint(b, 300) }, VarintSize(300), "d804"},
+		"varint max":     {func(b *bytes.Buffer) error { return EncodeVarint(b, math.MaxInt64) }, VarintSize(math.MaxInt64), "feffffffffffffffff01"},
+		"uvarint 0":      {func(b *bytes.Buffer) error { return EncodeUvarint(b, 0) }, UvarintSize(0), "00"},
+		"uvarint 300":    {func(b *bytes.Buffer) error { return EncodeUvarint(b, 300) }, UvarintSize(300), "ac02"},
+		"uvarint max":    {func(b *bytes.Buffer) error { return EncodeUvarint(b, math.MaxUint64) }, UvarintSize(math.MaxUint64), "ffffffffffffffffff01"},
+		"bytes empty":    {func(b *bytes.Buffer) error { return EncodeByteSlice(b, nil) }, ByteSliceSize(nil), "00"},
+		"bytes abc":      {func(b *bytes.Buffer) error { return EncodeByteSlice(b, []byte("abc")) }, ByteSliceSize([]byte("abc")), "03616263"},
+		"int32 1":        {func(b *bytes.Buffer) error { return EncodeInt32(b, 1) }, 4, "01000000"},
+		"int32 negative": {func(b *bytes.Buffer) error { return EncodeInt32(b, -2) }, 4, "feffffff"},
+	}
+	for name, tc := range testcases {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			var buf bytes.Buffer
+			require.NoError(t, tc.encode(&buf))
+			assert.Equal(t, tc.expect, hex.EncodeToString(buf.Bytes()))
+			assert.Equal(t, tc.size, buf.Len())
+		})
+	}
+}
+
+func TestDecode(t *testing.T) {
+	i, n, err := DecodeVarint([]byte{0xd8, 0x04, 0xff})
+	require.NoError(t, err)
+	assert.EqualValues(t, 300, i)
+	assert.Equal(t, 2, n)
+
+	u, n, err := DecodeUvarint([]byte{0xac, 0x02})
+	require.NoError(t, err)
+	assert.EqualValues(t, 300, u)
+	assert.Equal(t, 2, n)
+
+	i8, _, err := DecodeInt8([]byte{0x05})
+	require.NoError(t, err)
+	assert.EqualValues(t, -3, i8)
+	_, _, err = DecodeInt8([]byte{0x80, 0x02})
+	assert.Error(t, err)
+
+	i32, n, err := DecodeInt32([]byte{0xfe, 0xff, 0xff, 0xff})
+	require.NoError(t, err)
+	assert.EqualValues(t, -2, i32)
+	assert.Equal(t, 4, n)
+	_, _, err = DecodeInt32([]byte{0x01})
+	assert.Error(t, err)
+
+	bz, n, err := DecodeByteSlice([]byte{0x03, 'a', 'b', 'c', 'd'})
+	require.NoError(t, err)
+	assert.Equal(t, []byte("abc"), bz)
+	assert.Equal(t, 4, n)
+	_, _, err = DecodeByteSlice([]byte{0x03, 'a'})
+	assert.Error(t, err)
+
+	for _, bz := range [][]byte{nil, {0x80}, bytes.Repeat([]byte{0xff}, 11)} {
+		_, _, err = DecodeVarint(bz)
+		assert.Error(t, err)
+		_, _, err = DecodeUvarint(bz)
+		assert.Error(t, err)
+		_, _, err = DecodeByteSlice(bz)
+		assert.Error(t, err)
+	}
+}
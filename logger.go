@@ -2,14 +2,135 @@ package iavl
 
 import (
 	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"sync"
 )
 
-var (
-	debugging = false
+// Logger is a leveled, structured logger. Each message is followed by a list of
+// alternating key/value pairs describing the event, e.g.
+//
+//	logger.Info("saved version", "version", 3, "orphans", 12)
+type Logger interface {
+	Debug(msg string, keyvals ...interface{})
+	Info(msg string, keyvals ...interface{})
+	Error(msg string, keyvals ...interface{})
+}
+
+// LogLevel is the minimum level of messages emitted by a Logger returned by NewLogger.
+type LogLevel int8
+
+// Log levels, in increasing order of severity.
+const (
+	LogLevelDebug LogLevel = iota
+	LogLevelInfo
+	LogLevelError
 )
 
-func debug(format string, args ...interface{}) {
-	if debugging {
-		fmt.Printf(format, args...)
+// String returns the name of the log level as used in the log output.
+func (l LogLevel) String() string {
+	switch l {
+	case LogLevelDebug:
+		return "debug"
+	case LogLevelInfo:
+		return "info"
+	case LogLevelError:
+		return "error"
+	default:
+		return fmt.Sprintf("level(%d)", l)
+	}
+}
+
+type nopLogger struct{}
+
+var _ Logger = nopLogger{}
+
+// NewNopLogger returns a Logger that discards all messages. It is used when no
+// logger is given in the tree Options.
+func NewNopLogger() Logger {
+	return nopLogger{}
+}
+
+func (nopLogger) Debug(string, ...interface{}) {}
+func (nopLogger) Info(string, ...interface{})  {}
+func (nopLogger) Error(string, ...interface{}) {}
+
+// writerLogger writes logfmt-style lines to an io.Writer.
+type writerLogger struct {
+	mtx   sync.Mutex
+	w     io.Writer
+	level LogLevel
+}
+
+var _ Logger = (*writerLogger)(nil)
+
+// NewLogger returns a Logger which writes one logfmt-style line per message to w, e.g.
+//
+//	level=info msg="saved version" version=3 hash=6E1A... orphans=12
+//
+// Messages below the given level are discarded. Byte slices are written as
+// upper-case hex. The logger is safe for concurrent use.
+func NewLogger(w io.Writer, level LogLevel) Logger {
+	return &writerLogger{w: w, level: level}
+}
+
+func (l *writerLogger) Debug(msg string, keyvals ...interface{}) {
+	l.log(LogLevelDebug, msg, keyvals)
+}
+
+func (l *writerLogger) Info(msg string, keyvals ...interface{}) {
+	l.log(LogLevelInfo, msg, keyvals)
+}
+
+func (l *writerLogger) Error(msg string, keyvals ...interface{}) {
+	l.log(LogLevelError, msg, keyvals)
+}
+
+func (l *writerLogger) log(level LogLevel, msg string, keyvals []interface{}) {
+	if level < l.level {
+		return
+	}
+
+	var sb strings.Builder
+	sb.WriteString("level=")
+	sb.WriteString(level.String())
+	sb.WriteString(" msg=")
+	sb.WriteString(logfmtValue(msg))
+	for i := 0; i < len(keyvals); i += 2 {
+		sb.WriteByte(' ')
+		sb.WriteString(logfmtValue(keyvals[i]))
+		sb.WriteByte('=')
+		if i+1 < len(keyvals) {
+			sb.WriteString(logfmtValue(keyvals[i+1]))
+		} else {
+			sb.WriteString("MISSING")
+		}
+	}
+	sb.WriteByte('\n')
+
+	l.mtx.Lock()
+	defer l.mtx.Unlock()
+	_, _ = io.WriteString(l.w, sb.String())
+}
+
+// logfmtValue formats a key or value, quoting it if necessary.
+func logfmtValue(v interface{}) string {
+	var s string
+	switch v := v.(type) {
+	case []byte:
+		return fmt.Sprintf("%X", v)
+	case string:
+		s = v
+	case error:
+		s = v.Error()
+	case fmt.Stringer:
+		s = v.String()
+	default:
+		s = fmt.Sprintf("%v", v)
+	}
+	if s == "" || strings.ContainsAny(s, " =\"\t\n") {
+		return strconv.Quote(s)
 	}
+	return s
 }
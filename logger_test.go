@@ -0,0 +1,68 @@
+package iavl
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	db "github.com/tendermint/tm-db"
+)
+
+func TestLogger_Format(t *testing.T) {
+	buf := &bytes.Buffer{}
+	logger := NewLogger(buf, LogLevelDebug)
+
+	logger.Info("saved version", "version", 3, "hash", []byte{0xab, 0x01}, "snapshot", true)
+	logger.Error("failed", "err", errors.New("disk full"), "dangling")
+
+	require.Equal(t, `level=info msg="saved version" version=3 hash=AB01 snapshot=true
+level=error msg=failed err="disk full" dangling=MISSING
+`, buf.String())
+}
+
+func TestLogger_Level(t *testing.T) {
+	buf := &bytes.Buffer{}
+	logger := NewLogger(buf, LogLevelInfo)
+
+	logger.Debug("hidden")
+	logger.Info("shown")
+	logger.Error("shown")
+	require.Equal(t, "level=info msg=shown\nlevel=error msg=shown\n", buf.String())
+
+	NewNopLogger().Error("nothing happens")
+}
+
+func TestLogger_TreeEvents(t *testing.T) {
+	buf := &bytes.Buffer{}
+	opts := PruningOptions(1, 1)
+	opts.Logger = NewLogger(buf, LogLevelDebug)
+
+	tree, err := NewMutableTreeWithOpts(db.NewMemDB(), db.NewMemDB(), 0, opts)
+	require.NoError(t, err)
+
+	tree.Set([]byte("a"), []byte{1})
+	tree.Set([]byte("b"), []byte{2})
+	_, _, err = tree.SaveVersion()
+	require.NoError(t, err)
+
+	tree.Set([]byte("a"), []byte{3})
+	_, _, err = tree.SaveVersion()
+	require.NoError(t, err)
+
+	tree.Set([]byte("b"), []byte{4})
+	_, _, err = tree.SaveVersion()
+	require.NoError(t, err)
+
+	err = tree.DeleteVersion(1)
+	require.NoError(t, err)
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	require.Contains(t, lines[0], `level=info msg="saved version" version=1`)
+	require.Contains(t, lines[0], "size=2 orphans=0 snapshot=true")
+	require.Contains(t, buf.String(), `level=debug msg="saved orphans" version=2 toVersion=1 orphans=2 flushed=2`)
+	require.Contains(t, buf.String(), `level=debug msg="pruned orphans" version=1 db=recent deleted=2 moved=0`)
+	require.Contains(t, buf.String(), `level=info msg="deleted version" version=1`)
+}
@@ -1,5 +1,12 @@
 package iavl
 
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/pkg/errors"
+)
+
 // ProofOperator is a layer for calculating intermediate Merkle roots
 // when a series of Merkle trees are chained together.
 // Run() takes leaf values from a tree and returns the Merkle
@@ -12,3 +19,150 @@ type ProofOperator interface {
 	GetKey() []byte
 	ProofOp() ProofOp
 }
+
+// ProofOpEncoding is the encoding of the RangeProof in the ProofOp.Data of ValueOp and
+// AbsenceOp. Each encoding has its own ProofOp.Type, so that decoders can tell them apart.
+type ProofOpEncoding int8
+
+const (
+	// ProofOpEncodingAmino encodes the proof with the go-amino binary encoding. This is the
+	// default.
+	ProofOpEncodingAmino ProofOpEncoding = iota
+	// ProofOpEncodingProto encodes the proof as the protobuf messages in proto/iavl/proof.proto.
+	ProofOpEncodingProto
+)
+
+// ProofOperators is a slice of ProofOperator(s).
+// Each operator will be applied to the input value sequentially
+// and the last Merkle root will be verified with already known data.
+type ProofOperators []ProofOperator
+
+// VerifyValue verifies that the operators prove the value at the keypath under the root.
+func (poz ProofOperators) VerifyValue(root []byte, keypath string, value []byte) error {
+	return poz.Verify(root, keypath, [][]byte{value})
+}
+
+// Verify runs the operators on args, and verifies that the result is the root. Each operator
+// with a key consumes the last remaining key of the keypath, so the keypath lists the keys from
+// the outermost tree to the innermost one, and all of them must be consumed.
+func (poz ProofOperators) Verify(root []byte, keypath string, args [][]byte) error {
+	keys, err := KeyPathToKeys(keypath)
+	if err != nil {
+		return err
+	}
+
+	for i, op := range poz {
+		key := op.GetKey()
+		if len(key) != 0 {
+			if len(keys) == 0 {
+				return errors.Wrapf(ErrInvalidProof, "key path has insufficient # of parts: expected no more keys but got %+v", string(key))
+			}
+			lastKey := keys[len(keys)-1]
+			if !bytes.Equal(lastKey, key) {
+				return errors.Wrapf(ErrInvalidProof, "key mismatch on operation #%d: expected %+v but got %+v", i, string(lastKey), string(key))
+			}
+			keys = keys[:len(keys)-1]
+		}
+		args, err = op.Run(args)
+		if err != nil {
+			return err
+		}
+	}
+	if len(args) != 1 {
+		return errors.Wrapf(ErrInvalidProof, "expected 1 root from the last operation, got %d", len(args))
+	}
+	if !bytes.Equal(root, args[0]) {
+		return errors.Wrapf(ErrInvalidRoot, "calculated root hash is invalid: expected %X but got %X", root, args[0])
+	}
+	if len(keys) != 0 {
+		return errors.Wrap(ErrInvalidProof, "keypath not consumed all")
+	}
+	return nil
+}
+
+// OpDecoder decodes a ProofOp of a given type into a ProofOperator.
+type OpDecoder func(ProofOp) (ProofOperator, error)
+
+// ProofRuntime decodes proofs with a registry of OpDecoders, one per ProofOp.Type, and verifies
+// them.
+type ProofRuntime struct {
+	decoders map[string]OpDecoder
+}
+
+// NewProofRuntime returns a ProofRuntime without any decoders. See DefaultProofRuntime().
+func NewProofRuntime() *ProofRuntime {
+	return &ProofRuntime{
+		decoders: make(map[string]OpDecoder),
+	}
+}
+
+// DefaultProofRuntime returns a ProofRuntime with decoders for the IAVL value and absence
+// operators in all encodings, and the simple Merkle value operator, which is enough to verify
+// a multi-store proof from a value to the app hash.
+func DefaultProofRuntime() *ProofRuntime {
+	prt := NewProofRuntime()
+	prt.RegisterOpDecoder(ProofOpIAVLValue, ValueOpDecoder)
+	prt.RegisterOpDecoder(ProofOpIAVLValueProto, ValueOpDecoder)
+	prt.RegisterOpDecoder(ProofOpIAVLAbsence, AbsenceOpDecoder)
+	prt.RegisterOpDecoder(ProofOpIAVLAbsenceProto, AbsenceOpDecoder)
+	prt.RegisterOpDecoder(ProofOpSimpleValue, SimpleValueOpDecoder)
+	return prt
+}
+
+// RegisterOpDecoder registers the decoder for the ProofOp type. It panics if a decoder is
+// already registered for the type.
+func (prt *ProofRuntime) RegisterOpDecoder(typ string, dec OpDecoder) {
+	_, ok := prt.decoders[typ]
+	if ok {
+		panic(fmt.Sprintf("already registered for type %v", typ))
+	}
+	prt.decoders[typ] = dec
+}
+
+// Decode decodes a ProofOp with the decoder registered for its type.
+func (prt *ProofRuntime) Decode(pop ProofOp) (ProofOperator, error) {
+	decoder := prt.decoders[pop.Type]
+	if decoder == nil {
+		return nil, errors.Errorf("unrecognized proof type %v", pop.Type)
+	}
+	return decoder(pop)
+}
+
+// DecodeProof decodes all the ProofOps of the proof.
+func (prt *ProofRuntime) DecodeProof(proof *Proof) (ProofOperators, error) {
+	if proof == nil {
+		return nil, errors.Wrap(ErrInvalidProof, "proof is nil")
+	}
+	poz := make(ProofOperators, 0, len(proof.Ops))
+	for _, pop := range proof.Ops {
+		if pop == nil {
+			return nil, errors.Wrap(ErrInvalidProof, "proof operation is nil")
+		}
+		operator, err := prt.Decode(*pop)
+		if err != nil {
+			return nil, errors.Wrap(err, "decoding a proof operator")
+		}
+		poz = append(poz, operator)
+	}
+	return poz, nil
+}
+
+// VerifyValue verifies that the proof proves the value at the keypath under the root.
+func (prt *ProofRuntime) VerifyValue(proof *Proof, root []byte, keypath string, value []byte) error {
+	return prt.Verify(proof, root, keypath, [][]byte{value})
+}
+
+// VerifyAbsence verifies that the proof proves the absence of the keypath under the root.
+func (prt *ProofRuntime) VerifyAbsence(proof *Proof, root []byte, keypath string) error {
+	return prt.Verify(proof, root, keypath, nil)
+}
+
+// Verify decodes the proof, and verifies it against the root with the given arguments to the
+// first operator. See ProofOperators.Verify().
+func (prt *ProofRuntime) Verify(proof *Proof, root []byte, keypath string, args [][]byte) error {
+	poz, err := prt.DecodeProof(proof)
+	if err != nil {
+		return errors.Wrap(err, "decoding proof")
+	}
+	return poz.Verify(root, keypath, args)
+}
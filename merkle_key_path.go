@@ -0,0 +1,95 @@
+package iavl
+
+import (
+	"encoding/hex"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+/*
+	For generalized Merkle proofs, each layer of the proof may require an
+	optional key. The key may be encoded either by URL-encoding or
+	(upper-case) hex-encoding.
+	TODO: In the future, more encodings may be supported, like base32 (e.g.
+	/32:)
+
+	For example, for a Cosmos-SDK application where the first two proof layers
+	are ValueOps, and the third proof layer is a SimpleValueOp, the key path
+	might look like:
+
+	/appstore/MYKEY
+
+	The key of the first layer is "appstore", and the key of the second layer
+	is "MYKEY". Keys which aren't valid URL path segments can be hex-encoded
+	with the "x:" prefix, e.g.:
+
+	/appstore/x:00FF
+*/
+
+// KeyEncoding is the encoding of a key in a KeyPath.
+type KeyEncoding int
+
+const (
+	// KeyEncodingURL URL-encodes the key.
+	KeyEncodingURL KeyEncoding = iota
+	// KeyEncodingHex upper-case hex-encodes the key, with the prefix "x:".
+	KeyEncodingHex
+)
+
+// Key is a key of a KeyPath, along with its encoding.
+type Key struct {
+	name []byte
+	enc  KeyEncoding
+}
+
+// KeyPath is a path of keys from the outermost tree of a proof to the innermost one.
+type KeyPath []Key
+
+// AppendKey returns the key path with the key appended.
+func (pth KeyPath) AppendKey(key []byte, enc KeyEncoding) KeyPath {
+	return append(pth, Key{key, enc})
+}
+
+// String encodes the key path, e.g. "/appstore/x:00FF".
+func (pth KeyPath) String() string {
+	var sb strings.Builder
+	for _, key := range pth {
+		switch key.enc {
+		case KeyEncodingURL:
+			sb.WriteString("/" + url.PathEscape(string(key.name)))
+		case KeyEncodingHex:
+			sb.WriteString("/x:" + fmt.Sprintf("%X", key.name))
+		default:
+			panic(fmt.Sprintf("unexpected key encoding type %v", key.enc))
+		}
+	}
+	return sb.String()
+}
+
+// KeyPathToKeys decodes an encoded key path into its keys, outermost first.
+func KeyPathToKeys(path string) (keys [][]byte, err error) {
+	if path == "" || path[0] != '/' {
+		return nil, errors.Wrap(ErrInvalidInputs, "key path string must start with a forward slash '/'")
+	}
+	parts := strings.Split(path[1:], "/")
+	keys = make([][]byte, len(parts))
+	for i, part := range parts {
+		if strings.HasPrefix(part, "x:") {
+			key, err := hex.DecodeString(part[2:])
+			if err != nil {
+				return nil, errors.Wrapf(err, "decoding hex-encoded part #%d: /%s", i, part)
+			}
+			keys[i] = key
+		} else {
+			key, err := url.PathUnescape(part)
+			if err != nil {
+				return nil, errors.Wrapf(err, "decoding url-encoded part #%d: /%s", i, part)
+			}
+			keys[i] = []byte(key)
+		}
+	}
+	return keys, nil
+}
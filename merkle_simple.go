@@ -0,0 +1,236 @@
+package iavl
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"math/bits"
+	"sort"
+
+	"github.com/pkg/errors"
+
+	"github.com/tendermint/iavl/internal/encoding"
+	"github.com/tendermint/iavl/proofpb"
+)
+
+// The simple Merkle tree is a binary tree of hashes over a list of items, as described in
+// RFC 6962: leaves are hashed with the prefix 0x00 and inner nodes with the prefix 0x01, and
+// the left subtree of a node with n items holds the largest power of 2 smaller than n items.
+// It is used for the outer layer of multi-store proofs, where the items are the store roots.
+var (
+	simpleLeafPrefix  = []byte{0}
+	simpleInnerPrefix = []byte{1}
+)
+
+func simpleLeafHash(leaf []byte) []byte {
+	h := sha256.New()
+	h.Write(simpleLeafPrefix)
+	h.Write(leaf)
+	return h.Sum(nil)
+}
+
+func simpleInnerHash(left, right []byte) []byte {
+	h := sha256.New()
+	h.Write(simpleInnerPrefix)
+	h.Write(left)
+	h.Write(right)
+	return h.Sum(nil)
+}
+
+// getSplitPoint returns the largest power of 2 less than length.
+func getSplitPoint(length int) int {
+	if length < 1 {
+		panic("trying to split a tree with size < 1")
+	}
+	k := 1 << uint(bits.Len(uint(length))-1)
+	if k == length {
+		k >>= 1
+	}
+	return k
+}
+
+// SimpleHashFromByteSlices computes the simple Merkle root of the items. The root of no items
+// is nil.
+func SimpleHashFromByteSlices(items [][]byte) []byte {
+	switch len(items) {
+	case 0:
+		return nil
+	case 1:
+		return simpleLeafHash(items[0])
+	default:
+		k := getSplitPoint(len(items))
+		left := SimpleHashFromByteSlices(items[:k])
+		right := SimpleHashFromByteSlices(items[k:])
+		return simpleInnerHash(left, right)
+	}
+}
+
+// SimpleProofsFromByteSlices computes the simple Merkle root of the items, along with a proof
+// for each item.
+func SimpleProofsFromByteSlices(items [][]byte) (rootHash []byte, proofs []*SimpleProof) {
+	rootHash, leafHashes, aunts := simpleAuntsFromByteSlices(items)
+	proofs = make([]*SimpleProof, len(items))
+	for i := range items {
+		proofs[i] = &SimpleProof{
+			Total:    int64(len(items)),
+			Index:    int64(i),
+			LeafHash: leafHashes[i],
+			Aunts:    aunts[i],
+		}
+	}
+	return rootHash, proofs
+}
+
+// simpleAuntsFromByteSlices returns the root of the items, along with the leaf hash and the
+// aunts of each item, lowest first.
+func simpleAuntsFromByteSlices(items [][]byte) (rootHash []byte, leafHashes [][]byte, aunts [][][]byte) {
+	switch len(items) {
+	case 0:
+		return nil, nil, nil
+	case 1:
+		leafHash := simpleLeafHash(items[0])
+		return leafHash, [][]byte{leafHash}, [][][]byte{nil}
+	default:
+		k := getSplitPoint(len(items))
+		left, leftLeaves, leftAunts := simpleAuntsFromByteSlices(items[:k])
+		right, rightLeaves, rightAunts := simpleAuntsFromByteSlices(items[k:])
+		for i := range leftAunts {
+			leftAunts[i] = append(leftAunts[i], right)
+		}
+		for i := range rightAunts {
+			rightAunts[i] = append(rightAunts[i], left)
+		}
+		return simpleInnerHash(left, right), append(leftLeaves, rightLeaves...), append(leftAunts, rightAunts...)
+	}
+}
+
+// SimpleHashFromMap computes the simple Merkle root of the key/value pairs of the map, sorted
+// by key. See SimpleProofsFromMap().
+func SimpleHashFromMap(m map[string][]byte) []byte {
+	_, items := simpleKVItems(m)
+	return SimpleHashFromByteSlices(items)
+}
+
+// SimpleProofsFromMap computes the simple Merkle root of the key/value pairs of the map, along
+// with a proof for each key. The pairs are sorted by key, and each leaf is the length-prefixed
+// key followed by the length-prefixed SHA256 hash of the value, as verified by SimpleValueOp.
+func SimpleProofsFromMap(m map[string][]byte) (rootHash []byte, proofs map[string]*SimpleProof, keys []string) {
+	keys, items := simpleKVItems(m)
+	rootHash, proofList := SimpleProofsFromByteSlices(items)
+	proofs = make(map[string]*SimpleProof, len(keys))
+	for i, key := range keys {
+		proofs[key] = proofList[i]
+	}
+	return rootHash, proofs, keys
+}
+
+// simpleKVItems returns the sorted keys of the map, along with the leaf of each.
+func simpleKVItems(m map[string][]byte) (keys []string, items [][]byte) {
+	keys = make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	items = make([][]byte, len(keys))
+	for i, key := range keys {
+		items[i] = simpleKVLeaf([]byte(key), m[key])
+	}
+	return keys, items
+}
+
+// simpleKVLeaf encodes a key/value pair as a simple Merkle leaf.
+func simpleKVLeaf(key, value []byte) []byte {
+	valueHash := sha256.Sum256(value)
+	var buf bytes.Buffer
+	// Writing to a bytes.Buffer can't fail.
+	_ = encoding.EncodeByteSlice(&buf, key)
+	_ = encoding.EncodeByteSlice(&buf, valueHash[:])
+	return buf.Bytes()
+}
+
+// SimpleProof is a proof of a leaf in a simple Merkle tree. The aunts are the sibling hashes
+// along the path from the leaf to the root, lowest first.
+type SimpleProof struct {
+	Total    int64    `json:"total"`
+	Index    int64    `json:"index"`
+	LeafHash []byte   `json:"leaf_hash"`
+	Aunts    [][]byte `json:"aunts"`
+}
+
+// Verify verifies that the proof proves the leaf under the root hash.
+func (sp *SimpleProof) Verify(rootHash []byte, leaf []byte) error {
+	if sp == nil {
+		return errors.Wrap(ErrInvalidProof, "proof is nil")
+	}
+	if sp.Total < 0 || sp.Index < 0 {
+		return errors.Wrap(ErrInvalidProof, "proof total and index must be non-negative")
+	}
+	if !bytes.Equal(sp.LeafHash, simpleLeafHash(leaf)) {
+		return errors.Wrapf(ErrInvalidProof, "invalid leaf hash: wanted %X got %X", simpleLeafHash(leaf), sp.LeafHash)
+	}
+	computedHash := sp.ComputeRootHash()
+	if computedHash == nil || !bytes.Equal(computedHash, rootHash) {
+		return errors.Wrapf(ErrInvalidRoot, "invalid root hash: wanted %X got %X", rootHash, computedHash)
+	}
+	return nil
+}
+
+// ComputeRootHash computes the root hash from the leaf hash and aunts, or returns nil if the
+// proof is malformed. Does not verify the root hash.
+func (sp *SimpleProof) ComputeRootHash() []byte {
+	return computeSimpleHashFromAunts(sp.Index, sp.Total, sp.LeafHash, sp.Aunts)
+}
+
+func computeSimpleHashFromAunts(index, total int64, leafHash []byte, innerHashes [][]byte) []byte {
+	if index >= total || index < 0 || total <= 0 {
+		return nil
+	}
+	if total == 1 {
+		if len(innerHashes) != 0 {
+			return nil
+		}
+		return leafHash
+	}
+	if len(innerHashes) == 0 {
+		return nil
+	}
+	numLeft := int64(getSplitPoint(int(total)))
+	aunt, rest := innerHashes[len(innerHashes)-1], innerHashes[:len(innerHashes)-1]
+	if index < numLeft {
+		leftHash := computeSimpleHashFromAunts(index, numLeft, leafHash, rest)
+		if leftHash == nil {
+			return nil
+		}
+		return simpleInnerHash(leftHash, aunt)
+	}
+	rightHash := computeSimpleHashFromAunts(index-numLeft, total-numLeft, leafHash, rest)
+	if rightHash == nil {
+		return nil
+	}
+	return simpleInnerHash(aunt, rightHash)
+}
+
+// ToProto converts the proof to its protobuf representation.
+func (sp *SimpleProof) ToProto() *proofpb.SimpleProof {
+	if sp == nil {
+		return nil
+	}
+	return &proofpb.SimpleProof{
+		Total:    sp.Total,
+		Index:    sp.Index,
+		LeafHash: sp.LeafHash,
+		Aunts:    sp.Aunts,
+	}
+}
+
+// SimpleProofFromProto converts a protobuf SimpleProof to a SimpleProof.
+func SimpleProofFromProto(pb *proofpb.SimpleProof) (*SimpleProof, error) {
+	if pb == nil {
+		return nil, errors.Wrap(ErrInvalidProof, "simple proof is nil")
+	}
+	return &SimpleProof{
+		Total:    pb.Total,
+		Index:    pb.Index,
+		LeafHash: pb.LeafHash,
+		Aunts:    pb.Aunts,
+	}, nil
+}
@@ -0,0 +1,97 @@
+package iavl
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSimpleProofs(t *testing.T) {
+	for total := 0; total <= 33; total++ {
+		items := make([][]byte, total)
+		for i := range items {
+			items[i] = []byte(fmt.Sprintf("item-%d", i))
+		}
+		rootHash, proofs := SimpleProofsFromByteSlices(items)
+		require.Equal(t, SimpleHashFromByteSlices(items), rootHash)
+		require.Len(t, proofs, total)
+		for i, proof := range proofs {
+			require.NoError(t, proof.Verify(rootHash, items[i]), "item %d of %d", i, total)
+			require.Error(t, proof.Verify(rootHash, []byte("foo")), "item %d of %d", i, total)
+			require.Error(t, proof.Verify([]byte("foo"), items[i]), "item %d of %d", i, total)
+			if total > 1 {
+				proof.Index = (proof.Index + 1) % proof.Total
+				require.Error(t, proof.Verify(rootHash, items[i]), "item %d of %d", i, total)
+			}
+		}
+	}
+}
+
+func TestKeyPath(t *testing.T) {
+	var path KeyPath
+	path = path.AppendKey([]byte("store/name"), KeyEncodingURL)
+	path = path.AppendKey([]byte{0x00, 0xff}, KeyEncodingHex)
+	path = path.AppendKey([]byte("key"), KeyEncodingURL)
+	require.Equal(t, "/store%2Fname/x:00FF/key", path.String())
+
+	keys, err := KeyPathToKeys(path.String())
+	require.NoError(t, err)
+	require.Equal(t, [][]byte{[]byte("store/name"), {0x00, 0xff}, []byte("key")}, keys)
+
+	_, err = KeyPathToKeys("key")
+	require.Error(t, err)
+	_, err = KeyPathToKeys("/x:0")
+	require.Error(t, err)
+	_, err = KeyPathToKeys("/%zz")
+	require.Error(t, err)
+}
+
+// TestProofRuntime_MultiStore verifies a chained proof from a value in an IAVL store, through
+// the simple Merkle tree of store roots, to the app hash.
+func TestProofRuntime_MultiStore(t *testing.T) {
+	storeRoots := make(map[string][]byte)
+	stores := make(map[string]*MutableTree)
+	for _, name := range []string{"acc", "bank", "gov"} {
+		tree, err := getTestTree(0)
+		require.NoError(t, err)
+		for i := 0; i < 50; i += 2 {
+			tree.Set([]byte(fmt.Sprintf("key-%03d", i)), []byte(fmt.Sprintf("%s-%d", name, i)))
+		}
+		_, _, err = tree.SaveVersion()
+		require.NoError(t, err)
+		stores[name] = tree
+		storeRoots[name] = tree.Hash()
+	}
+	appHash, storeProofs, _ := SimpleProofsFromMap(storeRoots)
+	require.Equal(t, SimpleHashFromMap(storeRoots), appHash)
+	storeOp := NewSimpleValueOp([]byte("bank"), storeProofs["bank"]).ProofOp()
+
+	prt := DefaultProofRuntime()
+
+	value, existProof, err := stores["bank"].GetWithProof([]byte("key-010"))
+	require.NoError(t, err)
+	valueOp := NewValueOpWithEncoding([]byte("key-010"), existProof, ProofOpEncodingProto).ProofOp()
+	proof := &Proof{Ops: []*ProofOp{&valueOp, &storeOp}}
+	require.NoError(t, prt.VerifyValue(proof, appHash, "/bank/key-010", value))
+	assert.Error(t, prt.VerifyValue(proof, appHash, "/bank/key-010", []byte("foo")))
+	assert.Error(t, prt.VerifyValue(proof, appHash, "/acc/key-010", value))
+	assert.Error(t, prt.VerifyValue(proof, appHash, "/key-010", value))
+	assert.Error(t, prt.VerifyValue(proof, appHash, "/x/bank/key-010", value))
+	assert.Error(t, prt.VerifyValue(proof, storeRoots["bank"], "/bank/key-010", value))
+	assert.Error(t, prt.VerifyAbsence(proof, appHash, "/bank/key-010"))
+
+	_, absentProof, err := stores["bank"].GetWithProof([]byte("key-011"))
+	require.NoError(t, err)
+	absenceOp := NewAbsenceOp([]byte("key-011"), absentProof).ProofOp()
+	proof = &Proof{Ops: []*ProofOp{&absenceOp, &storeOp}}
+	require.NoError(t, prt.VerifyAbsence(proof, appHash, "/bank/key-011"))
+	assert.Error(t, prt.VerifyAbsence(proof, appHash, "/bank/key-012"))
+
+	// Unknown operation types are rejected.
+	unknown := ProofOp{Type: "foo", Key: []byte("bank")}
+	proof = &Proof{Ops: []*ProofOp{&valueOp, &unknown}}
+	assert.Error(t, prt.VerifyValue(proof, appHash, "/bank/key-010", value))
+	assert.Panics(t, func() { prt.RegisterOpDecoder(ProofOpSimpleValue, SimpleValueOpDecoder) })
+}
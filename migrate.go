@@ -0,0 +1,262 @@
+package iavl
+
+import (
+	"bytes"
+	"sort"
+
+	"github.com/pkg/errors"
+
+	dbm "github.com/tendermint/tm-db"
+)
+
+// MigrateResult reports the outcome of Migrate().
+type MigrateResult struct {
+	Versions int    // number of versions in the source
+	Resumed  int    // number of versions already copied by an interrupted migration
+	Nodes    uint64 // number of nodes copied
+	Orphans  uint64 // number of orphan records copied
+	Metadata uint64 // number of version metadata records copied
+}
+
+// Migrate copies the live data of a snapshot database to another database, e.g. of a different
+// backend or to compact it: the nodes reachable from each root, the roots, the orphan records of
+// those nodes and the version metadata. Unreachable nodes and orphan records of missing nodes are
+// left behind.
+//
+// The hash of every copied node is verified, and since inner node hashes cover their children,
+// so is the root hash of every version. Nodes are written after their children and roots after
+// their nodes, so a node in the target implies its subtree, and a root its version. An
+// interrupted migration can thus be resumed by calling Migrate() again with the same databases,
+// which skips copied versions and subtrees.
+//
+// The source must not be opened by a tree while migrating, and the target must be empty or
+// contain an interrupted migration of the same source. The target is trusted: when resuming, the
+// roots and root nodes of copied versions and the topmost nodes of skipped subtrees are checked
+// against the source, but the rest of the skipped subtrees are not read. A target which may have
+// been modified by anything else should be discarded instead.
+func Migrate(source, target dbm.DB) (*MigrateResult, error) {
+	sndb := newNodeDB(source, dbm.NewMemDB(), 0, nil)
+	pending, err := sndb.getImportCheckpointVersions()
+	if err != nil {
+		return nil, err
+	}
+	if len(pending) > 0 {
+		return nil, errors.Errorf("found aborted import of version %v, it must be resumed or cleaned up",
+			pending[0])
+	}
+
+	roots, err := migrateRoots(source, target)
+	if err != nil {
+		return nil, err
+	}
+	versions := make([]int64, 0, len(roots))
+	for version := range roots {
+		versions = append(versions, version)
+	}
+	sort.Slice(versions, func(i, j int) bool { return versions[i] < versions[j] })
+
+	result := &MigrateResult{Versions: len(versions)}
+	m := &migration{source: source, target: target, result: result}
+	for _, version := range versions {
+		done, err := target.Has(rootKeyFormat.Key(version))
+		if err != nil {
+			return nil, err
+		}
+		if done {
+			result.Resumed++
+			continue
+		}
+		if err = m.copyVersion(version, roots[version]); err != nil {
+			return nil, errors.Wrapf(err, "copying version %v", version)
+		}
+	}
+
+	if err = m.copyRecords(); err != nil {
+		return nil, err
+	}
+	for _, version := range versions {
+		if err = verifyMigratedRoot(target, version, roots[version]); err != nil {
+			return nil, err
+		}
+	}
+	return result, nil
+}
+
+// verifyMigratedRoot checks that the target has the given root hash for the version, and that
+// its root node has that hash.
+func verifyMigratedRoot(target dbm.DB, version int64, rootHash []byte) error {
+	hash, err := target.Get(rootKeyFormat.Key(version))
+	if err != nil {
+		return err
+	}
+	if !bytes.Equal(hash, rootHash) {
+		return errors.Errorf("target has root hash %X for version %v, expected %X", hash, version, rootHash)
+	}
+	if len(rootHash) == 0 {
+		return nil
+	}
+	bz, err := target.Get(nodeKeyFormat.Key(rootHash))
+	if err != nil {
+		return err
+	}
+	if bz == nil {
+		return errors.Errorf("target is missing root node %X of version %v", rootHash, version)
+	}
+	node, err := MakeNode(bz)
+	if err != nil {
+		return errors.Wrapf(err, "decoding root node %X of version %v", rootHash, version)
+	}
+	if !bytes.Equal(node._hash(), rootHash) {
+		return errors.Errorf("root node of version %v has hash %X, expected %X", version, node.hash, rootHash)
+	}
+	return nil
+}
+
+// migrateRoots returns the roots of the source by version, after checking that any roots in the
+// target were copied from it.
+func migrateRoots(source, target dbm.DB) (map[int64][]byte, error) {
+	roots := map[int64][]byte{}
+	traversePrefixFromDB(source, rootKeyFormat.Key(), func(k, v []byte) {
+		var version int64
+		rootKeyFormat.Scan(k, &version)
+		roots[version] = v
+	})
+
+	var err error
+	traversePrefixFromDB(target, rootKeyFormat.Key(), func(k, v []byte) {
+		var version int64
+		rootKeyFormat.Scan(k, &version)
+		if err != nil {
+			return
+		}
+		if hash, ok := roots[version]; !ok {
+			err = errors.Errorf("target has root %X for version %v, which is not in the source", v, version)
+		} else if !bytes.Equal(hash, v) {
+			err = errors.Errorf("target has root %X for version %v, but the source has root %X", v, version, hash)
+		}
+	})
+	return roots, err
+}
+
+// migration is the state of a Migrate() call.
+type migration struct {
+	source    dbm.DB
+	target    dbm.DB
+	batch     dbm.Batch
+	batchSize uint32
+	result    *MigrateResult
+}
+
+// copyVersion copies the nodes of a version which are not in the target yet, followed by its root.
+func (m *migration) copyVersion(version int64, rootHash []byte) error {
+	m.batch = m.target.NewBatch()
+	defer func() { m.batch.Close() }()
+	m.batchSize = 0
+
+	if len(rootHash) > 0 {
+		if err := m.copyNode(rootHash); err != nil {
+			return err
+		}
+	}
+	m.batch.Set(rootKeyFormat.Key(version), rootHash)
+	return m.batch.WriteSync()
+}
+
+// copyNode copies the subtree with the given hash, unless it is in the target already, in which
+// case the target's node must be identical to the source's. Subtrees are shared between versions
+// but never within one, so the nodes of the version being copied don't need to be readable before
+// it is done.
+func (m *migration) copyNode(hash []byte) error {
+	key := nodeKeyFormat.Key(hash)
+	existing, err := m.target.Get(key)
+	if err != nil {
+		return err
+	}
+	bz, err := m.source.Get(key)
+	if err != nil {
+		return err
+	}
+	if bz == nil {
+		return errors.Errorf("node %X is missing", hash)
+	}
+	if existing != nil {
+		if !bytes.Equal(existing, bz) {
+			return errors.Errorf("target has node %X which differs from the source", hash)
+		}
+		return nil
+	}
+	node, err := MakeNode(bz)
+	if err != nil {
+		return errors.Wrapf(err, "decoding node %X", hash)
+	}
+	if !bytes.Equal(node._hash(), hash) {
+		return errors.Errorf("node %X has hash %X", hash, node.hash)
+	}
+	if !node.isLeaf() {
+		if err = m.copyNode(node.leftHash); err != nil {
+			return err
+		}
+		if err = m.copyNode(node.rightHash); err != nil {
+			return err
+		}
+	}
+
+	m.batch.Set(key, bz)
+	m.result.Nodes++
+	m.batchSize++
+	if m.batchSize >= maxBatchSize {
+		if err = m.batch.Write(); err != nil {
+			return err
+		}
+		m.batch.Close()
+		m.batch = m.target.NewBatch()
+		m.batchSize = 0
+	}
+	return nil
+}
+
+// copyRecords copies the orphan records of nodes in the target, and all version metadata.
+func (m *migration) copyRecords() error {
+	m.batch = m.target.NewBatch()
+	defer func() { m.batch.Close() }()
+	m.batchSize = 0
+
+	var err error
+	set := func(k, v []byte) {
+		m.batch.Set(k, v)
+		m.batchSize++
+		if m.batchSize >= maxBatchSize {
+			if err = m.batch.Write(); err != nil {
+				return
+			}
+			m.batch.Close()
+			m.batch = m.target.NewBatch()
+			m.batchSize = 0
+		}
+	}
+	traversePrefixFromDB(m.source, orphanKeyFormat.Key(), func(k, v []byte) {
+		if err != nil {
+			return
+		}
+		var toVersion, fromVersion int64
+		var hash []byte
+		orphanKeyFormat.Scan(k, &toVersion, &fromVersion, &hash)
+		var ok bool
+		if ok, err = m.target.Has(nodeKeyFormat.Key(hash)); err != nil || !ok {
+			return
+		}
+		set(k, v)
+		m.result.Orphans++
+	})
+	traversePrefixFromDB(m.source, metadataKeyFormat.Key(), func(k, v []byte) {
+		if err != nil {
+			return
+		}
+		set(k, v)
+		m.result.Metadata++
+	})
+	if err != nil {
+		return err
+	}
+	return m.batch.WriteSync()
+}
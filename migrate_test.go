@@ -0,0 +1,121 @@
+package iavl
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	db "github.com/tendermint/tm-db"
+)
+
+func TestMigrate(t *testing.T) {
+	source, versions, stale := setupGCTree(t)
+	before := dumpDB(t, source)
+
+	target := db.NewMemDB()
+	result, err := Migrate(source, target)
+	require.NoError(t, err)
+	require.Equal(t, before, dumpDB(t, source))
+	require.Equal(t, len(versions), result.Versions)
+	require.Zero(t, result.Resumed)
+	require.EqualValues(t, countKeys(target, nodeKeyFormat.Key()), result.Nodes)
+	require.EqualValues(t, countKeys(target, orphanKeyFormat.Key()), result.Orphans)
+	require.EqualValues(t, countKeys(source, metadataKeyFormat.Key()), result.Metadata)
+
+	// Only the reachable nodes are copied, and orphans of missing nodes are left behind.
+	gc, err := GarbageCollect(source, GCOptions{DryRun: true})
+	require.NoError(t, err)
+	require.Equal(t, gc.Reachable, result.Nodes)
+	ok, err := target.Has(stale[0])
+	require.NoError(t, err)
+	require.False(t, ok)
+
+	tree, err := NewMutableTree(target, 0)
+	require.NoError(t, err)
+	_, err = tree.Load()
+	require.NoError(t, err)
+	for version, nodes := range versions {
+		itree, err := tree.GetImmutable(version)
+		require.NoError(t, err)
+		require.Equal(t, nodes, exportAll(t, itree.Export()))
+	}
+
+	// An interrupted migration is resumed, skipping the copied versions and subtrees.
+	migrated := dumpDB(t, target)
+	latest := tree.Version()
+	require.NoError(t, target.Delete(rootKeyFormat.Key(latest)))
+	require.NoError(t, target.Delete(nodeKeyFormat.Key(tree.root.hash)))
+	result, err = Migrate(source, target)
+	require.NoError(t, err)
+	require.Equal(t, len(versions)-1, result.Resumed)
+	require.EqualValues(t, 1, result.Nodes)
+	require.Equal(t, migrated, dumpDB(t, target))
+
+	// Versions can be saved and deleted in the target.
+	tree, err = NewMutableTree(target, 0)
+	require.NoError(t, err)
+	_, err = tree.Load()
+	require.NoError(t, err)
+	tree.Set([]byte("new"), []byte("value"))
+	_, _, err = tree.SaveVersion()
+	require.NoError(t, err)
+	require.NoError(t, tree.DeleteVersion(5))
+}
+
+func TestMigrate_Errors(t *testing.T) {
+	source, _, _ := setupGCTree(t)
+	tree, err := NewMutableTree(source, 0)
+	require.NoError(t, err)
+	_, err = tree.Load()
+	require.NoError(t, err)
+
+	// The target must not have roots which are not in the source.
+	target := db.NewMemDB()
+	require.NoError(t, target.Set(rootKeyFormat.Key(tree.Version()+1), []byte{}))
+	_, err = Migrate(source, target)
+	require.Error(t, err)
+
+	// A corrupt node fails the migration of its version, leaving it to be resumed.
+	key := nodeKeyFormat.Key(tree.root.leftHash)
+	bz, err := source.Get(key)
+	require.NoError(t, err)
+	corrupt := append([]byte{}, bz...)
+	corrupt[len(corrupt)-1] ^= 0xff
+	require.NoError(t, source.Set(key, corrupt))
+	target = db.NewMemDB()
+	_, err = Migrate(source, target)
+	require.Error(t, err)
+	ok, err := target.Has(rootKeyFormat.Key(tree.Version()))
+	require.NoError(t, err)
+	require.False(t, ok)
+
+	require.NoError(t, source.Set(key, bz))
+	_, err = Migrate(source, target)
+	require.NoError(t, err)
+
+	// Roots in the target must match the source's.
+	migrated := dumpDB(t, target)
+	rootKey := rootKeyFormat.Key(tree.Version())
+	require.NoError(t, target.Set(rootKey, tree.root.leftHash))
+	_, err = Migrate(source, target)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "but the source has root")
+
+	// When resuming, the topmost nodes of skipped subtrees must match the source's.
+	target = db.NewMemDB()
+	for k, v := range migrated {
+		require.NoError(t, target.Set([]byte(k), []byte(v)))
+	}
+	require.NoError(t, target.Delete(rootKey))
+	require.NoError(t, target.Delete(nodeKeyFormat.Key(tree.root.hash)))
+	require.NoError(t, target.Set(key, corrupt))
+	_, err = Migrate(source, target)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "differs from the source")
+
+	// Pending imports must be resumed or cleaned up first.
+	pending := db.NewMemDB()
+	require.NoError(t, pending.Set(importKeyFormat.Key(int64(1)), []byte{}))
+	_, err = Migrate(pending, db.NewMemDB())
+	require.Error(t, err)
+}
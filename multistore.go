@@ -0,0 +1,490 @@
+package iavl
+
+import (
+	"bytes"
+	"sort"
+
+	"github.com/pkg/errors"
+
+	dbm "github.com/tendermint/tm-db"
+
+	"github.com/tendermint/iavl/internal/encoding"
+)
+
+var (
+	// Commit infos of a MultiStore are indexed by version.
+	commitKeyFormat = NewKeyFormat('c', int64Size) // c<version>
+
+	// The trees of a MultiStore are stored under the prefix 's' followed by the length-prefixed
+	// store name, so that no store prefix is a prefix of another.
+	storeKeyPrefix = byte('s')
+)
+
+// StoreInfo is the name and root hash of a store in a CommitInfo.
+type StoreInfo struct {
+	Name string
+	Hash []byte
+}
+
+// CommitInfo is the version committed by a MultiStore, with the root hash of each store.
+type CommitInfo struct {
+	Version int64
+	Stores  []StoreInfo // sorted by name
+}
+
+// Hash returns the combined root hash of the stores, i.e. the root of the simple Merkle tree of
+// store names and root hashes built by SimpleHashFromMap().
+func (ci *CommitInfo) Hash() []byte {
+	return SimpleHashFromMap(ci.storeRoots())
+}
+
+// storeRoots returns the root hashes of the stores by name.
+func (ci *CommitInfo) storeRoots() map[string][]byte {
+	roots := make(map[string][]byte, len(ci.Stores))
+	for _, store := range ci.Stores {
+		roots[store.Name] = store.Hash
+	}
+	return roots
+}
+
+// storeHash returns the root hash of the store, and whether the store is in the commit.
+func (ci *CommitInfo) storeHash(name string) ([]byte, bool) {
+	for _, store := range ci.Stores {
+		if store.Name == name {
+			return store.Hash, true
+		}
+	}
+	return nil, false
+}
+
+// marshal encodes the commit info as its version followed by the number of stores and the
+// length-prefixed name and hash of each.
+func (ci *CommitInfo) marshal() ([]byte, error) {
+	var buf bytes.Buffer
+	if err := encoding.EncodeVarint(&buf, ci.Version); err != nil {
+		return nil, err
+	}
+	if err := encoding.EncodeUvarint(&buf, uint64(len(ci.Stores))); err != nil {
+		return nil, err
+	}
+	for _, store := range ci.Stores {
+		if err := encoding.EncodeByteSlice(&buf, []byte(store.Name)); err != nil {
+			return nil, err
+		}
+		if err := encoding.EncodeByteSlice(&buf, store.Hash); err != nil {
+			return nil, err
+		}
+	}
+	return buf.Bytes(), nil
+}
+
+// unmarshalCommitInfo decodes a commit info encoded by CommitInfo.marshal().
+func unmarshalCommitInfo(bz []byte) (*CommitInfo, error) {
+	version, n, err := encoding.DecodeVarint(bz)
+	if err != nil {
+		return nil, errors.Wrap(err, "decoding version")
+	}
+	bz = bz[n:]
+	count, n, err := encoding.DecodeUvarint(bz)
+	if err != nil {
+		return nil, errors.Wrap(err, "decoding store count")
+	}
+	bz = bz[n:]
+	if count > uint64(len(bz)) {
+		return nil, errors.Errorf("invalid store count %v", count)
+	}
+
+	ci := &CommitInfo{Version: version, Stores: make([]StoreInfo, 0, count)}
+	for i := uint64(0); i < count; i++ {
+		name, n, err := encoding.DecodeByteSlice(bz)
+		if err != nil {
+			return nil, errors.Wrapf(err, "decoding name of store %v", i)
+		}
+		bz = bz[n:]
+		hash, n, err := encoding.DecodeByteSlice(bz)
+		if err != nil {
+			return nil, errors.Wrapf(err, "decoding hash of store %q", name)
+		}
+		bz = bz[n:]
+		ci.Stores = append(ci.Stores, StoreInfo{Name: string(name), Hash: hash})
+	}
+	if len(bz) > 0 {
+		return nil, errors.Errorf("unexpected %v trailing bytes", len(bz))
+	}
+	return ci, nil
+}
+
+// MultiStore holds multiple named trees, called stores, in a single database under distinct key
+// prefixes, and commits them atomically: the writes of all stores are made in a single batch,
+// along with a CommitInfo of the store root hashes. A crash can therefore never leave stores at
+// different versions. The combined root hash of a version is the root of a simple Merkle tree of
+// the store roots, and values can be proven against it with GetVersionedWithProof().
+//
+// All stores are saved at every version, as the version of the MultiStore. Stores can be added
+// at any time, and are empty until the next commit. The trees returned by Store() must only be
+// modified with Set() and Remove(): all their writes are deferred to the next commit, so versions
+// must be saved, deleted and loaded through the MultiStore, and imports are not supported.
+//
+// MultiStore is not concurrency-safe.
+type MultiStore struct {
+	db     dbm.DB
+	batch  dbm.Batch // shared batch of all stores, written by Commit() and DeleteVersion()
+	names  []string  // sorted
+	stores map[string]*MutableTree
+	info   *CommitInfo // latest commit, nil if none
+}
+
+// NewMultiStore returns a MultiStore of the named stores in the database, each with the given
+// node cache size. Call Load() to load the latest version.
+func NewMultiStore(db dbm.DB, names []string, cacheSize int) (*MultiStore, error) {
+	ms := &MultiStore{
+		db:     db,
+		batch:  db.NewBatch(),
+		stores: make(map[string]*MutableTree, len(names)),
+	}
+	for _, name := range names {
+		if name == "" {
+			return nil, errors.New("store name cannot be empty")
+		}
+		if _, ok := ms.stores[name]; ok {
+			return nil, errors.Errorf("duplicate store %q", name)
+		}
+		tree, err := NewMutableTree(newStoreDB(ms, name), cacheSize)
+		if err != nil {
+			return nil, err
+		}
+		ms.stores[name] = tree
+		ms.names = append(ms.names, name)
+	}
+	sort.Strings(ms.names)
+	return ms, nil
+}
+
+// Load loads the latest committed version of all stores, and returns it.
+func (ms *MultiStore) Load() (int64, error) {
+	itr, err := ms.db.ReverseIterator(commitKeyFormat.Key(), cpIncr(commitKeyFormat.Key()))
+	if err != nil {
+		return 0, err
+	}
+	var bz []byte
+	if itr.Valid() {
+		bz = append([]byte{}, itr.Value()...)
+	}
+	err = itr.Error()
+	itr.Close()
+	if err != nil {
+		return 0, err
+	}
+
+	var info *CommitInfo
+	if bz != nil {
+		if info, err = unmarshalCommitInfo(bz); err != nil {
+			return 0, errors.Wrap(err, "decoding latest commit info")
+		}
+	}
+	if err = ms.loadStores(info); err != nil {
+		return 0, err
+	}
+	ms.info = info
+	return ms.Version(), nil
+}
+
+// loadStores loads the stores at the version of the commit info, or the initial version if nil.
+// Stores which are not in the commit are empty, and are set to its version.
+func (ms *MultiStore) loadStores(info *CommitInfo) error {
+	version := int64(0)
+	if info != nil {
+		version = info.Version
+	}
+	for _, name := range ms.names {
+		tree := ms.stores[name]
+		var hash []byte
+		var ok bool
+		if info != nil {
+			hash, ok = info.storeHash(name)
+		}
+		if !ok {
+			// Load() leaves the working tree as is if there are no versions, so it is replaced by
+			// an empty tree, whose new nodes get the next version.
+			loaded, err := tree.Load()
+			if err != nil {
+				return errors.Wrapf(err, "loading store %q", name)
+			}
+			if loaded != 0 {
+				return errors.Errorf("store %q has version %v, but is not in the commit of version %v",
+					name, loaded, version)
+			}
+			tree.ImmutableTree = &ImmutableTree{ndb: tree.ndb, version: version}
+			tree.lastSaved = tree.ImmutableTree.clone()
+			tree.orphans = map[string]int64{}
+			continue
+		}
+
+		if _, err := tree.LoadVersion(version); err != nil {
+			return errors.Wrapf(err, "loading store %q", name)
+		}
+		if !bytes.Equal(tree.Hash(), hash) {
+			return errors.Errorf("store %q has root hash %X at version %v, expected %X",
+				name, tree.Hash(), version, hash)
+		}
+	}
+	return nil
+}
+
+// Store returns the tree of the named store, or nil if there is no such store.
+func (ms *MultiStore) Store(name string) *MutableTree {
+	return ms.stores[name]
+}
+
+// Version returns the latest committed version, or 0 if none.
+func (ms *MultiStore) Version() int64 {
+	if ms.info == nil {
+		return 0
+	}
+	return ms.info.Version
+}
+
+// Hash returns the combined root hash of the latest committed version, or nil if none.
+func (ms *MultiStore) Hash() []byte {
+	if ms.info == nil {
+		return nil
+	}
+	return ms.info.Hash()
+}
+
+// LastCommitInfo returns the commit info of the latest committed version, or nil if none.
+func (ms *MultiStore) LastCommitInfo() *CommitInfo {
+	return ms.info
+}
+
+// GetCommitInfo returns the commit info of a version, or ErrVersionDoesNotExist.
+func (ms *MultiStore) GetCommitInfo(version int64) (*CommitInfo, error) {
+	bz, err := ms.db.Get(commitKeyFormat.Key(version))
+	if err != nil {
+		return nil, err
+	}
+	if bz == nil {
+		return nil, errors.Wrapf(ErrVersionDoesNotExist, "version %v", version)
+	}
+	info, err := unmarshalCommitInfo(bz)
+	if err != nil {
+		return nil, errors.Wrapf(err, "decoding commit info of version %v", version)
+	}
+	return info, nil
+}
+
+// Commit saves a new version of all stores in a single atomic write, and returns its commit
+// info. If it fails, the stores are reloaded at the latest committed version, discarding their
+// pending changes.
+func (ms *MultiStore) Commit() (*CommitInfo, error) {
+	info := &CommitInfo{Version: ms.Version() + 1, Stores: make([]StoreInfo, 0, len(ms.names))}
+	for _, name := range ms.names {
+		hash, _, err := ms.stores[name].saveVersion(info.Version)
+		if err != nil {
+			return nil, ms.rollback(errors.Wrapf(err, "saving store %q", name))
+		}
+		info.Stores = append(info.Stores, StoreInfo{Name: name, Hash: hash})
+	}
+	bz, err := info.marshal()
+	if err != nil {
+		return nil, ms.rollback(err)
+	}
+	ms.batch.Set(commitKeyFormat.Key(info.Version), bz)
+	if err = ms.write(); err != nil {
+		return nil, ms.rollback(err)
+	}
+	ms.info = info
+	return info, nil
+}
+
+// DeleteVersion deletes a version of all stores in a single atomic write. The latest version
+// can't be deleted, and all stores of the version must be in the MultiStore. If it fails, the
+// stores are reloaded at the latest committed version, discarding their pending changes.
+func (ms *MultiStore) DeleteVersion(version int64) error {
+	if version == ms.Version() {
+		return errors.Errorf("cannot delete latest saved version (%d)", version)
+	}
+	info, err := ms.GetCommitInfo(version)
+	if err != nil {
+		return err
+	}
+	for _, store := range info.Stores {
+		if ms.stores[store.Name] == nil {
+			return errors.Errorf("store %q of version %v is not in the multistore", store.Name, version)
+		}
+	}
+	for _, store := range info.Stores {
+		if err = ms.stores[store.Name].DeleteVersion(version); err != nil {
+			return ms.rollback(errors.Wrapf(err, "deleting version %v of store %q", version, store.Name))
+		}
+	}
+	ms.batch.Delete(commitKeyFormat.Key(version))
+	if err = ms.write(); err != nil {
+		return ms.rollback(err)
+	}
+	return nil
+}
+
+// write writes the shared batch, and replaces it with a new one.
+func (ms *MultiStore) write() error {
+	if err := ms.batch.WriteSync(); err != nil {
+		return err
+	}
+	ms.batch.Close()
+	ms.batch = ms.db.NewBatch()
+	return nil
+}
+
+// rollback discards the pending writes of the stores, reloads them at the latest committed
+// version, and returns the error which caused it.
+func (ms *MultiStore) rollback(cause error) error {
+	ms.batch.Close()
+	ms.batch = ms.db.NewBatch()
+	for _, name := range ms.names {
+		tree := ms.stores[name]
+		for version := range tree.versions {
+			if version > ms.Version() {
+				delete(tree.versions, version)
+			}
+		}
+		tree.ndb.resetLatestVersion(0)
+		tree.ndb.resetCache()
+	}
+	if err := ms.loadStores(ms.info); err != nil {
+		return errors.Wrapf(err, "reloading stores after error: %v", cause)
+	}
+	return cause
+}
+
+// GetWithProof returns the value of the key in the store at the latest version, along with a
+// proof against the combined root hash. See GetVersionedWithProof().
+func (ms *MultiStore) GetWithProof(store string, key []byte) ([]byte, *Proof, error) {
+	return ms.GetVersionedWithProof(store, key, ms.Version())
+}
+
+// GetVersionedWithProof returns the value of the key in the store at the given version, or nil
+// if absent, along with a proof against the combined root hash of the version. The proof chains
+// an IAVL value or absence operator with a simple Merkle value operator for the store, and can be
+// verified with DefaultProofRuntime() using MultiStoreKeyPath().
+func (ms *MultiStore) GetVersionedWithProof(store string, key []byte, version int64) ([]byte, *Proof, error) {
+	tree := ms.stores[store]
+	if tree == nil {
+		return nil, nil, errors.Errorf("unknown store %q", store)
+	}
+	info, err := ms.GetCommitInfo(version)
+	if err != nil {
+		return nil, nil, err
+	}
+	if _, ok := info.storeHash(store); !ok {
+		return nil, nil, errors.Errorf("store %q is not in version %v", store, version)
+	}
+
+	value, rangeProof, err := tree.GetVersionedWithProof(key, version)
+	if err != nil {
+		return nil, nil, err
+	}
+	var valueOp ProofOp
+	if value != nil {
+		valueOp = NewValueOpWithEncoding(key, rangeProof, ProofOpEncodingProto).ProofOp()
+	} else {
+		valueOp = NewAbsenceOpWithEncoding(key, rangeProof, ProofOpEncodingProto).ProofOp()
+	}
+	_, storeProofs, _ := SimpleProofsFromMap(info.storeRoots())
+	storeOp := NewSimpleValueOp([]byte(store), storeProofs[store]).ProofOp()
+	return value, &Proof{Ops: []*ProofOp{&valueOp, &storeOp}}, nil
+}
+
+// MultiStoreKeyPath returns the key path of a key in a store, for verifying proofs returned by
+// MultiStore.GetVersionedWithProof().
+func MultiStoreKeyPath(store string, key []byte) string {
+	return KeyPath{}.AppendKey([]byte(store), KeyEncodingURL).AppendKey(key, KeyEncodingHex).String()
+}
+
+// storeDB is the database of a store: a prefixed view of the MultiStore database, whose writes
+// are added to the shared batch of the MultiStore. Reads only see committed writes, except for
+// version metadata, which the nodeDB caches on write.
+type storeDB struct {
+	*dbm.PrefixDB
+	ms     *MultiStore
+	prefix []byte
+}
+
+var _ dbm.DB = (*storeDB)(nil)
+
+func newStoreDB(ms *MultiStore, name string) *storeDB {
+	var buf bytes.Buffer
+	buf.WriteByte(storeKeyPrefix)
+	if err := encoding.EncodeByteSlice(&buf, []byte(name)); err != nil {
+		panic(err)
+	}
+	prefix := buf.Bytes()
+	return &storeDB{
+		PrefixDB: dbm.NewPrefixDB(ms.db, prefix),
+		ms:       ms,
+		prefix:   prefix,
+	}
+}
+
+// Set implements dbm.DB.
+func (db *storeDB) Set(key, value []byte) error {
+	db.ms.batch.Set(db.key(key), value)
+	return nil
+}
+
+// SetSync implements dbm.DB.
+func (db *storeDB) SetSync(key, value []byte) error {
+	return db.Set(key, value)
+}
+
+// Delete implements dbm.DB.
+func (db *storeDB) Delete(key []byte) error {
+	db.ms.batch.Delete(db.key(key))
+	return nil
+}
+
+// DeleteSync implements dbm.DB.
+func (db *storeDB) DeleteSync(key []byte) error {
+	return db.Delete(key)
+}
+
+// NewBatch implements dbm.DB.
+func (db *storeDB) NewBatch() dbm.Batch {
+	return storeBatch{db: db}
+}
+
+// key returns the prefixed key.
+func (db *storeDB) key(key []byte) []byte {
+	pkey := make([]byte, 0, len(db.prefix)+len(key))
+	return append(append(pkey, db.prefix...), key...)
+}
+
+// storeBatch is a batch of a storeDB. Its writes are added to the shared batch of the
+// MultiStore, which writes it, so writing and closing it does nothing.
+type storeBatch struct {
+	db *storeDB
+}
+
+var _ dbm.Batch = storeBatch{}
+
+// Set implements dbm.Batch.
+func (b storeBatch) Set(key, value []byte) {
+	_ = b.db.Set(key, value)
+}
+
+// Delete implements dbm.Batch.
+func (b storeBatch) Delete(key []byte) {
+	_ = b.db.Delete(key)
+}
+
+// Write implements dbm.Batch.
+func (b storeBatch) Write() error {
+	return nil
+}
+
+// WriteSync implements dbm.Batch.
+func (b storeBatch) WriteSync() error {
+	return nil
+}
+
+// Close implements dbm.Batch.
+func (b storeBatch) Close() {}
@@ -0,0 +1,220 @@
+package iavl
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+
+	db "github.com/tendermint/tm-db"
+)
+
+// failingDB is a database whose batches fail to write while fail is set.
+type failingDB struct {
+	db.DB
+	fail bool
+}
+
+func (f *failingDB) NewBatch() db.Batch {
+	return failingBatch{Batch: f.DB.NewBatch(), db: f}
+}
+
+type failingBatch struct {
+	db.Batch
+	db *failingDB
+}
+
+func (b failingBatch) WriteSync() error {
+	if b.db.fail {
+		return errors.New("write failed")
+	}
+	return b.Batch.WriteSync()
+}
+
+// setupMultiStore returns a loaded multistore of the named stores.
+func setupMultiStore(t *testing.T, memDB db.DB, names ...string) *MultiStore {
+	ms, err := NewMultiStore(memDB, names, 1000)
+	require.NoError(t, err)
+	_, err = ms.Load()
+	require.NoError(t, err)
+	return ms
+}
+
+// setMultiStore sets keys in all stores of the multistore, with values depending on the round.
+func setMultiStore(ms *MultiStore, round int) {
+	for _, name := range ms.names {
+		for i := round; i < 50; i += 3 {
+			ms.Store(name).Set([]byte(fmt.Sprintf("key-%03d", i)), []byte(fmt.Sprintf("%s-%d-%d", name, i, round)))
+		}
+	}
+}
+
+func TestMultiStore(t *testing.T) {
+	memDB := db.NewMemDB()
+	ms := setupMultiStore(t, memDB, "bank", "acc", "gov")
+	require.Zero(t, ms.Version())
+	require.Nil(t, ms.Hash())
+
+	hashes := map[int64][]byte{}
+	for round := 1; round <= 3; round++ {
+		setMultiStore(ms, round)
+		info, err := ms.Commit()
+		require.NoError(t, err)
+		require.EqualValues(t, round, info.Version)
+		require.Equal(t, info, ms.LastCommitInfo())
+		require.Equal(t, []string{"acc", "bank", "gov"}, []string{info.Stores[0].Name, info.Stores[1].Name, info.Stores[2].Name})
+		for _, store := range info.Stores {
+			require.Equal(t, ms.Store(store.Name).Hash(), store.Hash)
+			require.EqualValues(t, round, ms.Store(store.Name).Version())
+		}
+		hashes[info.Version] = info.Hash()
+	}
+	require.Equal(t, hashes[3], ms.Hash())
+
+	// Values and absences are proven against the combined root hash of their version.
+	prt := DefaultProofRuntime()
+	value, proof, err := ms.GetWithProof("bank", []byte("key-004"))
+	require.NoError(t, err)
+	require.Equal(t, []byte("bank-4-1"), value)
+	require.NoError(t, prt.VerifyValue(proof, hashes[3], MultiStoreKeyPath("bank", []byte("key-004")), value))
+	require.Error(t, prt.VerifyValue(proof, hashes[3], MultiStoreKeyPath("acc", []byte("key-004")), value))
+	require.Error(t, prt.VerifyValue(proof, hashes[2], MultiStoreKeyPath("bank", []byte("key-004")), value))
+	require.Error(t, prt.VerifyValue(proof, hashes[3], MultiStoreKeyPath("bank", []byte("key-004")), []byte("foo")))
+
+	value, proof, err = ms.GetVersionedWithProof("gov", []byte("key-003"), 2)
+	require.NoError(t, err)
+	require.Equal(t, []byte("gov-3-3"), getStoreValue(ms, "gov", "key-003"))
+	require.Nil(t, value)
+	require.NoError(t, prt.VerifyAbsence(proof, hashes[2], MultiStoreKeyPath("gov", []byte("key-003"))))
+
+	_, _, err = ms.GetWithProof("foo", []byte("key-003"))
+	require.Error(t, err)
+	_, _, err = ms.GetVersionedWithProof("gov", []byte("key-003"), 4)
+	require.Error(t, err)
+
+	// A reopened multistore loads the latest version, and can add stores.
+	ms = setupMultiStore(t, memDB, "acc", "bank", "gov", "new")
+	require.EqualValues(t, 3, ms.Version())
+	require.Equal(t, hashes[3], ms.Hash())
+	require.Equal(t, []byte("acc-7-1"), getStoreValue(ms, "acc", "key-007"))
+	require.Zero(t, ms.Store("new").Size())
+	ms.Store("new").Set([]byte("a"), []byte{1})
+	info, err := ms.Commit()
+	require.NoError(t, err)
+	require.EqualValues(t, 4, info.Version)
+	require.Len(t, info.Stores, 4)
+	require.Equal(t, []int{4}, ms.Store("new").AvailableVersions())
+	value, proof, err = ms.GetWithProof("new", []byte("a"))
+	require.NoError(t, err)
+	require.NoError(t, prt.VerifyValue(proof, info.Hash(), MultiStoreKeyPath("new", []byte("a")), value))
+	_, _, err = ms.GetVersionedWithProof("new", []byte("a"), 3)
+	require.Error(t, err)
+
+	// Versions are deleted from all stores.
+	require.Error(t, ms.DeleteVersion(4))
+	require.NoError(t, ms.DeleteVersion(1))
+	_, err = ms.GetCommitInfo(1)
+	require.True(t, errors.Is(err, ErrVersionDoesNotExist))
+	for _, name := range []string{"acc", "bank", "gov"} {
+		require.Equal(t, []int{2, 3, 4}, ms.Store(name).AvailableVersions())
+	}
+	info, err = ms.GetCommitInfo(2)
+	require.NoError(t, err)
+	require.Equal(t, hashes[2], info.Hash())
+
+	ms = setupMultiStore(t, memDB, "acc", "bank", "gov", "new")
+	require.EqualValues(t, 4, ms.Version())
+	require.Equal(t, []byte{1}, getStoreValue(ms, "new", "a"))
+
+	// A store which is left out of the latest version can't be loaded.
+	ms = setupMultiStore(t, memDB, "acc")
+	_, err = ms.Commit()
+	require.NoError(t, err)
+	ms, err = NewMultiStore(memDB, []string{"acc", "bank"}, 0)
+	require.NoError(t, err)
+	_, err = ms.Load()
+	require.Error(t, err)
+}
+
+func TestMultiStore_Atomic(t *testing.T) {
+	failing := &failingDB{DB: db.NewMemDB()}
+	ms := setupMultiStore(t, failing, "acc", "bank")
+	setMultiStore(ms, 1)
+	committed, err := ms.Commit()
+	require.NoError(t, err)
+
+	// A failed commit writes nothing, and reloads the stores at the last commit.
+	before := dumpDB(t, failing)
+	failing.fail = true
+	setMultiStore(ms, 2)
+	ms.Store("acc").Remove([]byte("key-001"))
+	_, err = ms.Commit()
+	require.Error(t, err)
+	require.Equal(t, before, dumpDB(t, failing))
+	require.EqualValues(t, 1, ms.Version())
+	require.Equal(t, []byte("acc-1-1"), getStoreValue(ms, "acc", "key-001"))
+	for _, store := range committed.Stores {
+		require.Equal(t, store.Hash, ms.Store(store.Name).Hash())
+		require.Equal(t, []int{1}, ms.Store(store.Name).AvailableVersions())
+	}
+
+	// The same changes can be committed once writes succeed, and all their nodes are written.
+	failing.fail = false
+	setMultiStore(ms, 2)
+	ms.Store("acc").Remove([]byte("key-001"))
+	info, err := ms.Commit()
+	require.NoError(t, err)
+	require.EqualValues(t, 2, info.Version)
+
+	ms = setupMultiStore(t, failing, "acc", "bank")
+	require.Equal(t, info.Hash(), ms.Hash())
+	for _, name := range []string{"acc", "bank"} {
+		count := 0
+		ms.Store(name).Iterate(func(key, value []byte) bool {
+			count++
+			return false
+		})
+		require.EqualValues(t, ms.Store(name).Size(), count)
+	}
+	require.Nil(t, getStoreValue(ms, "acc", "key-001"))
+
+	// A failed deletion deletes nothing.
+	setMultiStore(ms, 3)
+	_, err = ms.Commit()
+	require.NoError(t, err)
+	before = dumpDB(t, failing)
+	failing.fail = true
+	require.Error(t, ms.DeleteVersion(1))
+	require.Equal(t, before, dumpDB(t, failing))
+	require.Equal(t, []int{1, 2, 3}, ms.Store("acc").AvailableVersions())
+	failing.fail = false
+	require.NoError(t, ms.DeleteVersion(1))
+	require.Equal(t, []int{2, 3}, ms.Store("acc").AvailableVersions())
+}
+
+func TestMultiStore_Invalid(t *testing.T) {
+	_, err := NewMultiStore(db.NewMemDB(), []string{"a", ""}, 0)
+	require.Error(t, err)
+	_, err = NewMultiStore(db.NewMemDB(), []string{"a", "b", "a"}, 0)
+	require.Error(t, err)
+
+	info := &CommitInfo{Version: 7, Stores: []StoreInfo{{Name: "a", Hash: []byte{1, 2}}, {Name: "b"}}}
+	bz, err := info.marshal()
+	require.NoError(t, err)
+	decoded, err := unmarshalCommitInfo(bz)
+	require.NoError(t, err)
+	require.Equal(t, info.Hash(), decoded.Hash())
+	for _, size := range []int{0, 1, len(bz) - 1} {
+		_, err = unmarshalCommitInfo(bz[:size])
+		require.Error(t, err, "truncated to %v bytes", size)
+	}
+	_, err = unmarshalCommitInfo(append(bz, 0))
+	require.Error(t, err)
+}
+
+// getStoreValue returns the value of the key in the working tree of the store.
+func getStoreValue(ms *MultiStore, store, key string) []byte {
+	_, value := ms.Store(store).Get([]byte(key))
+	return value
+}
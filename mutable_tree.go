@@ -2,6 +2,7 @@ package iavl
 
 import (
 	"bytes"
+	"context"
 	"fmt"
 	"sort"
 	"time"
@@ -154,7 +155,33 @@ func (tree *MutableTree) Set(key, value []byte) bool {
 // Import can only be called on an empty tree. It is the callers responsibility that no other
 // modifications are made to the tree while importing.
 func (tree *MutableTree) Import(version int64) (*Importer, error) {
-	return newImporter(tree, version)
+	return newImporter(context.Background(), tree, version, ImportOptions{})
+}
+
+// ImportCtx is like Import, but the returned importer stops accepting nodes once the context is
+// cancelled or its deadline expires. Nodes that were already flushed are not made visible.
+func (tree *MutableTree) ImportCtx(ctx context.Context, version int64) (*Importer, error) {
+	return newImporter(ctx, tree, version, ImportOptions{})
+}
+
+// ImportWithOptions is like Import, but with the given options. In particular, an expected root
+// hash makes the import safe for untrusted data, and AboveLatestVersion imports into a non-empty
+// database as a new version, see ImportOptions.
+func (tree *MutableTree) ImportWithOptions(version int64, opts ImportOptions) (*Importer, error) {
+	return newImporter(context.Background(), tree, version, opts)
+}
+
+// ResumeImport returns an importer which continues an aborted import of the given version from
+// the last checkpoint written when flushing nodes. Importer.Progress() gives the number of nodes
+// that were already imported, which must be skipped when adding the remaining nodes.
+func (tree *MutableTree) ResumeImport(version int64) (*Importer, error) {
+	return resumeImporter(context.Background(), tree, version)
+}
+
+// ResumeImportCtx is like ResumeImport, but the returned importer stops accepting nodes once the
+// context is cancelled or its deadline expires.
+func (tree *MutableTree) ResumeImportCtx(ctx context.Context, version int64) (*Importer, error) {
+	return resumeImporter(ctx, tree, version)
 }
 
 func (tree *MutableTree) set(key []byte, value []byte) (orphans []*Node, updated bool) {
@@ -405,12 +432,32 @@ func (tree *MutableTree) LoadVersion(targetVersion int64) (int64, error) {
 // version. Any versions greater than targetVersion will be deleted along with
 // their respective metadata.
 func (tree *MutableTree) LoadVersionForOverwriting(targetVersion int64) (int64, error) {
+	return tree.LoadVersionForOverwritingCtx(context.Background(), targetVersion)
+}
+
+// LoadVersionForOverwritingCtx is like LoadVersionForOverwriting, but stops deleting versions
+// when the context is cancelled or its deadline expires. In that case no versions are deleted,
+// the tree is left as it was before the call, and the context error is returned.
+func (tree *MutableTree) LoadVersionForOverwritingCtx(ctx context.Context, targetVersion int64) (int64, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	// Keep the current state around, so that we can restore it if the deletion is aborted.
+	prevTree, prevLastSaved, prevOrphans := tree.ImmutableTree, tree.lastSaved, tree.orphans
+	prevVersions := make(map[int64]bool, len(tree.versions))
+	for v, ok := range tree.versions {
+		prevVersions[v] = ok
+	}
+
 	latestVersion, err := tree.LoadVersion(targetVersion)
 	if err != nil {
 		return latestVersion, err
 	}
 
-	if err := tree.deleteVersionsFrom(targetVersion + 1); err != nil {
+	if err := tree.deleteVersionsFrom(ctx, targetVersion+1); err != nil {
+		tree.ImmutableTree, tree.lastSaved, tree.orphans = prevTree, prevLastSaved, prevOrphans
+		tree.versions = prevVersions
 		return latestVersion, err
 	}
 
@@ -484,7 +531,6 @@ func (tree *MutableTree) FlushVersion(version int64) error {
 		return nil
 	}
 
-	debug("FLUSHING VERSION: %d\n", version)
 	err = tree.ndb.flushVersion(version)
 	if err != nil {
 		return err
@@ -509,6 +555,7 @@ func (tree *MutableTree) FlushVersion(version int64) error {
 	}
 
 	tree.versions[version] = true
+	tree.ndb.logger.Info("flushed version", "version", version, "hash", rootHash)
 	return nil
 }
 
@@ -516,7 +563,12 @@ func (tree *MutableTree) FlushVersion(version int64) error {
 // based on the current state of the tree. Returns the hash and new version number.
 // If version is snapshot version, persist version to disk as well
 func (tree *MutableTree) SaveVersion() ([]byte, int64, error) {
-	version := tree.version + 1
+	return tree.saveVersion(tree.version + 1)
+}
+
+// saveVersion saves the working tree as the given version, which must be greater than the
+// latest version. It is used by SaveVersion() and for delta imports, which may skip versions.
+func (tree *MutableTree) saveVersion(version int64) ([]byte, int64, error) {
 	vm := &VersionMetadata{
 		Version:  version,
 		Snapshot: tree.ndb.opts.KeepEvery != 0 && version%tree.ndb.opts.KeepEvery == 0,
@@ -548,7 +600,6 @@ func (tree *MutableTree) SaveVersion() ([]byte, int64, error) {
 	if tree.root == nil {
 		// There can still be orphans, for example if the root is the node being
 		// removed.
-		debug("SAVE EMPTY TREE %v\n", version)
 		if err := tree.ndb.SaveOrphans(version, tree.orphans); err != nil {
 			panic(err)
 		}
@@ -557,8 +608,6 @@ func (tree *MutableTree) SaveVersion() ([]byte, int64, error) {
 			panic(err)
 		}
 	} else {
-		debug("SAVE TREE %v\n", version)
-
 		if _, err := tree.ndb.SaveTree(tree.root, version); err != nil {
 			panic(err)
 		}
@@ -582,6 +631,7 @@ func (tree *MutableTree) SaveVersion() ([]byte, int64, error) {
 
 	tree.version = version
 	tree.versions[version] = true
+	orphans := len(tree.orphans)
 
 	// set new working tree
 	tree.ImmutableTree = tree.ImmutableTree.clone()
@@ -597,6 +647,8 @@ func (tree *MutableTree) SaveVersion() ([]byte, int64, error) {
 		return nil, version, err
 	}
 
+	tree.ndb.logger.Info("saved version", "version", version, "hash", vm.RootHash,
+		"size", tree.Size(), "orphans", orphans, "snapshot", vm.Snapshot)
 	return tree.Hash(), version, nil
 }
 
@@ -620,13 +672,16 @@ func (tree *MutableTree) pruneRecentVersion() error {
 		}
 
 		delete(tree.versions, prunedVersion)
-		return tree.ndb.Commit()
+		if err := tree.ndb.Commit(); err != nil {
+			return err
+		}
+		tree.ndb.logger.Debug("pruned recent version", "version", prunedVersion)
 	}
 
 	return nil
 }
 
-func (tree *MutableTree) deleteVersion(version int64) error {
+func (tree *MutableTree) deleteVersion(ctx context.Context, version int64) error {
 	if version == 0 {
 		return errors.New("version must be greater than 0")
 	}
@@ -637,7 +692,7 @@ func (tree *MutableTree) deleteVersion(version int64) error {
 		return errors.Wrap(ErrVersionDoesNotExist, "")
 	}
 
-	if err := tree.ndb.DeleteVersion(version, true); err != nil {
+	if err := tree.ndb.DeleteVersionCtx(ctx, version, true); err != nil {
 		return err
 	}
 
@@ -648,12 +703,27 @@ func (tree *MutableTree) deleteVersion(version int64) error {
 // is returned if any single version is invalid or the delete fails. All writes
 // happen in a single batch with a single commit.
 func (tree *MutableTree) DeleteVersions(versions ...int64) error {
-	debug("DELETING VERSIONS: %v\n", versions)
+	return tree.DeleteVersionsCtx(context.Background(), versions...)
+}
 
+// DeleteVersionsCtx is like DeleteVersions, but stops when the context is cancelled or its
+// deadline expires. Since all writes happen in a single batch, either all versions are
+// deleted or none are: if the context is cancelled, the pending writes are discarded and the
+// context error is returned.
+func (tree *MutableTree) DeleteVersionsCtx(ctx context.Context, versions ...int64) error {
 	for _, version := range versions {
-		if err := tree.deleteVersion(version); err != nil {
+		if err := ctx.Err(); err != nil {
+			tree.ndb.resetBatch()
 			return err
 		}
+		if err := tree.deleteVersion(ctx, version); err != nil {
+			tree.ndb.resetBatch()
+			return err
+		}
+	}
+	if err := ctx.Err(); err != nil {
+		tree.ndb.resetBatch()
+		return err
 	}
 
 	if err := tree.ndb.Commit(); err != nil {
@@ -664,6 +734,7 @@ func (tree *MutableTree) DeleteVersions(versions ...int64) error {
 		delete(tree.versions, version)
 	}
 
+	tree.ndb.logger.Info("deleted versions", "versions", versions)
 	return nil
 }
 
@@ -671,14 +742,12 @@ func (tree *MutableTree) DeleteVersions(versions ...int64) error {
 // longer be accessed. Note, the version's metadata will still be retained. In
 // addition, it will contain the time at which the version was deleted.
 func (tree *MutableTree) DeleteVersion(version int64) error {
-	debug("DELETE VERSION: %d\n", version)
-
 	vm, err := tree.ndb.GetVersionMetadata(version)
 	if err != nil {
 		return err
 	}
 
-	if err := tree.deleteVersion(version); err != nil {
+	if err := tree.deleteVersion(context.Background(), version); err != nil {
 		return err
 	}
 
@@ -694,13 +763,15 @@ func (tree *MutableTree) DeleteVersion(version int64) error {
 	}
 
 	delete(tree.versions, version)
+	tree.ndb.logger.Info("deleted version", "version", version)
 	return nil
 }
 
 // deleteVersionsFrom deletes tree version from disk specified version to latest
 // version along with each version's metadata. The version can then no longer be
-// accessed.
-func (tree *MutableTree) deleteVersionsFrom(version int64) error {
+// accessed. If the context is cancelled, pending deletes are discarded and the
+// context error is returned.
+func (tree *MutableTree) deleteVersionsFrom(ctx context.Context, version int64) error {
 	if version <= 0 {
 		return errors.New("version must be greater than 0")
 	}
@@ -708,57 +779,77 @@ func (tree *MutableTree) deleteVersionsFrom(version int64) error {
 	newLatestVersion := version - 1
 	lastestVersion := tree.ndb.getLatestVersion()
 
-	for ; version <= lastestVersion; version++ {
-		if version == tree.version {
-			return errors.Errorf("cannot delete latest saved version (%d)", version)
+	for v := version; v <= lastestVersion; v++ {
+		if err := ctx.Err(); err != nil {
+			tree.ndb.resetBatch()
+			return err
+		}
+
+		if v == tree.version {
+			tree.ndb.resetBatch()
+			return errors.Errorf("cannot delete latest saved version (%d)", v)
 		}
 
-		if err := tree.ndb.DeleteVersion(version, false); err != nil {
+		if err := tree.ndb.DeleteVersionCtx(ctx, v, false); err != nil {
+			tree.ndb.resetBatch()
 			return err
 		}
 
-		if version == lastestVersion {
-			root, err := tree.ndb.getRoot(version)
+		if v == lastestVersion {
+			root, err := tree.ndb.getRoot(v)
 			if err != nil {
+				tree.ndb.resetBatch()
 				return err
 			}
 
-			if err := tree.deleteNodes(newLatestVersion, root); err != nil {
+			if err := tree.deleteNodes(ctx, newLatestVersion, root); err != nil {
+				tree.ndb.resetBatch()
 				return err
 			}
 		}
+	}
+
+	tree.ndb.restoreNodes(newLatestVersion)
 
-		if err := tree.ndb.DeleteVersionMetadata(version); err != nil {
+	for v := version; v <= lastestVersion; v++ {
+		if err := tree.ndb.deleteVersionMetadataBatch(v); err != nil {
+			tree.ndb.resetBatch()
 			return err
 		}
-
-		delete(tree.versions, version)
 	}
 
-	tree.ndb.restoreNodes(newLatestVersion)
-
 	if err := tree.ndb.Commit(); err != nil {
 		return err
 	}
 
+	for ; version <= lastestVersion; version++ {
+		tree.ndb.uncacheVersionMetadata(version)
+		delete(tree.versions, version)
+	}
+
 	tree.ndb.resetLatestVersion(newLatestVersion)
+	tree.ndb.logger.Info("deleted versions for overwriting", "from", newLatestVersion+1,
+		"to", lastestVersion)
 	return nil
 }
 
 // deleteNodes deletes all nodes which have greater version than current, because they are not useful anymore
-func (tree *MutableTree) deleteNodes(version int64, hash []byte) error {
+func (tree *MutableTree) deleteNodes(ctx context.Context, version int64, hash []byte) error {
 	if len(hash) == 0 {
 		return nil
 	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
 
 	node := tree.ndb.GetNode(hash)
 	if node.leftHash != nil {
-		if err := tree.deleteNodes(version, node.leftHash); err != nil {
+		if err := tree.deleteNodes(ctx, version, node.leftHash); err != nil {
 			return err
 		}
 	}
 	if node.rightHash != nil {
-		if err := tree.deleteNodes(version, node.rightHash); err != nil {
+		if err := tree.deleteNodes(ctx, version, node.rightHash); err != nil {
 			return err
 		}
 	}
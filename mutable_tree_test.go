@@ -2,6 +2,7 @@ package iavl
 
 import (
 	"bytes"
+	"context"
 	"fmt"
 	"runtime"
 	"testing"
@@ -283,6 +284,160 @@ func TestMutableTree_DeleteVersions(t *testing.T) {
 	}
 }
 
+func TestMutableTree_DeleteVersionsCtx_Cancel(t *testing.T) {
+	tree, err := NewMutableTree(db.NewMemDB(), 0)
+	require.NoError(t, err)
+
+	for i := 0; i < 5; i++ {
+		tree.Set([]byte(fmt.Sprintf("key-%d", i)), []byte(fmt.Sprintf("value-%d", i)))
+		_, _, err = tree.SaveVersion()
+		require.NoError(t, err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	err = tree.DeleteVersionsCtx(ctx, 1, 2, 3)
+	require.Equal(t, context.Canceled, err)
+
+	// nothing should have been deleted
+	for v := int64(1); v <= 5; v++ {
+		require.True(t, tree.VersionExists(v))
+		_, err := tree.GetImmutable(v)
+		require.NoError(t, err)
+	}
+
+	// a later delete should not pick up the discarded writes
+	require.NoError(t, tree.DeleteVersions(1))
+	require.False(t, tree.VersionExists(1))
+	require.True(t, tree.VersionExists(2))
+	_, err = tree.GetImmutable(2)
+	require.NoError(t, err)
+}
+
+// countdownCtx is a context which is cancelled once Err() has been called a given number of times.
+type countdownCtx struct {
+	context.Context
+	remaining int
+}
+
+func (c *countdownCtx) Err() error {
+	if c.remaining <= 0 {
+		return context.Canceled
+	}
+	c.remaining--
+	return nil
+}
+
+func TestMutableTree_DeleteVersionsCtx_CancelOrphans(t *testing.T) {
+	tree, err := NewMutableTree(db.NewMemDB(), 100)
+	require.NoError(t, err)
+	for v := 0; v < 3; v++ {
+		for i := 0; i < 100; i++ {
+			tree.Set([]byte(fmt.Sprintf("key-%03d", i)), []byte(fmt.Sprintf("value-%d-%d", i, v)))
+		}
+		_, _, err = tree.SaveVersion()
+		require.NoError(t, err)
+	}
+	itree, err := tree.GetImmutable(1)
+	require.NoError(t, err)
+	values := map[string]string{}
+	itree.Iterate(func(key, value []byte) bool {
+		values[string(key)] = string(value)
+		return false
+	})
+	require.Greater(t, countKeys(tree.ndb.snapshotDB, orphanKeyFormat.Key(int64(1))), 10)
+
+	// The deletion is cancelled while traversing the orphans of the version, not after.
+	ctx := &countdownCtx{Context: context.Background(), remaining: 5}
+	err = tree.DeleteVersionsCtx(ctx, 1)
+	require.Equal(t, context.Canceled, err)
+	require.Zero(t, ctx.remaining)
+
+	require.True(t, tree.VersionExists(1))
+	itree, err = tree.GetImmutable(1)
+	require.NoError(t, err)
+	for key, value := range values {
+		_, v := itree.Get([]byte(key))
+		require.Equal(t, value, string(v))
+	}
+
+	require.NoError(t, tree.DeleteVersions(1))
+	require.False(t, tree.VersionExists(1))
+}
+
+func TestMutableTree_LoadVersionForOverwritingCtx_Cancel(t *testing.T) {
+	tree, err := NewMutableTree(db.NewMemDB(), 0)
+	require.NoError(t, err)
+
+	hashes := make([][]byte, 0, 5)
+	for i := 0; i < 5; i++ {
+		tree.Set([]byte(fmt.Sprintf("key-%d", i)), []byte(fmt.Sprintf("value-%d", i)))
+		hash, _, err := tree.SaveVersion()
+		require.NoError(t, err)
+		hashes = append(hashes, hash)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = tree.LoadVersionForOverwritingCtx(ctx, 2)
+	require.Equal(t, context.Canceled, err)
+
+	// the tree should be unchanged
+	require.EqualValues(t, 5, tree.Version())
+	require.Equal(t, hashes[4], tree.Hash())
+	for v := int64(1); v <= 5; v++ {
+		require.True(t, tree.VersionExists(v))
+		itree, err := tree.GetImmutable(v)
+		require.NoError(t, err)
+		require.Equal(t, hashes[v-1], itree.Hash())
+		ok, err := tree.ndb.snapshotDB.Has(metadataKeyFormat.Key(v))
+		require.NoError(t, err)
+		require.True(t, ok)
+	}
+
+	version, err := tree.LoadVersionForOverwriting(2)
+	require.NoError(t, err)
+	require.EqualValues(t, 2, version)
+	require.False(t, tree.VersionExists(3))
+	require.Equal(t, hashes[1], tree.Hash())
+	for v := int64(1); v <= 5; v++ {
+		ok, err := tree.ndb.snapshotDB.Has(metadataKeyFormat.Key(v))
+		require.NoError(t, err)
+		require.Equal(t, v <= 2, ok, "metadata of version %v", v)
+	}
+}
+
+func TestMutableTree_IterateCtx_Cancel(t *testing.T) {
+	tree, err := NewMutableTree(db.NewMemDB(), 0)
+	require.NoError(t, err)
+	for i := 0; i < 100; i++ {
+		tree.Set([]byte(fmt.Sprintf("key-%03d", i)), []byte{byte(i)})
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	count := 0
+	stopped, err := tree.IterateCtx(ctx, func(key, value []byte) bool {
+		count++
+		if count == 10 {
+			cancel()
+		}
+		return false
+	})
+	require.Equal(t, context.Canceled, err)
+	require.True(t, stopped)
+	require.Equal(t, 10, count)
+
+	count = 0
+	stopped, err = tree.IterateRangeCtx(context.Background(), []byte("key-010"), []byte("key-020"), true,
+		func(key, value []byte) bool {
+			count++
+			return false
+		})
+	require.NoError(t, err)
+	require.False(t, stopped)
+	require.Equal(t, 10, count)
+}
+
 func TestEmptyRecents(t *testing.T) {
 	memDB := db.NewMemDB()
 	opts := Options{
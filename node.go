@@ -10,7 +10,8 @@ import (
 	"io"
 
 	"github.com/pkg/errors"
-	amino "github.com/tendermint/go-amino"
+
+	"github.com/tendermint/iavl/internal/encoding"
 )
 
 // Node represents a node in a Tree.
@@ -47,25 +48,25 @@ func NewNode(key []byte, value []byte, version int64) *Node {
 func MakeNode(buf []byte) (*Node, error) {
 
 	// Read node header (height, size, version, key).
-	height, n, cause := amino.DecodeInt8(buf)
+	height, n, cause := encoding.DecodeInt8(buf)
 	if cause != nil {
 		return nil, errors.Wrap(cause, "decoding node.height")
 	}
 	buf = buf[n:]
 
-	size, n, cause := amino.DecodeVarint(buf)
+	size, n, cause := encoding.DecodeVarint(buf)
 	if cause != nil {
 		return nil, errors.Wrap(cause, "decoding node.size")
 	}
 	buf = buf[n:]
 
-	ver, n, cause := amino.DecodeVarint(buf)
+	ver, n, cause := encoding.DecodeVarint(buf)
 	if cause != nil {
 		return nil, errors.Wrap(cause, "decoding node.version")
 	}
 	buf = buf[n:]
 
-	key, n, cause := amino.DecodeByteSlice(buf)
+	key, n, cause := encoding.DecodeByteSlice(buf)
 	if cause != nil {
 		return nil, errors.Wrap(cause, "decoding node.key")
 	}
@@ -81,19 +82,19 @@ func MakeNode(buf []byte) (*Node, error) {
 	// Read node body.
 
 	if node.isLeaf() {
-		val, _, cause := amino.DecodeByteSlice(buf)
+		val, _, cause := encoding.DecodeByteSlice(buf)
 		if cause != nil {
 			return nil, errors.Wrap(cause, "decoding node.value")
 		}
 		node.value = val
 	} else { // Read children.
-		leftHash, n, cause := amino.DecodeByteSlice(buf)
+		leftHash, n, cause := encoding.DecodeByteSlice(buf)
 		if cause != nil {
 			return nil, errors.Wrap(cause, "deocding node.leftHash")
 		}
 		buf = buf[n:]
 
-		rightHash, _, cause := amino.DecodeByteSlice(buf)
+		rightHash, _, cause := encoding.DecodeByteSlice(buf)
 		if cause != nil {
 			return nil, errors.Wrap(cause, "decoding node.rightHash")
 		}
@@ -280,15 +281,15 @@ func (node *Node) validate() error {
 // Writes the node's hash to the given io.Writer. This function expects
 // child hashes to be already set.
 func (node *Node) writeHashBytes(w io.Writer) error {
-	err := amino.EncodeInt8(w, node.height)
+	err := encoding.EncodeVarint(w, int64(node.height))
 	if err != nil {
 		return errors.Wrap(err, "writing height")
 	}
-	err = amino.EncodeVarint(w, node.size)
+	err = encoding.EncodeVarint(w, node.size)
 	if err != nil {
 		return errors.Wrap(err, "writing size")
 	}
-	err = amino.EncodeVarint(w, node.version)
+	err = encoding.EncodeVarint(w, node.version)
 	if err != nil {
 		return errors.Wrap(err, "writing version")
 	}
@@ -296,7 +297,7 @@ func (node *Node) writeHashBytes(w io.Writer) error {
 	// Key is not written for inner nodes, unlike writeBytes.
 
 	if node.isLeaf() {
-		err = amino.EncodeByteSlice(w, node.key)
+		err = encoding.EncodeByteSlice(w, node.key)
 		if err != nil {
 			return errors.Wrap(err, "writing key")
 		}
@@ -306,7 +307,7 @@ func (node *Node) writeHashBytes(w io.Writer) error {
 		h := sha256.Sum256(node.value)
 		valueHash := h[:]
 
-		err = amino.EncodeByteSlice(w, valueHash)
+		err = encoding.EncodeByteSlice(w, valueHash)
 		if err != nil {
 			return errors.Wrap(err, "writing value")
 		}
@@ -314,11 +315,11 @@ func (node *Node) writeHashBytes(w io.Writer) error {
 		if node.leftHash == nil || node.rightHash == nil {
 			panic("Found an empty child hash")
 		}
-		err = amino.EncodeByteSlice(w, node.leftHash)
+		err = encoding.EncodeByteSlice(w, node.leftHash)
 		if err != nil {
 			return errors.Wrap(err, "writing left hash")
 		}
-		err = amino.EncodeByteSlice(w, node.rightHash)
+		err = encoding.EncodeByteSlice(w, node.rightHash)
 		if err != nil {
 			return errors.Wrap(err, "writing right hash")
 		}
@@ -347,41 +348,41 @@ func (node *Node) writeHashBytesRecursively(w io.Writer) (hashCount int64, err e
 
 func (node *Node) aminoSize() int {
 	n := 1 +
-		amino.VarintSize(node.size) +
-		amino.VarintSize(node.version) +
-		amino.ByteSliceSize(node.key)
+		encoding.VarintSize(node.size) +
+		encoding.VarintSize(node.version) +
+		encoding.ByteSliceSize(node.key)
 	if node.isLeaf() {
-		n += amino.ByteSliceSize(node.value)
+		n += encoding.ByteSliceSize(node.value)
 	} else {
-		n += amino.ByteSliceSize(node.leftHash) +
-			amino.ByteSliceSize(node.rightHash)
+		n += encoding.ByteSliceSize(node.leftHash) +
+			encoding.ByteSliceSize(node.rightHash)
 	}
 	return n
 }
 
 // Writes the node as a serialized byte slice to the supplied io.Writer.
 func (node *Node) writeBytes(w io.Writer) error {
-	cause := amino.EncodeInt8(w, node.height)
+	cause := encoding.EncodeVarint(w, int64(node.height))
 	if cause != nil {
 		return errors.Wrap(cause, "writing height")
 	}
-	cause = amino.EncodeVarint(w, node.size)
+	cause = encoding.EncodeVarint(w, node.size)
 	if cause != nil {
 		return errors.Wrap(cause, "writing size")
 	}
-	cause = amino.EncodeVarint(w, node.version)
+	cause = encoding.EncodeVarint(w, node.version)
 	if cause != nil {
 		return errors.Wrap(cause, "writing version")
 	}
 
 	// Unlike writeHashBytes, key is written for inner nodes.
-	cause = amino.EncodeByteSlice(w, node.key)
+	cause = encoding.EncodeByteSlice(w, node.key)
 	if cause != nil {
 		return errors.Wrap(cause, "writing key")
 	}
 
 	if node.isLeaf() {
-		cause = amino.EncodeByteSlice(w, node.value)
+		cause = encoding.EncodeByteSlice(w, node.value)
 		if cause != nil {
 			return errors.Wrap(cause, "writing value")
 		}
@@ -389,7 +390,7 @@ func (node *Node) writeBytes(w io.Writer) error {
 		if node.leftHash == nil {
 			panic("node.leftHash was nil in writeBytes")
 		}
-		cause = amino.EncodeByteSlice(w, node.leftHash)
+		cause = encoding.EncodeByteSlice(w, node.leftHash)
 		if cause != nil {
 			return errors.Wrap(cause, "writing left hash")
 		}
@@ -397,7 +398,7 @@ func (node *Node) writeBytes(w io.Writer) error {
 		if node.rightHash == nil {
 			panic("node.rightHash was nil in writeBytes")
 		}
-		cause = amino.EncodeByteSlice(w, node.rightHash)
+		cause = encoding.EncodeByteSlice(w, node.rightHash)
 		if cause != nil {
 			return errors.Wrap(cause, "writing right hash")
 		}
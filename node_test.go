@@ -2,6 +2,7 @@ package iavl
 
 import (
 	"bytes"
+	"encoding/hex"
 	"math/rand"
 	"testing"
 
@@ -32,6 +33,38 @@ func TestNode_aminoSize(t *testing.T) {
 	require.Equal(t, 57, node.aminoSize())
 }
 
+// TestNode_encodingGolden checks the node encoding and hashes against values produced with
+// go-amino, which stored nodes and existing hashes depend on.
+func TestNode_encodingGolden(t *testing.T) {
+	leaf := NewNode([]byte("key"), []byte("value"), 3)
+	var buf bytes.Buffer
+	require.NoError(t, leaf.writeBytes(&buf))
+	require.Equal(t, "000206036b65790576616c7565", hex.EncodeToString(buf.Bytes()))
+	require.Equal(t, buf.Len(), leaf.aminoSize())
+	require.Equal(t, "7f6890ca16dea6e8893d96f0a30d0a14e55559fc9b830491e3d2451c81f6d10e",
+		hex.EncodeToString(leaf._hash()))
+
+	inner := &Node{
+		key:       []byte("key2"),
+		version:   4,
+		height:    1,
+		size:      2,
+		leftHash:  leaf._hash(),
+		rightHash: bytes.Repeat([]byte{1}, 32),
+	}
+	buf.Reset()
+	require.NoError(t, inner.writeBytes(&buf))
+	require.Equal(t, "020408046b657932207f6890ca16dea6e8893d96f0a30d0a14e55559fc9b830491e3d2451c81f6d10e"+
+		"200101010101010101010101010101010101010101010101010101010101010101", hex.EncodeToString(buf.Bytes()))
+	require.Equal(t, buf.Len(), inner.aminoSize())
+	require.Equal(t, "60d214a911b431a802d5cdd1d0a45b59d1a051004f4667e602327647c9c74566",
+		hex.EncodeToString(inner._hash()))
+
+	decoded, err := MakeNode(buf.Bytes())
+	require.NoError(t, err)
+	require.Equal(t, inner._hash(), decoded._hash())
+}
+
 func TestNode_validate(t *testing.T) {
 	k := []byte("key")
 	v := []byte("value")
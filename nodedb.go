@@ -3,6 +3,7 @@ package iavl
 import (
 	"bytes"
 	"container/list"
+	"context"
 	"crypto/sha256"
 	"fmt"
 	"sort"
@@ -34,6 +35,9 @@ var (
 	rootKeyFormat = NewKeyFormat('r', int64Size) // r<version>
 
 	metadataKeyFormat = NewKeyFormat('m', int64Size) // m<version>
+
+	// Checkpoints of imports in progress are indexed by the version being imported.
+	importKeyFormat = NewKeyFormat('i', int64Size) // i<version>
 )
 
 type nodeDB struct {
@@ -43,6 +47,7 @@ type nodeDB struct {
 	snapshotBatch  dbm.Batch        // Batched writing buffer.
 	recentBatch    dbm.Batch        // Batched writing buffer for recentDB.
 	opts           *Options         // Options to customize for pruning/writing
+	logger         Logger           // Structured event logger, never nil.
 	versionReaders map[int64]uint32 // Number of active version readers (prevents pruning)
 
 	latestVersion  int64
@@ -51,6 +56,8 @@ type nodeDB struct {
 	nodeCacheQueue *list.List               // LRU queue of cache elements. Used for deletion.
 
 	vmCache *lru.Cache // LRU cache of version metadata
+
+	recorder *nodeRecorder // Records loaded nodes while set, see StartRecording()
 }
 
 func newNodeDB(snapshotDB dbm.DB, recentDB dbm.DB, cacheSize int, opts *Options) *nodeDB {
@@ -58,6 +65,11 @@ func newNodeDB(snapshotDB dbm.DB, recentDB dbm.DB, cacheSize int, opts *Options)
 		opts = DefaultOptions()
 	}
 
+	logger := opts.Logger
+	if logger == nil {
+		logger = NewNopLogger()
+	}
+
 	vmCache, err := lru.New(20000)
 	if err != nil {
 		panic(fmt.Errorf("failed to create metadata cache: %w", err))
@@ -69,6 +81,7 @@ func newNodeDB(snapshotDB dbm.DB, recentDB dbm.DB, cacheSize int, opts *Options)
 		snapshotBatch:  snapshotDB.NewBatch(),
 		recentBatch:    recentDB.NewBatch(),
 		opts:           opts,
+		logger:         logger,
 		latestVersion:  0, // initially invalid
 		nodeCache:      make(map[string]*list.Element),
 		nodeCacheSize:  cacheSize,
@@ -152,6 +165,24 @@ func (ndb *nodeDB) DeleteVersionMetadata(version int64) error {
 	return nil
 }
 
+// deleteVersionMetadataBatch adds the deletion of a VersionMetadata object to the snapshotDB
+// batch, so that it is committed along with the deletion of the version. Without snapshots the
+// batch is never written and no roots are persisted, so it is deleted from the snapshotDB directly.
+// The LRU cache entry must be removed with uncacheVersionMetadata() once the batch is committed.
+func (ndb *nodeDB) deleteVersionMetadataBatch(version int64) error {
+	key := metadataKeyFormat.Key(version)
+	if ndb.opts.KeepEvery == 0 {
+		return ndb.snapshotDB.Delete(key)
+	}
+	ndb.snapshotBatch.Delete(key)
+	return nil
+}
+
+// uncacheVersionMetadata removes a VersionMetadata object from the LRU cache.
+func (ndb *nodeDB) uncacheVersionMetadata(version int64) {
+	ndb.vmCache.Remove(string(metadataKeyFormat.Key(version)))
+}
+
 func (ndb *nodeDB) isRecentVersion(version int64) bool {
 	return ndb.opts.KeepRecent != 0 && version > ndb.latestVersion-ndb.opts.KeepRecent
 }
@@ -170,10 +201,13 @@ func (ndb *nodeDB) GetNode(hash []byte) *Node {
 	if elem, ok := ndb.nodeCache[string(hash)]; ok {
 		// Already exists. Move to back of nodeCacheQueue.
 		ndb.nodeCacheQueue.MoveToBack(elem)
-		return elem.Value.(*Node)
+		node := elem.Value.(*Node)
+		ndb.recordNode(node)
+		return node
 	}
 
-	// Doesn't exist, load.
+	// Doesn't exist, load. The database is read while holding the mutex, so that the node can't
+	// be deleted and uncached between the read and caching it.
 	buf, err := ndb.recentDB.Get(ndb.nodeKey(hash))
 	if err != nil {
 		panic(fmt.Sprintf("can't get node %X: %v", hash, err))
@@ -200,10 +234,18 @@ func (ndb *nodeDB) GetNode(hash []byte) *Node {
 
 	node.hash = hash
 	ndb.cacheNode(node)
+	ndb.recordNode(node)
 
 	return node
 }
 
+// recordNode records a node read by GetNode, if a recorder is set. The mutex must be held.
+func (ndb *nodeDB) recordNode(node *Node) {
+	if ndb.recorder != nil {
+		ndb.recorder.nodes[string(node.hash)] = node
+	}
+}
+
 // SaveNode saves a node to disk.
 func (ndb *nodeDB) SaveNode(node *Node, flushToDisk bool) {
 	ndb.saveNodeBatch(node, flushToDisk, ndb.recentBatch, ndb.snapshotBatch)
@@ -332,18 +374,25 @@ func (ndb *nodeDB) saveBranchBatch(node *Node, flushToDisk bool, rb, sb dbm.Batc
 
 // DeleteVersion deletes a tree version from disk.
 func (ndb *nodeDB) DeleteVersion(version int64, checkLatestVersion bool) error {
+	return ndb.DeleteVersionCtx(context.Background(), version, checkLatestVersion)
+}
+
+// DeleteVersionCtx is like DeleteVersion, but stops traversing the orphans of the version when
+// the context is cancelled or its deadline expires, and returns the context error. The caller
+// must then discard the pending writes with resetBatch().
+func (ndb *nodeDB) DeleteVersionCtx(ctx context.Context, version int64, checkLatestVersion bool) error {
 	ndb.mtx.Lock()
 	defer ndb.mtx.Unlock()
-	return ndb.deleteVersion(version, checkLatestVersion, false)
+	return ndb.deleteVersion(ctx, version, checkLatestVersion, false)
 }
 
 func (ndb *nodeDB) DeleteVersionFromRecent(version int64, checkLatestVersion bool) error {
 	ndb.mtx.Lock()
 	defer ndb.mtx.Unlock()
-	return ndb.deleteVersion(version, checkLatestVersion, true)
+	return ndb.deleteVersion(context.Background(), version, checkLatestVersion, true)
 }
 
-func (ndb *nodeDB) deleteVersion(version int64, checkLatestVersion, memOnly bool) error {
+func (ndb *nodeDB) deleteVersion(ctx context.Context, version int64, checkLatestVersion, memOnly bool) error {
 	if ndb.versionReaders[version] > 0 {
 		return errors.Errorf("unable to delete version %v, it has %v active readers", version, ndb.versionReaders[version])
 	}
@@ -353,7 +402,7 @@ func (ndb *nodeDB) deleteVersion(version int64, checkLatestVersion, memOnly bool
 		return err
 	}
 
-	if err := ndb.deleteOrphans(version, memOnly, vm.Snapshot); err != nil {
+	if err := ndb.deleteOrphans(ctx, version, memOnly, vm.Snapshot); err != nil {
 		return err
 	}
 
@@ -369,6 +418,7 @@ func (ndb *nodeDB) SaveOrphans(version int64, orphans map[string]int64) error {
 	defer ndb.mtx.Unlock()
 
 	toVersion := ndb.getPreviousVersion(version)
+	flushed := 0
 
 	for hash, fromVersion := range orphans {
 		var flushToDisk bool
@@ -383,10 +433,16 @@ func (ndb *nodeDB) SaveOrphans(version int64, orphans map[string]int64) error {
 			flushToDisk = fromVersion/ndb.opts.KeepEvery != toVersion/ndb.opts.KeepEvery || vm.Snapshot
 		}
 
-		debug("SAVEORPHAN %v-%v %X flushToDisk: %t\n", fromVersion, toVersion, hash, flushToDisk)
+		if flushToDisk {
+			flushed++
+		}
 		ndb.saveOrphan([]byte(hash), fromVersion, toVersion, flushToDisk)
 	}
 
+	if len(orphans) > 0 {
+		ndb.logger.Debug("saved orphans", "version", version, "toVersion", toVersion,
+			"orphans", len(orphans), "flushed", flushed)
+	}
 	return nil
 }
 
@@ -411,24 +467,45 @@ func (ndb *nodeDB) saveOrphan(hash []byte, fromVersion, toVersion int64, flushTo
 
 // deleteOrphans deletes orphaned nodes from disk, and the associated orphan
 // entries.
-func (ndb *nodeDB) deleteOrphans(version int64, memOnly, isSnapshot bool) error {
+func (ndb *nodeDB) deleteOrphans(ctx context.Context, version int64, memOnly, isSnapshot bool) error {
 	if ndb.opts.KeepRecent != 0 {
-		ndb.deleteOrphansMem(version)
+		deleted, moved, err := ndb.deleteOrphansMem(ctx, version)
+		if err != nil {
+			return err
+		}
+		if deleted > 0 || moved > 0 {
+			ndb.logger.Debug("pruned orphans", "version", version, "db", "recent",
+				"deleted", deleted, "moved", moved)
+		}
 	}
 
 	if isSnapshot && !memOnly {
+		var deleted, moved int
 		predecessor := getPreviousVersionFromDB(version, ndb.snapshotDB)
-		traverseOrphansVersionFromDB(ndb.snapshotDB, version, func(key, hash []byte) {
+		err := traversePrefixFromDBCtx(ctx, ndb.snapshotDB, orphanKeyFormat.Key(version), func(key, hash []byte) {
 			ndb.snapshotBatch.Delete(key)
-			ndb.deleteOrphansHelper(ndb.snapshotDB, ndb.snapshotBatch, true, predecessor, key, hash)
+			if ndb.deleteOrphansHelper(ndb.snapshotDB, ndb.snapshotBatch, true, predecessor, key, hash) {
+				deleted++
+			} else {
+				moved++
+			}
 		})
+		if err != nil {
+			return err
+		}
+		if deleted > 0 || moved > 0 {
+			ndb.logger.Debug("pruned orphans", "version", version, "db", "snapshot",
+				"predecessor", predecessor, "deleted", deleted, "moved", moved)
+		}
 	}
 
 	return nil
 }
 
-func (ndb *nodeDB) deleteOrphansMem(version int64) {
-	traverseOrphansVersionFromDB(ndb.recentDB, version, func(key, hash []byte) {
+// deleteOrphansMem deletes the orphans of a version from the recentDB, returning
+// the number of orphaned nodes deleted and the number moved to a predecessor.
+func (ndb *nodeDB) deleteOrphansMem(ctx context.Context, version int64) (deleted, moved int, err error) {
+	err = traversePrefixFromDBCtx(ctx, ndb.recentDB, orphanKeyFormat.Key(version), func(key, hash []byte) {
 		if ndb.opts.KeepRecent == 0 {
 			return
 		}
@@ -438,6 +515,7 @@ func (ndb *nodeDB) deleteOrphansMem(version int64) {
 			// delete orphan look-up, delete and uncache node
 			ndb.recentBatch.Delete(ndb.nodeKey(hash))
 			ndb.uncacheNode(hash)
+			deleted++
 			return
 		}
 
@@ -446,11 +524,18 @@ func (ndb *nodeDB) deleteOrphansMem(version int64) {
 		// user is manually deleting version from memDB
 		// thus predecessor may exist in memDB
 		// Will be zero if there is no previous version.
-		ndb.deleteOrphansHelper(ndb.recentDB, ndb.recentBatch, false, predecessor, key, hash)
+		if ndb.deleteOrphansHelper(ndb.recentDB, ndb.recentBatch, false, predecessor, key, hash) {
+			deleted++
+		} else {
+			moved++
+		}
 	})
+	return deleted, moved, err
 }
 
-func (ndb *nodeDB) deleteOrphansHelper(db dbm.DB, batch dbm.Batch, flushToDisk bool, predecessor int64, key, hash []byte) {
+// deleteOrphansHelper deletes the orphaned node if its lifetime has ended, or moves
+// it to the predecessor version otherwise. It returns true if the node was deleted.
+func (ndb *nodeDB) deleteOrphansHelper(db dbm.DB, batch dbm.Batch, flushToDisk bool, predecessor int64, key, hash []byte) bool {
 	var fromVersion, toVersion int64
 
 	// See comment on `orphanKeyFmt`. Note that here, `version` and
@@ -463,13 +548,12 @@ func (ndb *nodeDB) deleteOrphansHelper(db dbm.DB, batch dbm.Batch, flushToDisk b
 	// can delete the orphan.  Otherwise, we shorten its lifetime, by
 	// moving its endpoint to the previous version.
 	if predecessor < fromVersion || fromVersion == toVersion {
-		debug("DELETE predecessor:%v fromVersion:%v toVersion:%v %X flushToDisk: %t\n", predecessor, fromVersion, toVersion, hash, flushToDisk)
 		batch.Delete(ndb.nodeKey(hash))
 		ndb.uncacheNode(hash)
-	} else {
-		debug("MOVE predecessor:%v fromVersion:%v toVersion:%v %X flushToDisk: %t\n", predecessor, fromVersion, toVersion, hash, flushToDisk)
-		ndb.saveOrphan(hash, fromVersion, predecessor, flushToDisk)
+		return true
 	}
+	ndb.saveOrphan(hash, fromVersion, predecessor, flushToDisk)
+	return false
 }
 
 func (ndb *nodeDB) PruneRecentVersion() (int64, error) {
@@ -485,7 +569,7 @@ func (ndb *nodeDB) PruneRecentVersion() (int64, error) {
 		return 0, nil
 	}
 
-	if err := ndb.deleteVersion(pruneVer, true, true); err != nil {
+	if err := ndb.deleteVersion(context.Background(), pruneVer, true, true); err != nil {
 		return 0, err
 	}
 
@@ -513,6 +597,28 @@ func (ndb *nodeDB) rootKey(version int64) []byte {
 	return rootKeyFormat.Key(version)
 }
 
+func (ndb *nodeDB) importKey(version int64) []byte {
+	return importKeyFormat.Key(version)
+}
+
+// getImportCheckpointVersions returns the versions of aborted imports which have a checkpoint,
+// in ascending order.
+func (ndb *nodeDB) getImportCheckpointVersions() ([]int64, error) {
+	itr, err := dbm.IteratePrefix(ndb.snapshotDB, importKeyFormat.Key())
+	if err != nil {
+		return nil, err
+	}
+	defer itr.Close()
+
+	var versions []int64
+	for ; itr.Valid(); itr.Next() {
+		var version int64
+		importKeyFormat.Scan(itr.Key(), &version)
+		versions = append(versions, version)
+	}
+	return versions, nil
+}
+
 func (ndb *nodeDB) getLatestVersion() int64 {
 	if ndb.latestVersion == 0 {
 		ndb.latestVersion = ndb.getPreviousVersion(1<<63 - 1)
@@ -673,6 +779,24 @@ func traversePrefixFromDB(db dbm.DB, prefix []byte, fn func(k, v []byte)) {
 	}
 }
 
+// traversePrefixFromDBCtx is like traversePrefixFromDB, but stops when the context is cancelled
+// or its deadline expires, and returns the context error.
+func traversePrefixFromDBCtx(ctx context.Context, db dbm.DB, prefix []byte, fn func(k, v []byte)) error {
+	itr, err := dbm.IteratePrefix(db, prefix)
+	if err != nil {
+		return err
+	}
+	defer itr.Close()
+
+	for ; itr.Valid(); itr.Next() {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		fn(itr.Key(), itr.Value())
+	}
+	return itr.Error()
+}
+
 func (ndb *nodeDB) uncacheNode(hash []byte) {
 	if elem, ok := ndb.nodeCache[string(hash)]; ok {
 		ndb.nodeCacheQueue.Remove(elem)
@@ -680,6 +804,17 @@ func (ndb *nodeDB) uncacheNode(hash []byte) {
 	}
 }
 
+// resetCache discards all cached nodes and version metadata. It must be called when pending
+// writes are discarded after nodes were saved, since cached nodes are assumed to be persisted.
+func (ndb *nodeDB) resetCache() {
+	ndb.mtx.Lock()
+	defer ndb.mtx.Unlock()
+
+	ndb.nodeCache = make(map[string]*list.Element)
+	ndb.nodeCacheQueue = list.New()
+	ndb.vmCache.Purge()
+}
+
 // Add a node to the cache and pop the least recently used node if we've
 // reached the cache size limit.
 func (ndb *nodeDB) cacheNode(node *Node) {
@@ -737,6 +872,19 @@ func (ndb *nodeDB) Commit() error {
 	return nil
 }
 
+// resetBatch discards all pending writes of the recentDB and snapshotDB batches. The node cache
+// is reset as well, since deletes uncache nodes before they are committed.
+func (ndb *nodeDB) resetBatch() {
+	ndb.mtx.Lock()
+	ndb.snapshotBatch.Close()
+	ndb.recentBatch.Close()
+	ndb.snapshotBatch = ndb.snapshotDB.NewBatch()
+	ndb.recentBatch = ndb.recentDB.NewBatch()
+	ndb.mtx.Unlock()
+
+	ndb.resetCache()
+}
+
 func (ndb *nodeDB) getRoot(version int64) ([]byte, error) {
 	if ndb.isRecentVersion(version) {
 		memroot, err := ndb.recentDB.Get(ndb.rootKey(version))
@@ -792,8 +940,10 @@ func (ndb *nodeDB) saveRoot(hash []byte, version int64, flushToDisk bool) error
 	ndb.mtx.Lock()
 	defer ndb.mtx.Unlock()
 
-	if version != ndb.getLatestVersion()+1 {
-		return fmt.Errorf("must save consecutive versions; expected %d, got %d", ndb.getLatestVersion()+1, version)
+	// Versions may be skipped when importing above the latest version, e.g. by delta imports, but
+	// must never be overwritten.
+	if version <= ndb.getLatestVersion() {
+		return fmt.Errorf("must save increasing versions; latest is %d, got %d", ndb.getLatestVersion(), version)
 	}
 
 	key := ndb.rootKey(version)
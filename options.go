@@ -5,6 +5,10 @@ type Options struct {
 	KeepEvery  int64
 	KeepRecent int64
 	Sync       bool
+
+	// Logger receives structured events about saved, flushed and pruned versions.
+	// If nil, nothing is logged.
+	Logger Logger
 }
 
 // DefaultOptions returns the default options for IAVL
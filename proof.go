@@ -2,145 +2,57 @@ package iavl
 
 import (
 	"bytes"
-	"crypto/sha256"
-	"fmt"
 
 	"github.com/pkg/errors"
 
-	amino "github.com/tendermint/go-amino"
-	cmn "github.com/tendermint/iavl/common"
+	"github.com/tendermint/iavl/proof"
+	"github.com/tendermint/iavl/proofpb"
 )
 
+// The proof types and their verification live in the proof package, which has no storage
+// dependencies. They are aliased here, so that there is a single implementation.
+
 var (
 	// ErrInvalidProof is returned by Verify when a proof cannot be validated.
-	ErrInvalidProof = fmt.Errorf("invalid proof")
+	ErrInvalidProof = proof.ErrInvalidProof
 
 	// ErrInvalidInputs is returned when the inputs passed to the function are invalid.
-	ErrInvalidInputs = fmt.Errorf("invalid inputs")
+	ErrInvalidInputs = proof.ErrInvalidInputs
 
 	// ErrInvalidRoot is returned when the root passed in does not match the proof's.
-	ErrInvalidRoot = fmt.Errorf("invalid root")
+	ErrInvalidRoot = proof.ErrInvalidRoot
 )
 
-//----------------------------------------
-
-type ProofInnerNode struct {
-	Height  int8   `json:"height"`
-	Size    int64  `json:"size"`
-	Version int64  `json:"version"`
-	Left    []byte `json:"left"`
-	Right   []byte `json:"right"`
-}
-
-func (pin ProofInnerNode) String() string {
-	return pin.stringIndented("")
-}
-
-func (pin ProofInnerNode) stringIndented(indent string) string {
-	return fmt.Sprintf(`ProofInnerNode{
-%s  Height:  %v
-%s  Size:    %v
-%s  Version: %v
-%s  Left:    %X
-%s  Right:   %X
-%s}`,
-		indent, pin.Height,
-		indent, pin.Size,
-		indent, pin.Version,
-		indent, pin.Left,
-		indent, pin.Right,
-		indent)
-}
-
-func (pin ProofInnerNode) Hash(childHash []byte) []byte {
-	hasher := sha256.New()
-	buf := new(bytes.Buffer)
-
-	err := amino.EncodeInt8(buf, pin.Height)
-	if err == nil {
-		err = amino.EncodeVarint(buf, pin.Size)
-	}
-	if err == nil {
-		err = amino.EncodeVarint(buf, pin.Version)
-	}
-
-	if len(pin.Left) == 0 {
-		if err == nil {
-			err = amino.EncodeByteSlice(buf, childHash)
-		}
-		if err == nil {
-			err = amino.EncodeByteSlice(buf, pin.Right)
-		}
-	} else {
-		if err == nil {
-			err = amino.EncodeByteSlice(buf, pin.Left)
-		}
-		if err == nil {
-			err = amino.EncodeByteSlice(buf, childHash)
-		}
-	}
-	if err != nil {
-		panic(fmt.Sprintf("Failed to hash ProofInnerNode: %v", err))
-	}
-
-	_, err = hasher.Write(buf.Bytes())
-	if err != nil {
-		panic(err)
-	}
-	return hasher.Sum(nil)
-}
-
-//----------------------------------------
+type (
+	// ProofInnerNode is an inner node of a PathToLeaf, see proof.ProofInnerNode.
+	ProofInnerNode = proof.ProofInnerNode
+	// ProofLeafNode is a leaf node of a RangeProof, see proof.ProofLeafNode.
+	ProofLeafNode = proof.ProofLeafNode
+	// PathToLeaf is a path from the root to a leaf, see proof.PathToLeaf.
+	PathToLeaf = proof.PathToLeaf
+	// RangeProof is a proof of existence or absence of a range of keys, see proof.RangeProof.
+	RangeProof = proof.RangeProof
+)
 
-type ProofLeafNode struct {
-	Key       cmn.HexBytes `json:"key"`
-	ValueHash cmn.HexBytes `json:"value"`
-	Version   int64        `json:"version"`
+// ProofInnerNodeFromProto converts a protobuf inner node to a ProofInnerNode.
+func ProofInnerNodeFromProto(pbInner *proofpb.ProofInnerNode) (ProofInnerNode, error) {
+	return proof.ProofInnerNodeFromProto(pbInner)
 }
 
-func (pln ProofLeafNode) String() string {
-	return pln.stringIndented("")
+// ProofLeafNodeFromProto converts a protobuf leaf node to a ProofLeafNode.
+func ProofLeafNodeFromProto(pbLeaf *proofpb.ProofLeafNode) (ProofLeafNode, error) {
+	return proof.ProofLeafNodeFromProto(pbLeaf)
 }
 
-func (pln ProofLeafNode) stringIndented(indent string) string {
-	return fmt.Sprintf(`ProofLeafNode{
-%s  Key:       %v
-%s  ValueHash: %X
-%s  Version:   %v
-%s}`,
-		indent, pln.Key,
-		indent, pln.ValueHash,
-		indent, pln.Version,
-		indent)
+// PathToLeafFromProto converts a protobuf path to a PathToLeaf. A nil path is empty.
+func PathToLeafFromProto(pbPath *proofpb.PathToLeaf) (PathToLeaf, error) {
+	return proof.PathToLeafFromProto(pbPath)
 }
 
-func (pln ProofLeafNode) Hash() []byte {
-	hasher := sha256.New()
-	buf := new(bytes.Buffer)
-
-	err := amino.EncodeInt8(buf, 0)
-	if err == nil {
-		err = amino.EncodeVarint(buf, 1)
-	}
-	if err == nil {
-		err = amino.EncodeVarint(buf, pln.Version)
-	}
-	if err == nil {
-		err = amino.EncodeByteSlice(buf, pln.Key)
-	}
-	if err == nil {
-		err = amino.EncodeByteSlice(buf, pln.ValueHash)
-	}
-	if err != nil {
-		panic(fmt.Sprintf("Failed to hash ProofLeafNode: %v", err))
-	}
-	_, err = hasher.Write(buf.Bytes())
-	if err != nil {
-		panic(err)
-
-	}
-
-	return hasher.Sum(nil)
+// RangeProofFromProto converts a protobuf range proof to a RangeProof. A nil proof (for an empty
+// tree) is returned as nil.
+func RangeProofFromProto(pbProof *proofpb.RangeProof) (*RangeProof, error) {
+	return proof.RangeProofFromProto(pbProof)
 }
 
 //----------------------------------------
@@ -1,8 +1,12 @@
-package iavl
+package proof
 
 import (
 	"fmt"
 	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/tendermint/iavl/proofpb"
 )
 
 // pathWithLeaf is a path to a leaf node and the leaf node itself.
@@ -29,7 +33,7 @@ func (pwl pathWithLeaf) StringIndented(indent string) string {
 // Does not verify the root hash.
 func (pwl pathWithLeaf) computeRootHash() []byte {
 	leafHash := pwl.Leaf.Hash()
-	return pwl.Path.computeRootHash(leafHash)
+	return pwl.Path.ComputeRootHash(leafHash)
 }
 
 //----------------------------------------
@@ -39,6 +43,31 @@ func (pwl pathWithLeaf) computeRootHash() []byte {
 // to the root of the tree.
 type PathToLeaf []ProofInnerNode
 
+// ToProto converts the path to its protobuf representation.
+func (pl PathToLeaf) ToProto() *proofpb.PathToLeaf {
+	inners := make([]*proofpb.ProofInnerNode, 0, len(pl))
+	for _, pin := range pl {
+		inners = append(inners, pin.ToProto())
+	}
+	return &proofpb.PathToLeaf{Inners: inners}
+}
+
+// PathToLeafFromProto converts a protobuf path to a PathToLeaf. A nil path is empty.
+func PathToLeafFromProto(pbPath *proofpb.PathToLeaf) (PathToLeaf, error) {
+	if pbPath == nil || len(pbPath.Inners) == 0 {
+		return nil, nil
+	}
+	pl := make(PathToLeaf, 0, len(pbPath.Inners))
+	for i, pbInner := range pbPath.Inners {
+		pin, err := ProofInnerNodeFromProto(pbInner)
+		if err != nil {
+			return nil, errors.Wrapf(err, "inner node %v", i)
+		}
+		pl = append(pl, pin)
+	}
+	return pl, nil
+}
+
 func (pl PathToLeaf) String() string {
 	return pl.stringIndented("")
 }
@@ -62,9 +91,9 @@ func (pl PathToLeaf) stringIndented(indent string) string {
 		indent)
 }
 
-// `computeRootHash` computes the root hash assuming some leaf hash.
+// ComputeRootHash computes the root hash assuming some leaf hash.
 // Does not verify the root hash.
-func (pl PathToLeaf) computeRootHash(leafHash []byte) []byte {
+func (pl PathToLeaf) ComputeRootHash(leafHash []byte) []byte {
 	hash := leafHash
 	for i := len(pl) - 1; i >= 0; i-- {
 		pin := pl[i]
@@ -73,7 +102,8 @@ func (pl PathToLeaf) computeRootHash(leafHash []byte) []byte {
 	return hash
 }
 
-func (pl PathToLeaf) isLeftmost() bool {
+// IsLeftmost returns true if the path leads to the leftmost leaf of the tree.
+func (pl PathToLeaf) IsLeftmost() bool {
 	for _, node := range pl {
 		if len(node.Left) > 0 {
 			return false
@@ -82,7 +112,8 @@ func (pl PathToLeaf) isLeftmost() bool {
 	return true
 }
 
-func (pl PathToLeaf) isRightmost() bool {
+// IsRightmost returns true if the path leads to the rightmost leaf of the tree.
+func (pl PathToLeaf) IsRightmost() bool {
 	for _, node := range pl {
 		if len(node.Right) > 0 {
 			return false
@@ -0,0 +1,198 @@
+// Package proof contains the IAVL proof types and their verification, without any storage
+// dependencies, so that proofs can be verified in constrained environments such as light
+// clients. The iavl package generates the proofs, and aliases these types.
+package proof
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"fmt"
+	"math"
+
+	"github.com/pkg/errors"
+
+	cmn "github.com/tendermint/iavl/common"
+	"github.com/tendermint/iavl/internal/encoding"
+	"github.com/tendermint/iavl/proofpb"
+)
+
+var (
+	// ErrInvalidProof is returned by Verify when a proof cannot be validated.
+	ErrInvalidProof = fmt.Errorf("invalid proof")
+
+	// ErrInvalidInputs is returned when the inputs passed to the function are invalid.
+	ErrInvalidInputs = fmt.Errorf("invalid inputs")
+
+	// ErrInvalidRoot is returned when the root passed in does not match the proof's.
+	ErrInvalidRoot = fmt.Errorf("invalid root")
+)
+
+//----------------------------------------
+
+type ProofInnerNode struct {
+	Height  int8   `json:"height"`
+	Size    int64  `json:"size"`
+	Version int64  `json:"version"`
+	Left    []byte `json:"left"`
+	Right   []byte `json:"right"`
+}
+
+func (pin ProofInnerNode) String() string {
+	return pin.stringIndented("")
+}
+
+func (pin ProofInnerNode) stringIndented(indent string) string {
+	return fmt.Sprintf(`ProofInnerNode{
+%s  Height:  %v
+%s  Size:    %v
+%s  Version: %v
+%s  Left:    %X
+%s  Right:   %X
+%s}`,
+		indent, pin.Height,
+		indent, pin.Size,
+		indent, pin.Version,
+		indent, pin.Left,
+		indent, pin.Right,
+		indent)
+}
+
+func (pin ProofInnerNode) Hash(childHash []byte) []byte {
+	hasher := sha256.New()
+	buf := new(bytes.Buffer)
+
+	err := encoding.EncodeVarint(buf, int64(pin.Height))
+	if err == nil {
+		err = encoding.EncodeVarint(buf, pin.Size)
+	}
+	if err == nil {
+		err = encoding.EncodeVarint(buf, pin.Version)
+	}
+
+	if len(pin.Left) == 0 {
+		if err == nil {
+			err = encoding.EncodeByteSlice(buf, childHash)
+		}
+		if err == nil {
+			err = encoding.EncodeByteSlice(buf, pin.Right)
+		}
+	} else {
+		if err == nil {
+			err = encoding.EncodeByteSlice(buf, pin.Left)
+		}
+		if err == nil {
+			err = encoding.EncodeByteSlice(buf, childHash)
+		}
+	}
+	if err != nil {
+		panic(fmt.Sprintf("Failed to hash ProofInnerNode: %v", err))
+	}
+
+	_, err = hasher.Write(buf.Bytes())
+	if err != nil {
+		panic(err)
+	}
+	return hasher.Sum(nil)
+}
+
+// ToProto converts the inner node to its protobuf representation.
+func (pin ProofInnerNode) ToProto() *proofpb.ProofInnerNode {
+	return &proofpb.ProofInnerNode{
+		Height:  int32(pin.Height),
+		Size_:   pin.Size,
+		Version: pin.Version,
+		Left:    pin.Left,
+		Right:   pin.Right,
+	}
+}
+
+// ProofInnerNodeFromProto converts a protobuf inner node to a ProofInnerNode.
+func ProofInnerNodeFromProto(pbInner *proofpb.ProofInnerNode) (ProofInnerNode, error) {
+	if pbInner == nil {
+		return ProofInnerNode{}, errors.New("inner node cannot be nil")
+	}
+	if pbInner.Height < math.MinInt8 || pbInner.Height > math.MaxInt8 {
+		return ProofInnerNode{}, errors.Errorf("height %v out of range", pbInner.Height)
+	}
+	return ProofInnerNode{
+		Height:  int8(pbInner.Height),
+		Size:    pbInner.Size_,
+		Version: pbInner.Version,
+		Left:    pbInner.Left,
+		Right:   pbInner.Right,
+	}, nil
+}
+
+//----------------------------------------
+
+type ProofLeafNode struct {
+	Key       cmn.HexBytes `json:"key"`
+	ValueHash cmn.HexBytes `json:"value"`
+	Version   int64        `json:"version"`
+}
+
+func (pln ProofLeafNode) String() string {
+	return pln.stringIndented("")
+}
+
+func (pln ProofLeafNode) stringIndented(indent string) string {
+	return fmt.Sprintf(`ProofLeafNode{
+%s  Key:       %v
+%s  ValueHash: %X
+%s  Version:   %v
+%s}`,
+		indent, pln.Key,
+		indent, pln.ValueHash,
+		indent, pln.Version,
+		indent)
+}
+
+func (pln ProofLeafNode) Hash() []byte {
+	hasher := sha256.New()
+	buf := new(bytes.Buffer)
+
+	err := encoding.EncodeVarint(buf, 0)
+	if err == nil {
+		err = encoding.EncodeVarint(buf, 1)
+	}
+	if err == nil {
+		err = encoding.EncodeVarint(buf, pln.Version)
+	}
+	if err == nil {
+		err = encoding.EncodeByteSlice(buf, pln.Key)
+	}
+	if err == nil {
+		err = encoding.EncodeByteSlice(buf, pln.ValueHash)
+	}
+	if err != nil {
+		panic(fmt.Sprintf("Failed to hash ProofLeafNode: %v", err))
+	}
+	_, err = hasher.Write(buf.Bytes())
+	if err != nil {
+		panic(err)
+
+	}
+
+	return hasher.Sum(nil)
+}
+
+// ToProto converts the leaf node to its protobuf representation.
+func (pln ProofLeafNode) ToProto() *proofpb.ProofLeafNode {
+	return &proofpb.ProofLeafNode{
+		Key:       pln.Key,
+		ValueHash: pln.ValueHash,
+		Version:   pln.Version,
+	}
+}
+
+// ProofLeafNodeFromProto converts a protobuf leaf node to a ProofLeafNode.
+func ProofLeafNodeFromProto(pbLeaf *proofpb.ProofLeafNode) (ProofLeafNode, error) {
+	if pbLeaf == nil {
+		return ProofLeafNode{}, errors.New("leaf node cannot be nil")
+	}
+	return ProofLeafNode{
+		Key:       pbLeaf.Key,
+		ValueHash: pbLeaf.ValueHash,
+		Version:   pbLeaf.Version,
+	}, nil
+}
@@ -0,0 +1,72 @@
+package proof
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"os/exec"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNodeHashes(t *testing.T) {
+	valueHash := sha256.Sum256([]byte("value"))
+	leaf := ProofLeafNode{Key: []byte("key"), ValueHash: valueHash[:], Version: 3}
+	leafHash := leaf.Hash()
+	assert.Equal(t, "7F6890CA16DEA6E8893D96F0A30D0A14E55559FC9B830491E3D2451C81F6D10E",
+		strings.ToUpper(hex.EncodeToString(leafHash)))
+
+	inner := ProofInnerNode{Height: -3, Size: 300, Version: 7, Right: bytes.Repeat([]byte{1}, 32)}
+	assert.Equal(t, "53665FFE90BC37053BBB7103088B181DD13FBEEE4E4F810C77A5C1EF434E1794",
+		strings.ToUpper(hex.EncodeToString(inner.Hash(leafHash))))
+}
+
+func TestRangeProof(t *testing.T) {
+	// A tree with two leaves, "a" and "b".
+	leaves := make([]ProofLeafNode, 0, 2)
+	for _, key := range []string{"a", "b"} {
+		valueHash := sha256.Sum256([]byte("value-" + key))
+		leaves = append(leaves, ProofLeafNode{Key: []byte(key), ValueHash: valueHash[:], Version: 1})
+	}
+	root := ProofInnerNode{Height: 1, Size: 2, Version: 1, Right: leaves[1].Hash()}.Hash(leaves[0].Hash())
+
+	proof := &RangeProof{
+		LeftPath:   PathToLeaf{{Height: 1, Size: 2, Version: 1, Right: leaves[1].Hash()}},
+		InnerNodes: []PathToLeaf{nil},
+		Leaves:     leaves,
+	}
+	require.Equal(t, root, proof.ComputeRootHash())
+	require.Error(t, proof.VerifyItem([]byte("a"), []byte("value-a")), "must verify root first")
+	require.Error(t, proof.Verify([]byte("foo")))
+	require.NoError(t, proof.Verify(root))
+	require.NoError(t, proof.VerifyItem([]byte("a"), []byte("value-a")))
+	require.NoError(t, proof.VerifyItem([]byte("b"), []byte("value-b")))
+	require.Error(t, proof.VerifyItem([]byte("b"), []byte("value-a")))
+	require.NoError(t, proof.VerifyAbsence([]byte("ab")))
+	require.NoError(t, proof.VerifyAbsence([]byte("c")))
+	require.Error(t, proof.VerifyAbsence([]byte("a")))
+	require.NoError(t, proof.VerifyIndexRange(0, [][]byte{[]byte("a"), []byte("b")},
+		[][]byte{[]byte("value-a"), []byte("value-b")}))
+
+	decoded, err := RangeProofFromProto(proof.ToProto())
+	require.NoError(t, err)
+	require.NoError(t, decoded.Verify(root))
+}
+
+// TestDependencies ensures that the package stays usable without the storage dependencies of
+// the iavl package.
+func TestDependencies(t *testing.T) {
+	out, err := exec.Command("go", "list", "-deps", ".").Output()
+	if err != nil {
+		t.Skipf("go list failed: %v", err)
+	}
+	for _, dep := range strings.Fields(string(out)) {
+		assert.NotEqual(t, "github.com/tendermint/iavl", dep)
+		for _, forbidden := range []string{"tm-db", "goleveldb", "go-amino", "lru"} {
+			assert.NotContains(t, dep, forbidden)
+		}
+	}
+}
@@ -0,0 +1,264 @@
+package iavl
+
+import (
+	"bytes"
+
+	"github.com/pkg/errors"
+
+	"github.com/tendermint/iavl/internal/encoding"
+)
+
+// The amino encoding of ValueOp and AbsenceOp (ProofOpEncodingAmino) is implemented by hand,
+// reproducing the output of go-amino's MarshalBinaryLengthPrefixed byte-for-byte. Amino encodes
+// structs like protobuf, with fields numbered in order of declaration, except that signed
+// integers narrower than 64 bits are zigzag-encoded, and lists of lists are wrapped as lists of
+// structs.
+//
+// The op structs encode as:
+//
+//	op         { 1: RangeProof (omitted if nil) }
+//	RangeProof { 1: repeated ProofInnerNode; 2: repeated PathToLeaf; 3: repeated ProofLeafNode }
+//	PathToLeaf { 1: repeated ProofInnerNode }
+//	ProofInnerNode { 1: zigzag height; 2: size; 3: version; 4: left; 5: right }
+//	ProofLeafNode  { 1: key; 2: value hash; 3: version }
+
+const (
+	aminoTyp3Varint     = 0
+	aminoTyp3ByteLength = 2
+)
+
+// marshalAminoRangeProofOp encodes a ValueOp or AbsenceOp with the given proof.
+func marshalAminoRangeProofOp(proof *RangeProof) []byte {
+	var op bytes.Buffer
+	if proof != nil {
+		writeAminoStructField(&op, 1, encodeAminoRangeProof(proof))
+	}
+	var buf bytes.Buffer
+	writeAminoBytes(&buf, op.Bytes())
+	return buf.Bytes()
+}
+
+// unmarshalAminoRangeProofOp decodes a ValueOp or AbsenceOp, returning its proof.
+func unmarshalAminoRangeProofOp(bz []byte) (*RangeProof, error) {
+	if len(bz) == 0 {
+		return nil, errors.New("cannot decode empty bytes")
+	}
+	opBz, n, err := encoding.DecodeByteSlice(bz)
+	if err != nil {
+		return nil, errors.Wrap(err, "decoding length prefix")
+	}
+	if n != len(bz) {
+		return nil, errors.Errorf("prefix length %v does not match encoded length %v", n, len(bz))
+	}
+	var proof *RangeProof
+	err = decodeAminoFields(opBz, func(num uint64, typ3 byte, value []byte) error {
+		if num != 1 || typ3 != aminoTyp3ByteLength {
+			return errors.Errorf("unexpected field %v of type %v", num, typ3)
+		}
+		proof, err = decodeAminoRangeProof(value)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return proof, nil
+}
+
+func encodeAminoRangeProof(proof *RangeProof) []byte {
+	var buf bytes.Buffer
+	for _, pin := range proof.LeftPath {
+		writeAminoStructField(&buf, 1, encodeAminoInnerNode(pin))
+	}
+	for _, path := range proof.InnerNodes {
+		var pbuf bytes.Buffer
+		for _, pin := range path {
+			writeAminoStructField(&pbuf, 1, encodeAminoInnerNode(pin))
+		}
+		writeAminoStructField(&buf, 2, pbuf.Bytes())
+	}
+	for _, leaf := range proof.Leaves {
+		writeAminoStructField(&buf, 3, encodeAminoLeafNode(leaf))
+	}
+	return buf.Bytes()
+}
+
+func decodeAminoRangeProof(bz []byte) (*RangeProof, error) {
+	proof := &RangeProof{}
+	err := decodeAminoFields(bz, func(num uint64, typ3 byte, value []byte) error {
+		if typ3 != aminoTyp3ByteLength {
+			return errors.Errorf("unexpected type %v for field %v", typ3, num)
+		}
+		switch num {
+		case 1:
+			pin, err := decodeAminoInnerNode(value)
+			if err != nil {
+				return errors.Wrap(err, "left path")
+			}
+			proof.LeftPath = append(proof.LeftPath, pin)
+		case 2:
+			var path PathToLeaf
+			err := decodeAminoFields(value, func(num uint64, typ3 byte, value []byte) error {
+				if num != 1 || typ3 != aminoTyp3ByteLength {
+					return errors.Errorf("unexpected field %v of type %v", num, typ3)
+				}
+				pin, err := decodeAminoInnerNode(value)
+				if err != nil {
+					return err
+				}
+				path = append(path, pin)
+				return nil
+			})
+			if err != nil {
+				return errors.Wrap(err, "inner path")
+			}
+			proof.InnerNodes = append(proof.InnerNodes, path)
+		case 3:
+			leaf, err := decodeAminoLeafNode(value)
+			if err != nil {
+				return errors.Wrap(err, "leaf")
+			}
+			proof.Leaves = append(proof.Leaves, leaf)
+		default:
+			return errors.Errorf("unexpected field %v", num)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return proof, nil
+}
+
+func encodeAminoInnerNode(pin ProofInnerNode) []byte {
+	var buf bytes.Buffer
+	if pin.Height != 0 {
+		writeAminoFieldKey(&buf, 1, aminoTyp3Varint)
+		_ = encoding.EncodeVarint(&buf, int64(pin.Height))
+	}
+	writeAminoUvarintField(&buf, 2, uint64(pin.Size))
+	writeAminoUvarintField(&buf, 3, uint64(pin.Version))
+	writeAminoBytesField(&buf, 4, pin.Left)
+	writeAminoBytesField(&buf, 5, pin.Right)
+	return buf.Bytes()
+}
+
+func decodeAminoInnerNode(bz []byte) (pin ProofInnerNode, err error) {
+	err = decodeAminoFields(bz, func(num uint64, typ3 byte, value []byte) error {
+		var err error
+		switch {
+		case num == 1 && typ3 == aminoTyp3Varint:
+			pin.Height, _, err = encoding.DecodeInt8(value)
+		case num == 2 && typ3 == aminoTyp3Varint:
+			pin.Size, err = decodeAminoInt64(value)
+		case num == 3 && typ3 == aminoTyp3Varint:
+			pin.Version, err = decodeAminoInt64(value)
+		case num == 4 && typ3 == aminoTyp3ByteLength:
+			pin.Left = value
+		case num == 5 && typ3 == aminoTyp3ByteLength:
+			pin.Right = value
+		default:
+			err = errors.Errorf("unexpected field %v of type %v", num, typ3)
+		}
+		return err
+	})
+	return pin, err
+}
+
+func encodeAminoLeafNode(leaf ProofLeafNode) []byte {
+	var buf bytes.Buffer
+	writeAminoBytesField(&buf, 1, leaf.Key)
+	writeAminoBytesField(&buf, 2, leaf.ValueHash)
+	writeAminoUvarintField(&buf, 3, uint64(leaf.Version))
+	return buf.Bytes()
+}
+
+func decodeAminoLeafNode(bz []byte) (leaf ProofLeafNode, err error) {
+	err = decodeAminoFields(bz, func(num uint64, typ3 byte, value []byte) error {
+		var err error
+		switch {
+		case num == 1 && typ3 == aminoTyp3ByteLength:
+			leaf.Key = value
+		case num == 2 && typ3 == aminoTyp3ByteLength:
+			leaf.ValueHash = value
+		case num == 3 && typ3 == aminoTyp3Varint:
+			leaf.Version, err = decodeAminoInt64(value)
+		default:
+			err = errors.Errorf("unexpected field %v of type %v", num, typ3)
+		}
+		return err
+	})
+	return leaf, err
+}
+
+// decodeAminoFields calls fn for each field of an encoded struct, in order. For varint fields
+// the value is the encoded varint, for byte length fields it is the contents.
+func decodeAminoFields(bz []byte, fn func(num uint64, typ3 byte, value []byte) error) error {
+	for len(bz) > 0 {
+		key, n, err := encoding.DecodeUvarint(bz)
+		if err != nil {
+			return errors.Wrap(err, "decoding field key")
+		}
+		bz = bz[n:]
+		num, typ3 := key>>3, byte(key&0x07)
+
+		var value []byte
+		switch typ3 {
+		case aminoTyp3Varint:
+			_, n, err = encoding.DecodeUvarint(bz)
+			if err != nil {
+				return errors.Wrapf(err, "decoding field %v", num)
+			}
+			value = bz[:n]
+		case aminoTyp3ByteLength:
+			value, n, err = encoding.DecodeByteSlice(bz)
+			if err != nil {
+				return errors.Wrapf(err, "decoding field %v", num)
+			}
+		default:
+			return errors.Errorf("unsupported type %v for field %v", typ3, num)
+		}
+		bz = bz[n:]
+		if err := fn(num, typ3, value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func decodeAminoInt64(bz []byte) (int64, error) {
+	u, _, err := encoding.DecodeUvarint(bz)
+	return int64(u), err
+}
+
+func writeAminoFieldKey(buf *bytes.Buffer, num uint64, typ3 byte) {
+	_ = encoding.EncodeUvarint(buf, num<<3|uint64(typ3))
+}
+
+// writeAminoStructField writes a struct or list element, which is written even if empty.
+func writeAminoStructField(buf *bytes.Buffer, num uint64, bz []byte) {
+	writeAminoFieldKey(buf, num, aminoTyp3ByteLength)
+	writeAminoBytes(buf, bz)
+}
+
+// writeAminoBytesField writes a byte slice field, which is omitted if empty.
+func writeAminoBytesField(buf *bytes.Buffer, num uint64, bz []byte) {
+	if len(bz) == 0 {
+		return
+	}
+	writeAminoStructField(buf, num, bz)
+}
+
+// writeAminoUvarintField writes an int64 field, which is omitted if zero. Amino encodes int64
+// fields as unsigned varints, like protobuf.
+func writeAminoUvarintField(buf *bytes.Buffer, num uint64, u uint64) {
+	if u == 0 {
+		return
+	}
+	writeAminoFieldKey(buf, num, aminoTyp3Varint)
+	_ = encoding.EncodeUvarint(buf, u)
+}
+
+func writeAminoBytes(buf *bytes.Buffer, bz []byte) {
+	// Writing to a bytes.Buffer can't fail.
+	_ = encoding.EncodeByteSlice(buf, bz)
+}
@@ -0,0 +1,212 @@
+package iavl
+
+import (
+	"bytes"
+	"crypto/sha256"
+
+	"github.com/pkg/errors"
+)
+
+// RangeCountProof proves the number of keys in a key range, without revealing the keys. It
+// proves the index of each range boundary using the paths to the leaves on either side of it,
+// since the subtree sizes along those paths determine the leaf positions.
+type RangeCountProof struct {
+	Start *RangeCountBoundary `json:"start"`
+	End   *RangeCountBoundary `json:"end"`
+
+	// memoize
+	rootVerified bool
+	count        int64 // valid iff rootVerified is true
+}
+
+// RangeCountBoundary proves the index of a range boundary, i.e. the number of keys below it.
+// A nil Key is the start or end of the tree, depending on which boundary it is.
+type RangeCountBoundary struct {
+	Key    []byte          `json:"key"`
+	Before *RangeCountLeaf `json:"before"` // leaf with the greatest key below Key, if any
+	After  *RangeCountLeaf `json:"after"`  // leaf with the smallest key at or above Key, if any
+}
+
+// RangeCountLeaf is a leaf next to a range boundary, along with its path from the root.
+type RangeCountLeaf struct {
+	Path PathToLeaf    `json:"path"`
+	Leaf ProofLeafNode `json:"leaf"`
+}
+
+// computeRootHash computes the root hash with the leaf. Does not verify the root hash.
+func (l *RangeCountLeaf) computeRootHash() []byte {
+	return l.Path.ComputeRootHash(l.Leaf.Hash())
+}
+
+// GetRangeCountWithProof returns the number of keys in the range [start, end), along with a
+// proof of it. A nil start or end means the range is open on that side.
+func (t *ImmutableTree) GetRangeCountWithProof(start, end []byte) (count int64, proof *RangeCountProof, err error) {
+	if start != nil && end != nil && bytes.Compare(start, end) >= 0 {
+		return 0, nil, errors.Wrap(ErrInvalidInputs, "start must be before end")
+	}
+	if t.root != nil {
+		t.root.hashWithCount() // Ensure that all hashes are calculated.
+	}
+
+	startBoundary, startIndex, err := t.getRangeCountBoundary(start, false)
+	if err != nil {
+		return 0, nil, err
+	}
+	endBoundary, endIndex, err := t.getRangeCountBoundary(end, true)
+	if err != nil {
+		return 0, nil, err
+	}
+	return endIndex - startIndex, &RangeCountProof{Start: startBoundary, End: endBoundary}, nil
+}
+
+// getRangeCountBoundary builds the boundary for the key, returning it along with its index.
+func (t *ImmutableTree) getRangeCountBoundary(key []byte, isEnd bool) (*RangeCountBoundary, int64, error) {
+	boundary := &RangeCountBoundary{Key: key}
+	if t.root == nil {
+		return boundary, 0, nil
+	}
+
+	var index int64
+	switch {
+	case key != nil:
+		index, _ = t.Get(key)
+	case isEnd:
+		index = t.root.size
+	}
+
+	if index > 0 {
+		leaf, err := t.getRangeCountLeaf(index - 1)
+		if err != nil {
+			return nil, 0, err
+		}
+		boundary.Before = leaf
+	}
+	if index < t.root.size {
+		leaf, err := t.getRangeCountLeaf(index)
+		if err != nil {
+			return nil, 0, err
+		}
+		boundary.After = leaf
+	}
+	return boundary, index, nil
+}
+
+func (t *ImmutableTree) getRangeCountLeaf(index int64) (*RangeCountLeaf, error) {
+	key, _ := t.GetByIndex(index)
+	path, node, err := t.root.PathToLeaf(t, key)
+	if err != nil {
+		return nil, errors.Wrapf(err, "constructing path to leaf %v", index)
+	}
+	valueHash := sha256.Sum256(node.value)
+	return &RangeCountLeaf{
+		Path: path,
+		Leaf: ProofLeafNode{
+			Key:       node.key,
+			ValueHash: valueHash[:],
+			Version:   node.version,
+		},
+	}, nil
+}
+
+// Verify verifies that the proof is valid for the given root hash. It must be called before
+// VerifyCount().
+func (proof *RangeCountProof) Verify(root []byte) error {
+	if proof == nil {
+		return errors.Wrap(ErrInvalidProof, "proof is nil")
+	}
+	proof.rootVerified = false
+	if proof.Start == nil || proof.End == nil {
+		return errors.Wrap(ErrInvalidProof, "proof is missing boundaries")
+	}
+	if proof.Start.Key != nil && proof.End.Key != nil && bytes.Compare(proof.Start.Key, proof.End.Key) >= 0 {
+		return errors.Wrap(ErrInvalidProof, "start must be before end")
+	}
+	startIndex, err := proof.Start.verify(root, false)
+	if err != nil {
+		return errors.Wrap(err, "start boundary")
+	}
+	endIndex, err := proof.End.verify(root, true)
+	if err != nil {
+		return errors.Wrap(err, "end boundary")
+	}
+	if endIndex < startIndex {
+		return errors.Wrap(ErrInvalidProof, "end boundary before start boundary")
+	}
+	proof.count = endIndex - startIndex
+	proof.rootVerified = true
+	return nil
+}
+
+// VerifyCount verifies that the proof is for the range [start, end), and that the range contains
+// the given number of keys. Verify() must be called first.
+func (proof *RangeCountProof) VerifyCount(start, end []byte, count int64) error {
+	if proof == nil {
+		return errors.Wrap(ErrInvalidProof, "proof is nil")
+	}
+	if !proof.rootVerified {
+		return errors.New("must call Verify(root) first")
+	}
+	if !bytes.Equal(proof.Start.Key, start) || (proof.Start.Key == nil) != (start == nil) {
+		return errors.Wrap(ErrInvalidProof, "proof start not same")
+	}
+	if !bytes.Equal(proof.End.Key, end) || (proof.End.Key == nil) != (end == nil) {
+		return errors.Wrap(ErrInvalidProof, "proof end not same")
+	}
+	if proof.count != count {
+		return errors.Wrapf(ErrInvalidProof, "proof count is %v, not %v", proof.count, count)
+	}
+	return nil
+}
+
+// verify verifies the boundary against the root, and returns its index. A nil key is the start
+// of the tree for start boundaries, or the end of the tree for end boundaries.
+func (b *RangeCountBoundary) verify(root []byte, isEnd bool) (int64, error) {
+	if b.Before == nil && b.After == nil {
+		if len(root) != 0 {
+			return 0, errors.Wrap(ErrInvalidProof, "no leaves for non-empty root")
+		}
+		return 0, nil
+	}
+
+	if b.Before != nil {
+		if !bytes.Equal(b.Before.computeRootHash(), root) {
+			return 0, errors.Wrap(ErrInvalidRoot, "root hash of leaf before boundary doesn't match")
+		}
+		if b.Key == nil && !isEnd {
+			return 0, errors.Wrap(ErrInvalidProof, "leaf before start of tree")
+		}
+		if b.Key != nil && bytes.Compare(b.Before.Leaf.Key, b.Key) >= 0 {
+			return 0, errors.Wrap(ErrInvalidProof, "leaf before boundary isn't below key")
+		}
+	}
+	if b.After != nil {
+		if !bytes.Equal(b.After.computeRootHash(), root) {
+			return 0, errors.Wrap(ErrInvalidRoot, "root hash of leaf after boundary doesn't match")
+		}
+		if b.Key == nil && isEnd {
+			return 0, errors.Wrap(ErrInvalidProof, "leaf after end of tree")
+		}
+		if b.Key != nil && bytes.Compare(b.After.Leaf.Key, b.Key) < 0 {
+			return 0, errors.Wrap(ErrInvalidProof, "leaf after boundary is below key")
+		}
+	}
+
+	switch {
+	case b.Before == nil:
+		if !b.After.Path.IsLeftmost() {
+			return 0, errors.Wrap(ErrInvalidProof, "leaf before boundary missing, but leaf after isn't leftmost")
+		}
+		return 0, nil
+	case b.After == nil:
+		if !b.Before.Path.IsRightmost() {
+			return 0, errors.Wrap(ErrInvalidProof, "leaf after boundary missing, but leaf before isn't rightmost")
+		}
+		return b.Before.Path.Index() + 1, nil
+	default:
+		before, after := b.Before.Path.Index(), b.After.Path.Index()
+		if before < 0 || after != before+1 {
+			return 0, errors.Wrap(ErrInvalidProof, "leaves around boundary are not adjacent")
+		}
+		return after, nil
+	}
+}
@@ -0,0 +1,101 @@
+package iavl
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetRangeCountWithProof(t *testing.T) {
+	tree, err := getTestTree(0)
+	require.NoError(t, err)
+	for i := 0; i < 100; i += 2 {
+		tree.Set([]byte(fmt.Sprintf("key-%03d", i)), []byte(fmt.Sprintf("value-%d", i)))
+	}
+	_, _, err = tree.SaveVersion()
+	require.NoError(t, err)
+	root := tree.Hash()
+
+	testcases := []struct {
+		start, end string
+		count      int64
+	}{
+		{"", "", 50},
+		{"", "key-010", 5},
+		{"key-010", "", 45},
+		{"key-010", "key-020", 5},
+		{"key-011", "key-020", 4},
+		{"key-011", "key-021", 5},
+		{"key-011", "key-012", 0},
+		{"a", "z", 50},
+		{"key-098", "key-099", 1},
+		{"key-099", "", 0},
+		{"", "a", 0},
+	}
+	for _, tc := range testcases {
+		var start, end []byte
+		if tc.start != "" {
+			start = []byte(tc.start)
+		}
+		if tc.end != "" {
+			end = []byte(tc.end)
+		}
+		count, proof, err := tree.GetRangeCountWithProof(start, end)
+		require.NoError(t, err)
+		assert.Equal(t, tc.count, count, "range %q-%q", tc.start, tc.end)
+		require.NoError(t, proof.Verify(root), "range %q-%q", tc.start, tc.end)
+		assert.NoError(t, proof.VerifyCount(start, end, tc.count), "range %q-%q", tc.start, tc.end)
+		assert.Error(t, proof.VerifyCount(start, end, tc.count+1), "range %q-%q", tc.start, tc.end)
+		assert.Error(t, proof.VerifyCount([]byte("b"), end, tc.count), "range %q-%q", tc.start, tc.end)
+		assert.Error(t, proof.Verify([]byte("foo")), "range %q-%q", tc.start, tc.end)
+	}
+
+	_, _, err = tree.GetRangeCountWithProof([]byte("b"), []byte("a"))
+	require.Error(t, err)
+}
+
+func TestGetRangeCountWithProof_Invalid(t *testing.T) {
+	tree, err := getTestTree(0)
+	require.NoError(t, err)
+	for i := 0; i < 100; i++ {
+		tree.Set([]byte(fmt.Sprintf("key-%03d", i)), []byte(fmt.Sprintf("value-%d", i)))
+	}
+	_, _, err = tree.SaveVersion()
+	require.NoError(t, err)
+	root := tree.Hash()
+
+	_, proof, err := tree.GetRangeCountWithProof([]byte("key-010"), []byte("key-050"))
+	require.NoError(t, err)
+	require.Error(t, proof.VerifyCount([]byte("key-010"), []byte("key-050"), 40), "must verify root first")
+
+	// Replacing a boundary leaf with one further away must be detected.
+	_, other, err := tree.GetRangeCountWithProof([]byte("key-011"), []byte("key-050"))
+	require.NoError(t, err)
+	proof.Start.After = other.Start.After
+	require.Error(t, proof.Verify(root))
+
+	// So must dropping a boundary leaf that isn't at the edge of the tree.
+	_, proof, err = tree.GetRangeCountWithProof([]byte("key-010"), []byte("key-050"))
+	require.NoError(t, err)
+	proof.End.After = nil
+	require.Error(t, proof.Verify(root))
+
+	_, proof, err = tree.GetRangeCountWithProof([]byte("key-010"), []byte("key-050"))
+	require.NoError(t, err)
+	proof.Start.Before = nil
+	require.Error(t, proof.Verify(root))
+}
+
+func TestGetRangeCountWithProof_Empty(t *testing.T) {
+	tree, err := getTestTree(0)
+	require.NoError(t, err)
+
+	count, proof, err := tree.GetRangeCountWithProof(nil, nil)
+	require.NoError(t, err)
+	require.EqualValues(t, 0, count)
+	require.NoError(t, proof.Verify(nil))
+	require.NoError(t, proof.VerifyCount(nil, nil, 0))
+	require.Error(t, proof.Verify([]byte("foo")))
+}
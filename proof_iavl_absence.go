@@ -4,9 +4,14 @@ import (
 	"fmt"
 
 	"github.com/pkg/errors"
+
+	"github.com/tendermint/iavl/proofpb"
 )
 
-const ProofOpIAVLAbsence = "iavl:a"
+const (
+	ProofOpIAVLAbsence      = "iavl:a"
+	ProofOpIAVLAbsenceProto = "iavl:a:pb"
+)
 
 // IAVLAbsenceOp takes a key as its only argument
 //
@@ -20,6 +25,9 @@ type AbsenceOp struct {
 	// Proof is nil for an empty tree.
 	// The hash of an empty tree is nil.
 	Proof *RangeProof `json:"proof"`
+
+	// Encoding of Proof in ProofOp.Data.
+	encoding ProofOpEncoding
 }
 
 var _ ProofOperator = AbsenceOp{}
@@ -31,20 +39,59 @@ func NewAbsenceOp(key []byte, proof *RangeProof) AbsenceOp {
 	}
 }
 
-func AbsenceOpDecoder(pop ProofOp) (ProofOperator, error) {
-	if pop.Type != ProofOpIAVLAbsence {
-		return nil, errors.Errorf("unexpected ProofOp.Type; got %v, want %v", pop.Type, ProofOpIAVLAbsence)
+// NewAbsenceOpWithEncoding is like NewAbsenceOp, but encodes the proof with the given encoding in
+// ProofOp().
+func NewAbsenceOpWithEncoding(key []byte, proof *RangeProof, encoding ProofOpEncoding) AbsenceOp {
+	return AbsenceOp{
+		key:      key,
+		Proof:    proof,
+		encoding: encoding,
 	}
-	var op AbsenceOp // a bit strange as we'll discard this, but it works.
-	err := cdc.UnmarshalBinaryLengthPrefixed(pop.Data, &op)
-	if err != nil {
-		return nil, errors.Wrap(err, "decoding ProofOp.Data into IAVLAbsenceOp")
+}
+
+// AbsenceOpDecoder decodes a AbsenceOp from a ProofOp in either encoding.
+func AbsenceOpDecoder(pop ProofOp) (ProofOperator, error) {
+	switch pop.Type {
+	case ProofOpIAVLAbsence:
+		proof, err := unmarshalAminoRangeProofOp(pop.Data)
+		if err != nil {
+			return nil, errors.Wrap(err, "decoding ProofOp.Data into IAVLAbsenceOp")
+		}
+		return NewAbsenceOp(pop.Key, proof), nil
+
+	case ProofOpIAVLAbsenceProto:
+		var pbOp proofpb.AbsenceOp
+		err := pbOp.Unmarshal(pop.Data)
+		if err != nil {
+			return nil, errors.Wrap(err, "decoding ProofOp.Data into IAVLAbsenceOp")
+		}
+		proof, err := RangeProofFromProto(pbOp.Proof)
+		if err != nil {
+			return nil, errors.Wrap(err, "decoding ProofOp.Data into IAVLAbsenceOp")
+		}
+		return NewAbsenceOpWithEncoding(pop.Key, proof, ProofOpEncodingProto), nil
+
+	default:
+		return nil, errors.Errorf("unexpected ProofOp.Type; got %v, want %v or %v",
+			pop.Type, ProofOpIAVLAbsence, ProofOpIAVLAbsenceProto)
 	}
-	return NewAbsenceOp(pop.Key, op.Proof), nil
 }
 
 func (op AbsenceOp) ProofOp() ProofOp {
-	bz := cdc.MustMarshalBinaryLengthPrefixed(op)
+	if op.encoding == ProofOpEncodingProto {
+		pbOp := &proofpb.AbsenceOp{Proof: op.Proof.ToProto()}
+		bz, err := pbOp.Marshal()
+		if err != nil {
+			panic(err)
+		}
+		return ProofOp{
+			Type: ProofOpIAVLAbsenceProto,
+			Key:  op.key,
+			Data: bz,
+		}
+	}
+
+	bz := marshalAminoRangeProofOp(op.Proof)
 	return ProofOp{
 		Type: ProofOpIAVLAbsence,
 		Key:  op.key,
@@ -0,0 +1,155 @@
+package iavl
+
+import (
+	"encoding/hex"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/tendermint/iavl/proofpb"
+)
+
+func TestRangeProof_Proto(t *testing.T) {
+	tree, err := getTestTree(0)
+	require.NoError(t, err)
+	for i := 0; i < 100; i += 2 {
+		tree.Set([]byte(fmt.Sprintf("key-%03d", i)), []byte(fmt.Sprintf("value-%d", i)))
+	}
+	_, _, err = tree.SaveVersion()
+	require.NoError(t, err)
+	root := tree.Hash()
+
+	_, _, rangeProof, err := tree.GetRangeWithProof([]byte("key-011"), []byte("key-051"), 0)
+	require.NoError(t, err)
+	_, existProof, err := tree.GetWithProof([]byte("key-050"))
+	require.NoError(t, err)
+	_, absentProof, err := tree.GetWithProof([]byte("key-051"))
+	require.NoError(t, err)
+
+	for _, proof := range []*RangeProof{rangeProof, existProof, absentProof} {
+		bz, err := proof.ToProto().Marshal()
+		require.NoError(t, err)
+		pbProof := &proofpb.RangeProof{}
+		require.NoError(t, pbProof.Unmarshal(bz))
+		decoded, err := RangeProofFromProto(pbProof)
+		require.NoError(t, err)
+
+		assert.Equal(t, proof.LeftPath, decoded.LeftPath)
+		assert.Equal(t, proof.InnerNodes, decoded.InnerNodes)
+		assert.Equal(t, proof.Leaves, decoded.Leaves)
+		require.NoError(t, decoded.Verify(root))
+	}
+
+	decoded, err := RangeProofFromProto(nil)
+	require.NoError(t, err)
+	require.Nil(t, decoded)
+
+	_, err = RangeProofFromProto(&proofpb.RangeProof{
+		LeftPath: &proofpb.PathToLeaf{Inners: []*proofpb.ProofInnerNode{{Height: 1000}}},
+	})
+	require.Error(t, err)
+}
+
+func TestProofOps_Encoding(t *testing.T) {
+	tree, err := getTestTree(0)
+	require.NoError(t, err)
+	for i := 0; i < 100; i += 2 {
+		tree.Set([]byte(fmt.Sprintf("key-%03d", i)), []byte(fmt.Sprintf("value-%d", i)))
+	}
+	_, _, err = tree.SaveVersion()
+	require.NoError(t, err)
+	root := tree.Hash()
+
+	key, absentKey := []byte("key-050"), []byte("key-051")
+	value, existProof, err := tree.GetWithProof(key)
+	require.NoError(t, err)
+	_, absentProof, err := tree.GetWithProof(absentKey)
+	require.NoError(t, err)
+
+	testcases := map[string]struct {
+		encoding    ProofOpEncoding
+		valueType   string
+		absenceType string
+	}{
+		"amino": {ProofOpEncodingAmino, ProofOpIAVLValue, ProofOpIAVLAbsence},
+		"proto": {ProofOpEncodingProto, ProofOpIAVLValueProto, ProofOpIAVLAbsenceProto},
+	}
+	for name, tc := range testcases {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			valuePop := NewValueOpWithEncoding(key, existProof, tc.encoding).ProofOp()
+			require.Equal(t, tc.valueType, valuePop.Type)
+			op, err := ValueOpDecoder(valuePop)
+			require.NoError(t, err)
+			require.Equal(t, valuePop, op.ProofOp(), "decoded op should keep its encoding")
+			roots, err := op.Run([][]byte{value})
+			require.NoError(t, err)
+			require.Equal(t, [][]byte{root}, roots)
+			_, err = op.Run([][]byte{[]byte("foo")})
+			require.Error(t, err)
+
+			absencePop := NewAbsenceOpWithEncoding(absentKey, absentProof, tc.encoding).ProofOp()
+			require.Equal(t, tc.absenceType, absencePop.Type)
+			op, err = AbsenceOpDecoder(absencePop)
+			require.NoError(t, err)
+			roots, err = op.Run(nil)
+			require.NoError(t, err)
+			require.Equal(t, [][]byte{root}, roots)
+
+			_, err = ValueOpDecoder(absencePop)
+			require.Error(t, err)
+			_, err = AbsenceOpDecoder(valuePop)
+			require.Error(t, err)
+
+			// The protobuf encoding can be decoded with the generated types alone.
+			if tc.encoding == ProofOpEncodingProto {
+				pbOp := &proofpb.ValueOp{}
+				require.NoError(t, pbOp.Unmarshal(valuePop.Data))
+				require.Len(t, pbOp.Proof.Leaves, 1)
+				require.Equal(t, key, pbOp.Proof.Leaves[0].Key)
+			}
+		})
+	}
+
+	// An empty tree has a nil proof, and all keys are absent.
+	op, err := AbsenceOpDecoder(NewAbsenceOpWithEncoding(absentKey, nil, ProofOpEncodingProto).ProofOp())
+	require.NoError(t, err)
+	roots, err := op.Run(nil)
+	require.NoError(t, err)
+	require.Equal(t, [][]byte{nil}, roots)
+}
+
+// TestValueOp_AminoGolden checks the amino encoding of ProofOps against data produced with
+// go-amino, so that existing proofs keep decoding.
+func TestValueOp_AminoGolden(t *testing.T) {
+	tree, err := getTestTree(0)
+	require.NoError(t, err)
+	for i := 0; i < 5; i++ {
+		tree.Set([]byte(fmt.Sprintf("key-%d", i)), []byte(fmt.Sprintf("value-%d", i)))
+	}
+	_, _, err = tree.SaveVersion()
+	require.NoError(t, err)
+	root := tree.Hash()
+	require.Equal(t, "dc11c5141788dd6aa25036c0604aa8c5ae02656f2cae7c0a616f11de8390b3bf", hex.EncodeToString(root))
+
+	value, proof, err := tree.GetWithProof([]byte("key-2"))
+	require.NoError(t, err)
+	pop := NewValueOp([]byte("key-2"), proof).ProofOp()
+	require.Equal(t, "84010a81010a2808061005180122208ac7969452f45e5db1c106063146a1cdf1322b0ed71cbc077a7d32c0124"+
+		"8c7e80a280804100318012a207d0b0fb18797119459823d307456f7274f911060fc8c326689fae4104a5377c01a2b0a056"+
+		"b65792d32122050d8aa76c5b9dd3c1c41abade6b1a68272d55cd3a05c7eb1cf78d57d232f720a1801", hex.EncodeToString(pop.Data))
+
+	op, err := ValueOpDecoder(pop)
+	require.NoError(t, err)
+	roots, err := op.Run([][]byte{value})
+	require.NoError(t, err)
+	require.Equal(t, [][]byte{root}, roots)
+
+	// A nil proof (empty tree) encodes as an empty op.
+	pop = NewAbsenceOp([]byte("key"), nil).ProofOp()
+	require.Equal(t, []byte{0x00}, pop.Data)
+	_, err = AbsenceOpDecoder(ProofOp{Type: ProofOpIAVLAbsence, Data: []byte{0x01}})
+	require.Error(t, err)
+}
@@ -4,9 +4,14 @@ import (
 	"fmt"
 
 	"github.com/pkg/errors"
+
+	"github.com/tendermint/iavl/proofpb"
 )
 
-const ProofOpIAVLValue = "iavl:v"
+const (
+	ProofOpIAVLValue      = "iavl:v"
+	ProofOpIAVLValueProto = "iavl:v:pb"
+)
 
 // IAVLValueOp takes a key and a single value as argument and
 // produces the root hash.
@@ -21,6 +26,9 @@ type ValueOp struct {
 	// Proof is nil for an empty tree.
 	// The hash of an empty tree is nil.
 	Proof *RangeProof `json:"proof"`
+
+	// Encoding of Proof in ProofOp.Data.
+	encoding ProofOpEncoding
 }
 
 var _ ProofOperator = ValueOp{}
@@ -32,20 +40,59 @@ func NewValueOp(key []byte, proof *RangeProof) ValueOp {
 	}
 }
 
-func ValueOpDecoder(pop ProofOp) (ProofOperator, error) {
-	if pop.Type != ProofOpIAVLValue {
-		return nil, errors.Errorf("unexpected ProofOp.Type; got %v, want %v", pop.Type, ProofOpIAVLValue)
+// NewValueOpWithEncoding is like NewValueOp, but encodes the proof with the given encoding in
+// ProofOp().
+func NewValueOpWithEncoding(key []byte, proof *RangeProof, encoding ProofOpEncoding) ValueOp {
+	return ValueOp{
+		key:      key,
+		Proof:    proof,
+		encoding: encoding,
 	}
-	var op ValueOp // a bit strange as we'll discard this, but it works.
-	err := cdc.UnmarshalBinaryLengthPrefixed(pop.Data, &op)
-	if err != nil {
-		return nil, errors.Wrap(err, "decoding ProofOp.Data into IAVLValueOp")
+}
+
+// ValueOpDecoder decodes a ValueOp from a ProofOp in either encoding.
+func ValueOpDecoder(pop ProofOp) (ProofOperator, error) {
+	switch pop.Type {
+	case ProofOpIAVLValue:
+		proof, err := unmarshalAminoRangeProofOp(pop.Data)
+		if err != nil {
+			return nil, errors.Wrap(err, "decoding ProofOp.Data into IAVLValueOp")
+		}
+		return NewValueOp(pop.Key, proof), nil
+
+	case ProofOpIAVLValueProto:
+		var pbOp proofpb.ValueOp
+		err := pbOp.Unmarshal(pop.Data)
+		if err != nil {
+			return nil, errors.Wrap(err, "decoding ProofOp.Data into IAVLValueOp")
+		}
+		proof, err := RangeProofFromProto(pbOp.Proof)
+		if err != nil {
+			return nil, errors.Wrap(err, "decoding ProofOp.Data into IAVLValueOp")
+		}
+		return NewValueOpWithEncoding(pop.Key, proof, ProofOpEncodingProto), nil
+
+	default:
+		return nil, errors.Errorf("unexpected ProofOp.Type; got %v, want %v or %v",
+			pop.Type, ProofOpIAVLValue, ProofOpIAVLValueProto)
 	}
-	return NewValueOp(pop.Key, op.Proof), nil
 }
 
 func (op ValueOp) ProofOp() ProofOp {
-	bz := cdc.MustMarshalBinaryLengthPrefixed(op)
+	if op.encoding == ProofOpEncodingProto {
+		pbOp := &proofpb.ValueOp{Proof: op.Proof.ToProto()}
+		bz, err := pbOp.Marshal()
+		if err != nil {
+			panic(err)
+		}
+		return ProofOp{
+			Type: ProofOpIAVLValueProto,
+			Key:  op.key,
+			Data: bz,
+		}
+	}
+
+	bz := marshalAminoRangeProofOp(op.Proof)
 	return ProofOp{
 		Type: ProofOpIAVLValue,
 		Key:  op.key,
@@ -0,0 +1,237 @@
+package iavl
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"sort"
+
+	"github.com/pkg/errors"
+)
+
+// MultiProofNode is a node of a MultiProof. It is either an inner node, a leaf node (Height 0),
+// or a pruned subtree which is only represented by its Hash.
+type MultiProofNode struct {
+	Height    int8   `json:"height"`
+	Size      int64  `json:"size"`
+	Version   int64  `json:"version"`
+	Key       []byte `json:"key,omitempty"`        // leaf nodes only
+	ValueHash []byte `json:"value_hash,omitempty"` // leaf nodes only
+	Hash      []byte `json:"hash,omitempty"`       // pruned subtrees only
+}
+
+// MultiProof proves the existence or absence of several keys against a single root. It is a
+// partial tree, where the subtrees not needed by any of the proven keys are pruned and replaced by
+// their hash, so that inner nodes shared by several keys are only included once.
+type MultiProof struct {
+	// Nodes contains the partial tree in depth-first pre-order (NLR). It is empty for an empty
+	// tree.
+	Nodes []MultiProofNode `json:"nodes"`
+
+	// memoize
+	rootVerified bool
+	leaves       []ProofLeafNode // in order, valid iff rootVerified is true
+	gaps         []bool          // pruned subtrees before leaves[i], or after the last leaf at len(leaves)
+}
+
+// GetMultiWithProof gets the values of the given keys, with nil for absent keys, along with a
+// single proof of their existence or absence.
+func (t *ImmutableTree) GetMultiWithProof(keys [][]byte) (values [][]byte, proof *MultiProof, err error) {
+	values = make([][]byte, len(keys))
+	if t.root == nil {
+		return values, &MultiProof{}, nil
+	}
+	t.root.hashWithCount() // Ensure that all hashes are calculated.
+
+	// Collect the indexes of the leaves needed to prove each key: the leaf itself for existing
+	// keys, and the neighbouring leaves for absent keys.
+	needed := map[int64]bool{}
+	for i, key := range keys {
+		if key == nil {
+			return nil, nil, errors.Wrapf(ErrInvalidInputs, "key %d is nil", i)
+		}
+		index, value := t.Get(key)
+		values[i] = value
+		if value != nil {
+			needed[index] = true
+			continue
+		}
+		if index > 0 {
+			needed[index-1] = true
+		}
+		if index < t.root.size {
+			needed[index] = true
+		}
+	}
+	indexes := make([]int64, 0, len(needed))
+	for index := range needed {
+		indexes = append(indexes, index)
+	}
+	sort.Slice(indexes, func(i, j int) bool { return indexes[i] < indexes[j] })
+
+	proof = &MultiProof{}
+	t.root.buildMultiProof(t, 0, indexes, proof)
+	return values, proof, nil
+}
+
+// buildMultiProof appends the node to the proof, pruning it if none of the given sorted leaf
+// indexes are within it. offset is the index of the leftmost leaf of the node.
+func (node *Node) buildMultiProof(t *ImmutableTree, offset int64, indexes []int64, proof *MultiProof) {
+	i := sort.Search(len(indexes), func(i int) bool { return indexes[i] >= offset })
+	if i >= len(indexes) || indexes[i] >= offset+node.size {
+		proof.Nodes = append(proof.Nodes, MultiProofNode{Hash: node.hash})
+		return
+	}
+	if node.isLeaf() {
+		valueHash := sha256.Sum256(node.value)
+		proof.Nodes = append(proof.Nodes, MultiProofNode{
+			Height:    node.height,
+			Size:      node.size,
+			Version:   node.version,
+			Key:       node.key,
+			ValueHash: valueHash[:],
+		})
+		return
+	}
+	proof.Nodes = append(proof.Nodes, MultiProofNode{
+		Height:  node.height,
+		Size:    node.size,
+		Version: node.version,
+	})
+	leftNode := node.getLeftNode(t)
+	leftNode.buildMultiProof(t, offset, indexes, proof)
+	node.getRightNode(t).buildMultiProof(t, offset+leftNode.size, indexes, proof)
+}
+
+// Keys returns the keys of all leaves in the proof, in order. These may include neighbouring
+// keys used to prove the absence of a key.
+func (proof *MultiProof) Keys() (keys [][]byte) {
+	if proof == nil {
+		return nil
+	}
+	for _, node := range proof.Nodes {
+		if len(node.Hash) == 0 && node.Height == 0 {
+			keys = append(keys, node.Key)
+		}
+	}
+	return keys
+}
+
+// Verify verifies that the proof is valid for the given root hash. It must be called before
+// VerifyItem() and VerifyAbsence().
+func (proof *MultiProof) Verify(root []byte) error {
+	if proof == nil {
+		return errors.Wrap(ErrInvalidProof, "proof is nil")
+	}
+	proof.rootVerified = false
+	proof.leaves = nil
+	proof.gaps = []bool{false}
+
+	if len(proof.Nodes) == 0 {
+		if len(root) != 0 {
+			return errors.Wrap(ErrInvalidRoot, "empty proof for non-empty root")
+		}
+		proof.rootVerified = true
+		return nil
+	}
+
+	rootHash, n, err := proof.computeHash(0)
+	if err != nil {
+		return err
+	}
+	if n != len(proof.Nodes) {
+		return errors.Wrapf(ErrInvalidProof, "found %d unused nodes", len(proof.Nodes)-n)
+	}
+	for i := 1; i < len(proof.leaves); i++ {
+		if bytes.Compare(proof.leaves[i-1].Key, proof.leaves[i].Key) >= 0 {
+			return errors.Wrap(ErrInvalidProof, "leaf keys are not ordered")
+		}
+	}
+	if !bytes.Equal(rootHash, root) {
+		return errors.Wrap(ErrInvalidRoot, "root hash doesn't match")
+	}
+	proof.rootVerified = true
+	return nil
+}
+
+// computeHash computes the hash of the subtree starting at the given node index, returning the
+// hash and the index following the subtree. Leaves and pruned gaps are recorded in the proof.
+func (proof *MultiProof) computeHash(i int) ([]byte, int, error) {
+	if i >= len(proof.Nodes) {
+		return nil, 0, errors.Wrap(ErrInvalidProof, "proof is missing nodes")
+	}
+	node := proof.Nodes[i]
+	switch {
+	case len(node.Hash) > 0:
+		proof.gaps[len(proof.gaps)-1] = true
+		return node.Hash, i + 1, nil
+
+	case node.Height == 0:
+		if node.Size != 1 {
+			return nil, 0, errors.Wrapf(ErrInvalidProof, "leaf node %d has size %d", i, node.Size)
+		}
+		leaf := ProofLeafNode{Key: node.Key, ValueHash: node.ValueHash, Version: node.Version}
+		proof.leaves = append(proof.leaves, leaf)
+		proof.gaps = append(proof.gaps, false)
+		return leaf.Hash(), i + 1, nil
+
+	case node.Height > 0:
+		leftHash, next, err := proof.computeHash(i + 1)
+		if err != nil {
+			return nil, 0, err
+		}
+		rightHash, next, err := proof.computeHash(next)
+		if err != nil {
+			return nil, 0, err
+		}
+		pin := ProofInnerNode{Height: node.Height, Size: node.Size, Version: node.Version, Left: leftHash}
+		return pin.Hash(rightHash), next, nil
+
+	default:
+		return nil, 0, errors.Wrapf(ErrInvalidProof, "node %d has negative height", i)
+	}
+}
+
+// VerifyItem verifies that the key has the given value. Verify() must be called first.
+func (proof *MultiProof) VerifyItem(key, value []byte) error {
+	if proof == nil {
+		return errors.Wrap(ErrInvalidProof, "proof is nil")
+	}
+	if !proof.rootVerified {
+		return errors.New("must call Verify(root) first")
+	}
+	i := proof.search(key)
+	if i >= len(proof.leaves) || !bytes.Equal(proof.leaves[i].Key, key) {
+		return errors.Wrap(ErrInvalidProof, "leaf key not found in proof")
+	}
+	valueHash := sha256.Sum256(value)
+	if !bytes.Equal(proof.leaves[i].ValueHash, valueHash[:]) {
+		return errors.Wrap(ErrInvalidProof, "leaf value hash not same")
+	}
+	return nil
+}
+
+// VerifyAbsence verifies that the key does not exist, by checking that the leaves on either
+// side of it are adjacent in the tree. Verify() must be called first.
+func (proof *MultiProof) VerifyAbsence(key []byte) error {
+	if proof == nil {
+		return errors.Wrap(ErrInvalidProof, "proof is nil")
+	}
+	if !proof.rootVerified {
+		return errors.New("must call Verify(root) first")
+	}
+	i := proof.search(key)
+	if i < len(proof.leaves) && bytes.Equal(proof.leaves[i].Key, key) {
+		return errors.New("absence disproved by existing leaf")
+	}
+	if proof.gaps[i] {
+		return errors.Wrap(ErrInvalidProof, "absence not proved by adjacent leaves")
+	}
+	return nil
+}
+
+// search returns the index of the first leaf with a key greater than or equal to the given key.
+func (proof *MultiProof) search(key []byte) int {
+	return sort.Search(len(proof.leaves), func(i int) bool {
+		return bytes.Compare(key, proof.leaves[i].Key) <= 0
+	})
+}
@@ -0,0 +1,117 @@
+package iavl
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func setupMultiProofTree(t *testing.T) *MutableTree {
+	tree, err := getTestTree(0)
+	require.NoError(t, err)
+	for i := 0; i < 1000; i += 2 {
+		tree.Set([]byte(fmt.Sprintf("key-%04d", i)), []byte(fmt.Sprintf("value-%d", i)))
+	}
+	_, _, err = tree.SaveVersion()
+	require.NoError(t, err)
+	return tree
+}
+
+func TestGetMultiWithProof(t *testing.T) {
+	tree := setupMultiProofTree(t)
+	root := tree.Hash()
+
+	keys := [][]byte{
+		[]byte("key-0000"), []byte("key-0002"), []byte("key-0500"), []byte("key-0998"), // existing
+		[]byte("key-0001"), []byte("key-0777"), []byte("a"), []byte("z"), // absent
+	}
+	values, proof, err := tree.GetMultiWithProof(keys)
+	require.NoError(t, err)
+	require.Len(t, values, len(keys))
+	require.NoError(t, proof.Verify(root))
+
+	for i, key := range keys {
+		if i < 4 {
+			require.NotNil(t, values[i])
+			assert.NoError(t, proof.VerifyItem(key, values[i]), "key %s", key)
+			assert.Error(t, proof.VerifyAbsence(key), "key %s", key)
+			assert.Error(t, proof.VerifyItem(key, []byte("other")), "key %s", key)
+		} else {
+			require.Nil(t, values[i])
+			assert.NoError(t, proof.VerifyAbsence(key), "key %s", key)
+			assert.Error(t, proof.VerifyItem(key, nil), "key %s", key)
+		}
+	}
+
+	// Keys not covered by the proof can't be verified.
+	assert.Error(t, proof.VerifyAbsence([]byte("key-0301")))
+	assert.Error(t, proof.VerifyItem([]byte("key-0300"), []byte("value-300")))
+
+	// The proof should be smaller than separate proofs for each key.
+	pathNodes := 0
+	for _, key := range keys {
+		_, rproof, err := tree.GetWithProof(key)
+		require.NoError(t, err)
+		pathNodes += len(rproof.LeftPath)
+		for _, path := range rproof.InnerNodes {
+			pathNodes += len(path)
+		}
+	}
+	innerNodes := 0
+	for _, node := range proof.Nodes {
+		if node.Height > 0 {
+			innerNodes++
+		}
+	}
+	assert.Less(t, innerNodes, pathNodes)
+}
+
+func TestGetMultiWithProof_Invalid(t *testing.T) {
+	tree := setupMultiProofTree(t)
+	root := tree.Hash()
+	keys := [][]byte{[]byte("key-0010"), []byte("key-0011")}
+
+	_, proof, err := tree.GetMultiWithProof(keys)
+	require.NoError(t, err)
+	require.Error(t, proof.VerifyItem(keys[0], []byte("value-10")), "must verify root first")
+	require.Error(t, proof.Verify([]byte("foo")))
+	require.NoError(t, proof.Verify(root))
+
+	// Tampering with a leaf value must be detected.
+	for i, node := range proof.Nodes {
+		if node.Height == 0 && len(node.Hash) == 0 {
+			proof.Nodes[i].ValueHash = []byte("foo")
+			break
+		}
+	}
+	require.Error(t, proof.Verify(root))
+
+	// So must truncated and padded proofs.
+	_, proof, err = tree.GetMultiWithProof(keys)
+	require.NoError(t, err)
+	nodes := proof.Nodes
+	proof.Nodes = nodes[:len(nodes)-1]
+	require.Error(t, proof.Verify(root))
+	proof.Nodes = append(nodes, MultiProofNode{Hash: root})
+	require.Error(t, proof.Verify(root))
+
+	_, _, err = tree.GetMultiWithProof([][]byte{nil})
+	require.Error(t, err)
+
+	var nilProof *MultiProof
+	require.Error(t, nilProof.Verify(root))
+}
+
+func TestGetMultiWithProof_Empty(t *testing.T) {
+	tree, err := getTestTree(0)
+	require.NoError(t, err)
+
+	values, proof, err := tree.GetMultiWithProof([][]byte{[]byte("a")})
+	require.NoError(t, err)
+	require.Equal(t, [][]byte{nil}, values)
+	require.NoError(t, proof.Verify(nil))
+	require.NoError(t, proof.VerifyAbsence([]byte("a")))
+	require.Error(t, proof.Verify([]byte("foo")))
+}
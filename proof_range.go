@@ -3,310 +3,10 @@ package iavl
 import (
 	"bytes"
 	"crypto/sha256"
-	"fmt"
-	"sort"
-	"strings"
 
 	"github.com/pkg/errors"
 )
 
-type RangeProof struct {
-	// You don't need the right path because
-	// it can be derived from what we have.
-	LeftPath   PathToLeaf      `json:"left_path"`
-	InnerNodes []PathToLeaf    `json:"inner_nodes"`
-	Leaves     []ProofLeafNode `json:"leaves"`
-
-	// memoize
-	rootHash     []byte // valid iff rootVerified is true
-	rootVerified bool
-	treeEnd      bool // valid iff rootVerified is true
-
-}
-
-// Keys returns all the keys in the RangeProof.  NOTE: The keys here may
-// include more keys than provided by tree.GetRangeWithProof or
-// MutableTree.GetVersionedRangeWithProof.  The keys returned there are only
-// in the provided [startKey,endKey){limit} range.  The keys returned here may
-// include extra keys, such as:
-// - the key before startKey if startKey is provided and doesn't exist;
-// - the key after a queried key with tree.GetWithProof, when the key is absent.
-func (proof *RangeProof) Keys() (keys [][]byte) {
-	if proof == nil {
-		return nil
-	}
-	for _, leaf := range proof.Leaves {
-		keys = append(keys, leaf.Key)
-	}
-	return keys
-}
-
-// String returns a string representation of the proof.
-func (proof *RangeProof) String() string {
-	if proof == nil {
-		return "<nil-RangeProof>"
-	}
-	return proof.StringIndented("")
-}
-
-func (proof *RangeProof) StringIndented(indent string) string {
-	istrs := make([]string, 0, len(proof.InnerNodes))
-	for _, ptl := range proof.InnerNodes {
-		istrs = append(istrs, ptl.stringIndented(indent+"    "))
-	}
-	lstrs := make([]string, 0, len(proof.Leaves))
-	for _, leaf := range proof.Leaves {
-		lstrs = append(lstrs, leaf.stringIndented(indent+"    "))
-	}
-	return fmt.Sprintf(`RangeProof{
-%s  LeftPath: %v
-%s  InnerNodes:
-%s    %v
-%s  Leaves:
-%s    %v
-%s  (rootVerified): %v
-%s  (rootHash): %X
-%s  (treeEnd): %v
-%s}`,
-		indent, proof.LeftPath.stringIndented(indent+"  "),
-		indent,
-		indent, strings.Join(istrs, "\n"+indent+"    "),
-		indent,
-		indent, strings.Join(lstrs, "\n"+indent+"    "),
-		indent, proof.rootVerified,
-		indent, proof.rootHash,
-		indent, proof.treeEnd,
-		indent)
-}
-
-// The index of the first leaf (of the whole tree).
-// Returns -1 if the proof is nil.
-func (proof *RangeProof) LeftIndex() int64 {
-	if proof == nil {
-		return -1
-	}
-	return proof.LeftPath.Index()
-}
-
-// Also see LeftIndex().
-// Verify that a key has some value.
-// Does not assume that the proof itself is valid, call Verify() first.
-func (proof *RangeProof) VerifyItem(key, value []byte) error {
-	if proof == nil {
-		return errors.Wrap(ErrInvalidProof, "proof is nil")
-	}
-	if !proof.rootVerified {
-		return errors.New("must call Verify(root) first")
-	}
-	leaves := proof.Leaves
-	i := sort.Search(len(leaves), func(i int) bool {
-		return bytes.Compare(key, leaves[i].Key) <= 0
-	})
-	if i >= len(leaves) || !bytes.Equal(leaves[i].Key, key) {
-		return errors.Wrap(ErrInvalidProof, "leaf key not found in proof")
-	}
-
-	h := sha256.Sum256(value)
-	valueHash := h[:]
-	if !bytes.Equal(leaves[i].ValueHash, valueHash) {
-		return errors.Wrap(ErrInvalidProof, "leaf value hash not same")
-	}
-
-	return nil
-}
-
-// Verify that proof is valid absence proof for key.
-// Does not assume that the proof itself is valid.
-// For that, use Verify(root).
-func (proof *RangeProof) VerifyAbsence(key []byte) error {
-	if proof == nil {
-		return errors.Wrap(ErrInvalidProof, "proof is nil")
-	}
-	if !proof.rootVerified {
-		return errors.New("must call Verify(root) first")
-	}
-	cmp := bytes.Compare(key, proof.Leaves[0].Key)
-	if cmp < 0 {
-		if proof.LeftPath.isLeftmost() {
-			return nil
-		}
-		return errors.New("absence not proved by left path")
-
-	} else if cmp == 0 {
-		return errors.New("absence disproved via first item #0")
-	}
-	if len(proof.LeftPath) == 0 {
-		return nil // proof ok
-	}
-	if proof.LeftPath.isRightmost() {
-		return nil
-	}
-
-	// See if any of the leaves are greater than key.
-	for i := 1; i < len(proof.Leaves); i++ {
-		leaf := proof.Leaves[i]
-		cmp := bytes.Compare(key, leaf.Key)
-		switch {
-		case cmp < 0:
-			return nil // proof ok
-		case cmp == 0:
-			return errors.New(fmt.Sprintf("absence disproved via item #%v", i))
-		default:
-			// if i == len(proof.Leaves)-1 {
-			// If last item, check whether
-			// it's the last item in the tree.
-
-			// }
-			continue
-		}
-	}
-
-	// It's still a valid proof if our last leaf is the rightmost child.
-	if proof.treeEnd {
-		return nil // OK!
-	}
-
-	// It's not a valid absence proof.
-	if len(proof.Leaves) < 2 {
-		return errors.New("absence not proved by right leaf (need another leaf?)")
-	}
-	return errors.New("absence not proved by right leaf")
-
-}
-
-// Verify that proof is valid.
-func (proof *RangeProof) Verify(root []byte) error {
-	if proof == nil {
-		return errors.Wrap(ErrInvalidProof, "proof is nil")
-	}
-	err := proof.verify(root)
-	return err
-}
-
-func (proof *RangeProof) verify(root []byte) (err error) {
-	rootHash := proof.rootHash
-	if rootHash == nil {
-		derivedHash, err := proof.computeRootHash()
-		if err != nil {
-			return err
-		}
-		rootHash = derivedHash
-	}
-	if !bytes.Equal(rootHash, root) {
-		return errors.Wrap(ErrInvalidRoot, "root hash doesn't match")
-	}
-	proof.rootVerified = true
-	return nil
-}
-
-// ComputeRootHash computes the root hash with leaves.
-// Returns nil if error or proof is nil.
-// Does not verify the root hash.
-func (proof *RangeProof) ComputeRootHash() []byte {
-	if proof == nil {
-		return nil
-	}
-	rootHash, _ := proof.computeRootHash()
-	return rootHash
-}
-
-func (proof *RangeProof) computeRootHash() (rootHash []byte, err error) {
-	rootHash, treeEnd, err := proof._computeRootHash()
-	if err == nil {
-		proof.rootHash = rootHash // memoize
-		proof.treeEnd = treeEnd   // memoize
-	}
-	return rootHash, err
-}
-
-func (proof *RangeProof) _computeRootHash() (rootHash []byte, treeEnd bool, err error) {
-	if len(proof.Leaves) == 0 {
-		return nil, false, errors.Wrap(ErrInvalidProof, "no leaves")
-	}
-	if len(proof.InnerNodes)+1 != len(proof.Leaves) {
-		return nil, false, errors.Wrap(ErrInvalidProof, "InnerNodes vs Leaves length mismatch, leaves should be 1 more.")
-	}
-
-	// Start from the left path and prove each leaf.
-
-	// shared across recursive calls
-	var leaves = proof.Leaves
-	var innersq = proof.InnerNodes
-	var COMPUTEHASH func(path PathToLeaf, rightmost bool) (hash []byte, treeEnd bool, done bool, err error)
-
-	// rightmost: is the root a rightmost child of the tree?
-	// treeEnd: true iff the last leaf is the last item of the tree.
-	// Returns the (possibly intermediate, possibly root) hash.
-	COMPUTEHASH = func(path PathToLeaf, rightmost bool) (hash []byte, treeEnd bool, done bool, err error) {
-
-		// Pop next leaf.
-		nleaf, rleaves := leaves[0], leaves[1:]
-		leaves = rleaves
-
-		// Compute hash.
-		hash = (pathWithLeaf{
-			Path: path,
-			Leaf: nleaf,
-		}).computeRootHash()
-
-		// If we don't have any leaves left, we're done.
-		if len(leaves) == 0 {
-			rightmost = rightmost && path.isRightmost()
-			return hash, rightmost, true, nil
-		}
-
-		// Prove along path (until we run out of leaves).
-		for len(path) > 0 {
-
-			// Drop the leaf-most (last-most) inner nodes from path
-			// until we encounter one with a left hash.
-			// We assume that the left side is already verified.
-			// rpath: rest of path
-			// lpath: last path item
-			rpath, lpath := path[:len(path)-1], path[len(path)-1]
-			path = rpath
-			if len(lpath.Right) == 0 {
-				continue
-			}
-
-			// Pop next inners, a PathToLeaf (e.g. []ProofInnerNode).
-			inners, rinnersq := innersq[0], innersq[1:]
-			innersq = rinnersq
-
-			// Recursively verify inners against remaining leaves.
-			derivedRoot, treeEnd, done, err := COMPUTEHASH(inners, rightmost && rpath.isRightmost())
-			if err != nil {
-				return nil, treeEnd, false, errors.Wrap(err, "recursive COMPUTEHASH call")
-			}
-			if !bytes.Equal(derivedRoot, lpath.Right) {
-				return nil, treeEnd, false, errors.Wrapf(ErrInvalidRoot, "intermediate root hash %X doesn't match, got %X", lpath.Right, derivedRoot)
-			}
-			if done {
-				return hash, treeEnd, true, nil
-			}
-		}
-
-		// We're not done yet (leaves left over). No error, not done either.
-		// Technically if rightmost, we know there's an error "left over leaves
-		// -- malformed proof", but we return that at the top level, below.
-		return hash, false, false, nil
-	}
-
-	// Verify!
-	path := proof.LeftPath
-	rootHash, treeEnd, done, err := COMPUTEHASH(path, true)
-	if err != nil {
-		return nil, treeEnd, errors.Wrap(err, "root COMPUTEHASH call")
-	} else if !done {
-		return nil, treeEnd, errors.Wrap(ErrInvalidProof, "left over leaves -- malformed proof")
-	}
-
-	// Ok!
-	return rootHash, treeEnd, nil
-}
-
-///////////////////////////////////////////////////////////////////////////////
-
 // keyStart is inclusive and keyEnd is exclusive.
 // If keyStart or keyEnd don't exist, the leaf before keyStart
 // or after keyEnd will also be included, but not be included in values.
@@ -473,6 +173,42 @@ func (t *ImmutableTree) GetWithProof(key []byte) (value []byte, proof *RangeProo
 	return nil, proof, nil
 }
 
+// GetByIndexWithProof gets the key and value at the specified index, along with a proof of
+// their position in the tree. See RangeProof.VerifyItemAtIndex().
+func (t *ImmutableTree) GetByIndexWithProof(index int64) (key, value []byte, proof *RangeProof, err error) {
+	if index < 0 || index >= t.Size() {
+		return nil, nil, nil, errors.Wrapf(ErrInvalidInputs, "index %v out of range", index)
+	}
+	key, value = t.GetByIndex(index)
+	proof, _, _, err = t.getRangeProof(key, nil, 1)
+	if err != nil {
+		return nil, nil, nil, errors.Wrap(err, "constructing range proof")
+	}
+	return key, value, proof, nil
+}
+
+// GetIndexRangeWithProof gets the key/value pairs with indexes in [start, end), along with a
+// proof of their positions in the tree. The end is capped at the tree size. See
+// RangeProof.VerifyIndexRange().
+func (t *ImmutableTree) GetIndexRangeWithProof(start, end int64) (keys, values [][]byte, proof *RangeProof, err error) {
+	if start < 0 || start >= end {
+		return nil, nil, nil, errors.Wrapf(ErrInvalidInputs, "invalid index range [%v, %v)", start, end)
+	}
+	if start >= t.Size() {
+		return nil, nil, nil, errors.Wrapf(ErrInvalidInputs, "index %v out of range", start)
+	}
+	if end > t.Size() {
+		end = t.Size()
+	}
+	startKey, _ := t.GetByIndex(start)
+	// The limit includes the leaf following the range, which is not returned as a key.
+	proof, keys, values, err = t.getRangeProof(startKey, nil, int(end-start)+1)
+	if err != nil {
+		return nil, nil, nil, errors.Wrap(err, "constructing range proof")
+	}
+	return keys, values, proof, nil
+}
+
 // GetRangeWithProof gets key/value pairs within the specified range and limit.
 func (t *ImmutableTree) GetRangeWithProof(startKey []byte, endKey []byte, limit int) (keys, values [][]byte, proof *RangeProof, err error) {
 	proof, keys, values, err = t.getRangeProof(startKey, endKey, limit)
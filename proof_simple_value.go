@@ -0,0 +1,91 @@
+package iavl
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/pkg/errors"
+
+	"github.com/tendermint/iavl/proofpb"
+)
+
+const ProofOpSimpleValue = "simple:v"
+
+// SimpleValueOp takes a key and a single value as argument and
+// produces the root hash of a simple Merkle tree of key/value pairs,
+// as built by SimpleProofsFromMap().
+//
+// If the produced root hash matches the expected hash, the proof
+// is good.
+type SimpleValueOp struct {
+	// Encoded in ProofOp.Key.
+	key []byte
+
+	// To encode in ProofOp.Data.
+	Proof *SimpleProof `json:"proof"`
+}
+
+var _ ProofOperator = SimpleValueOp{}
+
+func NewSimpleValueOp(key []byte, proof *SimpleProof) SimpleValueOp {
+	return SimpleValueOp{
+		key:   key,
+		Proof: proof,
+	}
+}
+
+// SimpleValueOpDecoder decodes a SimpleValueOp from a ProofOp.
+func SimpleValueOpDecoder(pop ProofOp) (ProofOperator, error) {
+	if pop.Type != ProofOpSimpleValue {
+		return nil, errors.Errorf("unexpected ProofOp.Type; got %v, want %v", pop.Type, ProofOpSimpleValue)
+	}
+	var pbOp proofpb.SimpleValueOp
+	err := pbOp.Unmarshal(pop.Data)
+	if err != nil {
+		return nil, errors.Wrap(err, "decoding ProofOp.Data into SimpleValueOp")
+	}
+	proof, err := SimpleProofFromProto(pbOp.Proof)
+	if err != nil {
+		return nil, errors.Wrap(err, "decoding ProofOp.Data into SimpleValueOp")
+	}
+	return NewSimpleValueOp(pop.Key, proof), nil
+}
+
+func (op SimpleValueOp) ProofOp() ProofOp {
+	pbOp := proofpb.SimpleValueOp{Proof: op.Proof.ToProto()}
+	bz, err := pbOp.Marshal()
+	if err != nil {
+		panic(err)
+	}
+	return ProofOp{
+		Type: ProofOpSimpleValue,
+		Key:  op.key,
+		Data: bz,
+	}
+}
+
+func (op SimpleValueOp) String() string {
+	return fmt.Sprintf("SimpleValueOp{%v}", op.GetKey())
+}
+
+func (op SimpleValueOp) Run(args [][]byte) ([][]byte, error) {
+	if len(args) != 1 {
+		return nil, errors.Errorf("expected 1 arg, got %v", len(args))
+	}
+	if op.Proof == nil {
+		return nil, errors.Wrap(ErrInvalidProof, "proof is nil")
+	}
+	leafHash := simpleLeafHash(simpleKVLeaf(op.key, args[0]))
+	if !bytes.Equal(leafHash, op.Proof.LeafHash) {
+		return nil, errors.Wrapf(ErrInvalidProof, "leaf hash mismatch: want %X got %X", op.Proof.LeafHash, leafHash)
+	}
+	rootHash := op.Proof.ComputeRootHash()
+	if rootHash == nil {
+		return nil, errors.Wrap(ErrInvalidProof, "malformed simple proof")
+	}
+	return [][]byte{rootHash}, nil
+}
+
+func (op SimpleValueOp) GetKey() []byte {
+	return op.key
+}
@@ -3,12 +3,12 @@ package iavl
 
 import (
 	"bytes"
+	"fmt"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
-	amino "github.com/tendermint/go-amino"
 	cmn "github.com/tendermint/iavl/common"
 )
 
@@ -205,27 +205,96 @@ func TestTreeKeyInRangeProofs(t *testing.T) {
 	}
 }
 
+func TestTreeGetByIndexWithProof(t *testing.T) {
+	tree, err := getTestTree(0)
+	require.NoError(t, err)
+	for i := 0; i < 100; i++ {
+		tree.Set([]byte(fmt.Sprintf("key-%03d", i)), []byte(fmt.Sprintf("value-%d", i)))
+	}
+	_, _, err = tree.SaveVersion()
+	require.NoError(t, err)
+	root := tree.Hash()
+
+	for _, index := range []int64{0, 1, 37, 63, 98, 99} {
+		key, value, proof, err := tree.GetByIndexWithProof(index)
+		require.NoError(t, err)
+		require.Equal(t, []byte(fmt.Sprintf("key-%03d", index)), key)
+		require.NoError(t, proof.Verify(root))
+		require.NoError(t, proof.VerifyItemAtIndex(index, key, value), "index %v", index)
+		require.Error(t, proof.VerifyItemAtIndex(index+1, key, value), "index %v", index)
+		require.Error(t, proof.VerifyItemAtIndex(index, key, []byte("foo")), "index %v", index)
+	}
+
+	_, _, _, err = tree.GetByIndexWithProof(100)
+	require.Error(t, err)
+	_, _, _, err = tree.GetByIndexWithProof(-1)
+	require.Error(t, err)
+}
+
+func TestTreeGetIndexRangeWithProof(t *testing.T) {
+	tree, err := getTestTree(0)
+	require.NoError(t, err)
+	for i := 0; i < 100; i++ {
+		tree.Set([]byte(fmt.Sprintf("key-%03d", i)), []byte(fmt.Sprintf("value-%d", i)))
+	}
+	_, _, err = tree.SaveVersion()
+	require.NoError(t, err)
+	root := tree.Hash()
+
+	testcases := []struct {
+		start, end int64
+		count      int
+	}{
+		{0, 1, 1},
+		{0, 100, 100},
+		{10, 20, 10},
+		{63, 64, 1},
+		{90, 200, 10},
+	}
+	for _, tc := range testcases {
+		keys, values, proof, err := tree.GetIndexRangeWithProof(tc.start, tc.end)
+		require.NoError(t, err)
+		require.Len(t, keys, tc.count)
+		require.Equal(t, []byte(fmt.Sprintf("key-%03d", tc.start)), keys[0])
+		require.NoError(t, proof.Verify(root))
+		require.NoError(t, proof.VerifyIndexRange(tc.start, keys, values), "range %v-%v", tc.start, tc.end)
+		require.Error(t, proof.VerifyIndexRange(tc.start+1, keys, values), "range %v-%v", tc.start, tc.end)
+		for i, key := range keys {
+			require.NoError(t, proof.VerifyItemAtIndex(tc.start+int64(i), key, values[i]))
+		}
+	}
+
+	// Skipping leaves in the middle of the range must be detected.
+	keys, values, proof, err := tree.GetIndexRangeWithProof(10, 20)
+	require.NoError(t, err)
+	require.NoError(t, proof.Verify(root))
+	require.Error(t, proof.VerifyIndexRange(10, append(keys[:5:5], keys[6:]...), append(values[:5:5], values[6:]...)))
+
+	_, _, _, err = tree.GetIndexRangeWithProof(5, 5)
+	require.Error(t, err)
+	_, _, _, err = tree.GetIndexRangeWithProof(100, 101)
+	require.Error(t, err)
+}
+
 func verifyProof(t *testing.T, proof *RangeProof, root []byte) {
 	// Proof must verify.
 	require.NoError(t, proof.Verify(root))
 
 	// Write/Read then verify.
-	cdc := amino.NewCodec()
-	proofBytes := cdc.MustMarshalBinaryLengthPrefixed(proof)
-	var proof2 = new(RangeProof)
-	err := cdc.UnmarshalBinaryLengthPrefixed(proofBytes, proof2)
+	proofBytes := marshalAminoRangeProofOp(proof)
+	proof2, err := unmarshalAminoRangeProofOp(proofBytes)
 	require.Nil(t, err, "Failed to read KeyExistsProof from bytes: %v", err)
+	require.NoError(t, proof2.Verify(root))
 
 	// Random mutations must not verify
 	for i := 0; i < 1e4; i++ {
 		badProofBytes := cmn.MutateByteSlice(proofBytes)
-		var badProof = new(RangeProof)
-		err := cdc.UnmarshalBinaryLengthPrefixed(badProofBytes, badProof)
+		badProof, err := unmarshalAminoRangeProofOp(badProofBytes)
 		if err != nil {
 			continue // couldn't even decode.
 		}
 		// re-encode to make sure it's actually different.
-		badProofBytes2 := cdc.MustMarshalBinaryLengthPrefixed(badProof)
+		badProofBytes2 := marshalAminoRangeProofOp(badProof)
 		if bytes.Equal(proofBytes, badProofBytes2) {
 			continue // didn't mutate successfully.
 		}
@@ -11,5 +11,5 @@ paths=source_relative:. \
   $(find "${dir}" -maxdepth 1 -name '*.proto')
 done
 
-mv ./iavl/* .
+cp -r ./iavl/. .
 rm -rf ./iavl
@@ -0,0 +1,459 @@
+package iavl
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"hash"
+	"hash/crc32"
+	"io"
+
+	"github.com/pkg/errors"
+
+	"github.com/tendermint/iavl/internal/encoding"
+)
+
+// The snapshot format is a framed, versioned binary encoding of an export, as written by
+// WriteSnapshot() and read by ReadSnapshot(). All integers are varints, and byte slices are
+// prefixed by their length as an unsigned varint.
+//
+//	header:   magic "IAVLSNAP", format (uvarint), tree version (uvarint), root hash (bytes),
+//	          node count (uvarint), CRC-32C of the header (4 bytes, big-endian)
+//	records:  a type byte, followed by:
+//	          node:     payload length (uvarint), height (varint), version (varint), key (bytes),
+//	                    and value (bytes) for leaf nodes
+//	          checksum: CRC-32C (4 bytes, big-endian) of all bytes since the previous checksum or
+//	                    the header, including this record's type byte
+//	          end:      nothing, must directly follow a checksum
+//
+// Nodes are in the export order, i.e. depth-first post-order. A checksum is written every
+// snapshotChecksumInterval nodes, and before the end record.
+const (
+	snapshotMagic            = "IAVLSNAP"
+	snapshotFormat           = 1
+	snapshotChecksumInterval = 1000
+	// snapshotMaxRecordSize is the maximum size of a node record, to avoid allocating
+	// excessive memory for corrupt record lengths.
+	snapshotMaxRecordSize = 256 << 20
+
+	snapshotRecordNode     byte = 1
+	snapshotRecordChecksum byte = 2
+	snapshotRecordEnd      byte = 3
+)
+
+// ErrInvalidSnapshot is returned when a snapshot is truncated, corrupt, or does not match the
+// tree it is imported into.
+var ErrInvalidSnapshot = errors.New("invalid snapshot")
+
+var snapshotCRCTable = crc32.MakeTable(crc32.Castagnoli)
+
+// SnapshotHeader is the header of a snapshot.
+type SnapshotHeader struct {
+	Format    uint64
+	Version   int64
+	RootHash  []byte
+	NodeCount uint64
+}
+
+// WriteSnapshot writes all remaining nodes of the exporter to w in the snapshot format. The
+// exporter must not have been read from, and may be a range exporter. It does not close the
+// exporter.
+func WriteSnapshot(w io.Writer, exporter *Exporter) error {
+	if exporter == nil || exporter.tree == nil {
+		return errors.New("exporter is closed")
+	}
+	header := SnapshotHeader{
+		Format:   snapshotFormat,
+		Version:  exporter.tree.version,
+		RootHash: exporter.rootHash,
+	}
+	if size := exporter.size; size > 0 {
+		// IAVL trees are full binary trees, with one inner node less than there are leaves.
+		header.NodeCount = uint64(2*size - 1)
+	}
+
+	bw := bufio.NewWriter(w)
+	cw := &snapshotCRCWriter{w: bw, crc: crc32.New(snapshotCRCTable)}
+	if err := writeSnapshotHeader(cw, header); err != nil {
+		return err
+	}
+
+	var count, sinceChecksum uint64
+	var buf bytes.Buffer
+	for {
+		node, err := exporter.Next()
+		if err == ExportDone {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		count++
+		if count > header.NodeCount {
+			return errors.Errorf("exported more than the expected %v nodes", header.NodeCount)
+		}
+
+		buf.Reset()
+		if err := encodeSnapshotNode(&buf, node); err != nil {
+			return err
+		}
+		if err := cw.WriteByte(snapshotRecordNode); err != nil {
+			return err
+		}
+		if err := encoding.EncodeByteSlice(cw, buf.Bytes()); err != nil {
+			return err
+		}
+
+		sinceChecksum++
+		if sinceChecksum >= snapshotChecksumInterval {
+			if err := cw.writeChecksum(); err != nil {
+				return err
+			}
+			sinceChecksum = 0
+		}
+	}
+	if count != header.NodeCount {
+		return errors.Errorf("exported %v nodes, expected %v", count, header.NodeCount)
+	}
+	if err := cw.writeChecksum(); err != nil {
+		return err
+	}
+	if err := cw.WriteByte(snapshotRecordEnd); err != nil {
+		return err
+	}
+	return bw.Flush()
+}
+
+// ReadSnapshot reads a snapshot written by WriteSnapshot() from r, and adds its nodes to the
+// importer. The importer must be for the snapshot version. Nodes are only added once the checksum
+// covering them has been verified, and the root hash is verified before committing the import.
+// Any truncation or corruption of the snapshot returns an error wrapping ErrInvalidSnapshot.
+//
+// On errors nothing is committed: any nodes already flushed to the database are removed again,
+// and the importer is closed, so that the import can be retried.
+func ReadSnapshot(r io.Reader, importer *Importer) error {
+	if importer == nil || importer.tree == nil {
+		return ErrNoImport
+	}
+	cr := &snapshotCRCReader{r: bufio.NewReader(r), crc: crc32.New(snapshotCRCTable)}
+	header, err := readSnapshotHeader(cr)
+	if err == nil && header.Version != importer.version {
+		err = errors.Wrapf(ErrInvalidSnapshot, "snapshot version %v does not match import version %v",
+			header.Version, importer.version)
+	}
+	if err == nil {
+		err = readSnapshotNodes(cr, header, importer)
+	}
+	if err != nil {
+		return abortSnapshotImport(importer, err)
+	}
+	return nil
+}
+
+// ImportSnapshot imports a snapshot written by WriteSnapshot() from r into the empty tree, at the
+// snapshot version. See ReadSnapshot().
+func (tree *MutableTree) ImportSnapshot(r io.Reader) (*SnapshotHeader, error) {
+	cr := &snapshotCRCReader{r: bufio.NewReader(r), crc: crc32.New(snapshotCRCTable)}
+	header, err := readSnapshotHeader(cr)
+	if err != nil {
+		return nil, err
+	}
+	importer, err := tree.Import(header.Version)
+	if err != nil {
+		return nil, err
+	}
+	defer importer.Close()
+	if err := readSnapshotNodes(cr, header, importer); err != nil {
+		return nil, abortSnapshotImport(importer, err)
+	}
+	return header, nil
+}
+
+// abortSnapshotImport removes any nodes flushed by the importer before the error, and closes it.
+func abortSnapshotImport(importer *Importer, err error) error {
+	if purgeErr := importer.purge(); purgeErr != nil {
+		return errors.Wrapf(purgeErr, "removing imported nodes after error: %v", err)
+	}
+	return err
+}
+
+func writeSnapshotHeader(cw *snapshotCRCWriter, header SnapshotHeader) error {
+	if _, err := io.WriteString(cw, snapshotMagic); err != nil {
+		return err
+	}
+	if err := encoding.EncodeUvarint(cw, header.Format); err != nil {
+		return err
+	}
+	if err := encoding.EncodeUvarint(cw, uint64(header.Version)); err != nil {
+		return err
+	}
+	if err := encoding.EncodeByteSlice(cw, header.RootHash); err != nil {
+		return err
+	}
+	if err := encoding.EncodeUvarint(cw, header.NodeCount); err != nil {
+		return err
+	}
+	return cw.writeCRC()
+}
+
+func readSnapshotHeader(cr *snapshotCRCReader) (*SnapshotHeader, error) {
+	magic := make([]byte, len(snapshotMagic))
+	if _, err := io.ReadFull(cr, magic); err != nil {
+		return nil, snapshotReadError(err, "reading magic")
+	}
+	if string(magic) != snapshotMagic {
+		return nil, errors.Wrap(ErrInvalidSnapshot, "not a snapshot")
+	}
+	format, err := binary.ReadUvarint(cr)
+	if err != nil {
+		return nil, snapshotReadError(err, "reading format")
+	}
+	if format != snapshotFormat {
+		return nil, errors.Wrapf(ErrInvalidSnapshot, "unsupported format %v", format)
+	}
+	version, err := binary.ReadUvarint(cr)
+	if err != nil {
+		return nil, snapshotReadError(err, "reading version")
+	}
+	if int64(version) < 0 {
+		return nil, errors.Wrapf(ErrInvalidSnapshot, "invalid version %v", version)
+	}
+	rootHash, err := cr.readBytes()
+	if err != nil {
+		return nil, snapshotReadError(err, "reading root hash")
+	}
+	nodeCount, err := binary.ReadUvarint(cr)
+	if err != nil {
+		return nil, snapshotReadError(err, "reading node count")
+	}
+	if err := cr.verifyCRC(); err != nil {
+		return nil, errors.Wrap(err, "header")
+	}
+	if len(rootHash) == 0 {
+		rootHash = nil
+	}
+	if (rootHash == nil) != (nodeCount == 0) {
+		return nil, errors.Wrap(ErrInvalidSnapshot, "root hash and node count mismatch")
+	}
+	return &SnapshotHeader{
+		Format:    format,
+		Version:   int64(version),
+		RootHash:  rootHash,
+		NodeCount: nodeCount,
+	}, nil
+}
+
+// readSnapshotNodes reads the node records of a snapshot, adds them to the importer once their
+// checksum is verified, and commits the import.
+func readSnapshotNodes(cr *snapshotCRCReader, header *SnapshotHeader, importer *Importer) error {
+	var count uint64
+	pending := make([]*ExportNode, 0, snapshotChecksumInterval)
+	for {
+		typ, err := cr.ReadByte()
+		if err != nil {
+			return snapshotReadError(err, "reading record type")
+		}
+		switch typ {
+		case snapshotRecordNode:
+			bz, err := cr.readBytes()
+			if err != nil {
+				return snapshotReadError(err, "reading node")
+			}
+			node, err := decodeSnapshotNode(bz)
+			if err != nil {
+				return errors.Wrapf(ErrInvalidSnapshot, "decoding node %v: %v", count, err)
+			}
+			count++
+			if count > header.NodeCount {
+				return errors.Wrapf(ErrInvalidSnapshot, "more than the expected %v nodes", header.NodeCount)
+			}
+			if len(pending) >= snapshotChecksumInterval {
+				return errors.Wrapf(ErrInvalidSnapshot, "more than %v nodes without a checksum",
+					snapshotChecksumInterval)
+			}
+			pending = append(pending, node)
+
+		case snapshotRecordChecksum:
+			if err := cr.verifyCRC(); err != nil {
+				return errors.Wrapf(err, "after node %v", count)
+			}
+			for _, node := range pending {
+				if err := importer.Add(node); err != nil {
+					return err
+				}
+			}
+			pending = pending[:0]
+
+		case snapshotRecordEnd:
+			if len(pending) > 0 {
+				return errors.Wrap(ErrInvalidSnapshot, "missing checksum before end")
+			}
+			if count != header.NodeCount {
+				return errors.Wrapf(ErrInvalidSnapshot, "found %v nodes, expected %v", count, header.NodeCount)
+			}
+			if err := verifyImportRoot(importer, header.RootHash); err != nil {
+				return err
+			}
+			return importer.Commit()
+
+		default:
+			return errors.Wrapf(ErrInvalidSnapshot, "unknown record type %v", typ)
+		}
+	}
+}
+
+// verifyImportRoot verifies that the nodes added to the importer form a tree with the given
+// root hash, before it is committed.
+func verifyImportRoot(importer *Importer, rootHash []byte) error {
+	var hash []byte
+	switch len(importer.stack) {
+	case 0:
+	case 1:
+		hash = importer.stack[0].hash
+	default:
+		return errors.Wrapf(ErrInvalidSnapshot, "nodes do not form a tree, found %v roots", len(importer.stack))
+	}
+	if !bytes.Equal(hash, rootHash) {
+		return errors.Wrapf(ErrInvalidSnapshot, "root hash %X does not match snapshot root hash %X", hash, rootHash)
+	}
+	return nil
+}
+
+func encodeSnapshotNode(w io.Writer, node *ExportNode) error {
+	if err := encoding.EncodeVarint(w, int64(node.Height)); err != nil {
+		return err
+	}
+	if err := encoding.EncodeVarint(w, node.Version); err != nil {
+		return err
+	}
+	if err := encoding.EncodeByteSlice(w, node.Key); err != nil {
+		return err
+	}
+	if node.Height == 0 {
+		return encoding.EncodeByteSlice(w, node.Value)
+	}
+	return nil
+}
+
+func decodeSnapshotNode(bz []byte) (*ExportNode, error) {
+	height, n, err := encoding.DecodeInt8(bz)
+	if err != nil {
+		return nil, errors.Wrap(err, "decoding height")
+	}
+	bz = bz[n:]
+	version, n, err := encoding.DecodeVarint(bz)
+	if err != nil {
+		return nil, errors.Wrap(err, "decoding version")
+	}
+	bz = bz[n:]
+	key, n, err := encoding.DecodeByteSlice(bz)
+	if err != nil {
+		return nil, errors.Wrap(err, "decoding key")
+	}
+	bz = bz[n:]
+	node := &ExportNode{Key: key, Version: version, Height: height}
+	if height == 0 {
+		node.Value, n, err = encoding.DecodeByteSlice(bz)
+		if err != nil {
+			return nil, errors.Wrap(err, "decoding value")
+		}
+		bz = bz[n:]
+	}
+	if len(bz) > 0 {
+		return nil, errors.Errorf("%v trailing bytes", len(bz))
+	}
+	return node, nil
+}
+
+// snapshotReadError converts read errors, turning EOF into a truncation error.
+func snapshotReadError(err error, msg string) error {
+	if err == io.EOF || err == io.ErrUnexpectedEOF {
+		return errors.Wrapf(ErrInvalidSnapshot, "%v: unexpected end of snapshot", msg)
+	}
+	return errors.Wrap(err, msg)
+}
+
+// snapshotCRCWriter is a writer which computes the checksum of all bytes written since the last
+// checksum.
+type snapshotCRCWriter struct {
+	w   *bufio.Writer
+	crc hash.Hash32
+}
+
+func (cw *snapshotCRCWriter) Write(p []byte) (int, error) {
+	cw.crc.Write(p) // never fails
+	return cw.w.Write(p)
+}
+
+func (cw *snapshotCRCWriter) WriteByte(b byte) error {
+	_, err := cw.Write([]byte{b})
+	return err
+}
+
+// writeChecksum writes a checksum record.
+func (cw *snapshotCRCWriter) writeChecksum() error {
+	if err := cw.WriteByte(snapshotRecordChecksum); err != nil {
+		return err
+	}
+	return cw.writeCRC()
+}
+
+// writeCRC writes the current checksum, and resets it.
+func (cw *snapshotCRCWriter) writeCRC() error {
+	var crc [4]byte
+	binary.BigEndian.PutUint32(crc[:], cw.crc.Sum32())
+	cw.crc.Reset()
+	_, err := cw.w.Write(crc[:])
+	return err
+}
+
+// snapshotCRCReader is a reader which computes the checksum of all bytes read since the last
+// checksum.
+type snapshotCRCReader struct {
+	r   *bufio.Reader
+	crc hash.Hash32
+}
+
+func (cr *snapshotCRCReader) Read(p []byte) (int, error) {
+	n, err := cr.r.Read(p)
+	cr.crc.Write(p[:n]) // never fails
+	return n, err
+}
+
+func (cr *snapshotCRCReader) ReadByte() (byte, error) {
+	b, err := cr.r.ReadByte()
+	if err == nil {
+		cr.crc.Write([]byte{b}) // never fails
+	}
+	return b, err
+}
+
+// readBytes reads a length-prefixed byte slice.
+func (cr *snapshotCRCReader) readBytes() ([]byte, error) {
+	size, err := binary.ReadUvarint(cr)
+	if err != nil {
+		return nil, err
+	}
+	if size > snapshotMaxRecordSize {
+		return nil, errors.Wrapf(ErrInvalidSnapshot, "record size %v too large", size)
+	}
+	bz := make([]byte, size)
+	if _, err := io.ReadFull(cr, bz); err != nil {
+		return nil, err
+	}
+	return bz, nil
+}
+
+// verifyCRC reads a checksum and verifies it against the bytes read since the last checksum.
+func (cr *snapshotCRCReader) verifyCRC() error {
+	expect := cr.crc.Sum32()
+	cr.crc.Reset()
+	var crc [4]byte
+	if _, err := io.ReadFull(cr.r, crc[:]); err != nil {
+		return snapshotReadError(err, "reading checksum")
+	}
+	if binary.BigEndian.Uint32(crc[:]) != expect {
+		return errors.Wrap(ErrInvalidSnapshot, "checksum mismatch")
+	}
+	return nil
+}
@@ -0,0 +1,194 @@
+package iavl
+
+import (
+	"bufio"
+	"bytes"
+	"hash/crc32"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	db "github.com/tendermint/tm-db"
+)
+
+func writeTestSnapshot(t *testing.T, tree *ImmutableTree) []byte {
+	exporter := tree.Export()
+	defer exporter.Close()
+	var buf bytes.Buffer
+	require.NoError(t, WriteSnapshot(&buf, exporter))
+	return buf.Bytes()
+}
+
+func TestSnapshot(t *testing.T) {
+	testcases := map[string]*ImmutableTree{
+		"empty tree": NewImmutableTree(db.NewMemDB(), 0),
+		"basic tree": setupExportTreeBasic(t),
+		"sized tree": setupExportTreeSized(t, 4096),
+	}
+	for desc, tree := range testcases {
+		tree := tree
+		t.Run(desc, func(t *testing.T) {
+			snapshot := writeTestSnapshot(t, tree)
+
+			newTree, err := NewMutableTree(db.NewMemDB(), 0)
+			require.NoError(t, err)
+			header, err := newTree.ImportSnapshot(bytes.NewReader(snapshot))
+			require.NoError(t, err)
+			require.Equal(t, tree.Version(), header.Version)
+			require.Equal(t, tree.Hash(), header.RootHash)
+			require.Equal(t, tree.Hash(), newTree.Hash())
+			require.Equal(t, tree.Version(), newTree.Version())
+			require.Equal(t, tree.Size(), newTree.Size())
+
+			// ReadSnapshot requires an importer at the snapshot version.
+			newTree, err = NewMutableTree(db.NewMemDB(), 0)
+			require.NoError(t, err)
+			importer, err := newTree.Import(tree.Version() + 1)
+			require.NoError(t, err)
+			err = ReadSnapshot(bytes.NewReader(snapshot), importer)
+			require.True(t, errors.Is(err, ErrInvalidSnapshot), "unexpected error %v", err)
+			importer.Close()
+
+			importer, err = newTree.Import(tree.Version())
+			require.NoError(t, err)
+			require.NoError(t, ReadSnapshot(bytes.NewReader(snapshot), importer))
+			require.Equal(t, tree.Hash(), newTree.Hash())
+		})
+	}
+}
+
+func TestSnapshot_Invalid(t *testing.T) {
+	tree := setupExportTreeBasic(t)
+	snapshot := writeTestSnapshot(t, tree)
+
+	importSnapshot := func(snapshot []byte) error {
+		newTree, err := NewMutableTree(db.NewMemDB(), 0)
+		require.NoError(t, err)
+		_, err = newTree.ImportSnapshot(bytes.NewReader(snapshot))
+		if err != nil {
+			// Nothing must be committed on errors.
+			assert.EqualValues(t, 0, newTree.Version())
+			assert.Empty(t, newTree.AvailableVersions())
+		}
+		return err
+	}
+
+	for i := 0; i < len(snapshot); i++ {
+		err := importSnapshot(snapshot[:i])
+		require.True(t, errors.Is(err, ErrInvalidSnapshot), "truncated at %v: unexpected error %v", i, err)
+
+		corrupt := append([]byte{}, snapshot...)
+		corrupt[i] ^= 0x01
+		require.Error(t, importSnapshot(corrupt), "corrupted at %v", i)
+	}
+
+	// A snapshot whose contents do not match its root hash is rejected, even with valid checksums.
+	other := setupExportTreeSized(t, 16)
+	otherSnapshot := writeTestSnapshot(t, other)
+	header := writeTestSnapshot(t, NewImmutableTree(db.NewMemDB(), 0))
+	require.Error(t, importSnapshot(append(header[:len(header)-1], otherSnapshot[len(header)-1:]...)))
+
+	// The exporter must not have been read from.
+	exporter := tree.Export()
+	defer exporter.Close()
+	_, err := exporter.Next()
+	require.NoError(t, err)
+	require.Error(t, WriteSnapshot(&bytes.Buffer{}, exporter))
+}
+
+func TestSnapshot_ImportRemovesNodesOnError(t *testing.T) {
+	tree := setupExportTreeSized(t, 6000)
+	snapshot := writeTestSnapshot(t, tree)
+
+	// Truncating the snapshot fails the import after nodes have been flushed.
+	memDB := db.NewMemDB()
+	newTree, err := NewMutableTree(memDB, 0)
+	require.NoError(t, err)
+	before := dumpDB(t, memDB)
+	_, err = newTree.ImportSnapshot(bytes.NewReader(snapshot[:len(snapshot)-10]))
+	require.True(t, errors.Is(err, ErrInvalidSnapshot), "unexpected error %v", err)
+	require.Equal(t, before, dumpDB(t, memDB))
+
+	_, err = newTree.ImportSnapshot(bytes.NewReader(snapshot))
+	require.NoError(t, err)
+	require.Equal(t, tree.Hash(), newTree.Hash())
+}
+
+// recordingDB is a database which records the keys written through its batches.
+type recordingDB struct {
+	*db.MemDB
+	written map[string]bool
+}
+
+func (r *recordingDB) NewBatch() db.Batch {
+	return &recordingBatch{Batch: r.MemDB.NewBatch(), db: r}
+}
+
+type recordingBatch struct {
+	db.Batch
+	db   *recordingDB
+	keys [][]byte
+}
+
+func (b *recordingBatch) Set(key, value []byte) {
+	b.keys = append(b.keys, key)
+	b.Batch.Set(key, value)
+}
+
+func (b *recordingBatch) Write() error {
+	for _, key := range b.keys {
+		b.db.written[string(key)] = true
+	}
+	b.keys = nil
+	return b.Batch.Write()
+}
+
+func TestSnapshot_NodesAddedAfterChecksum(t *testing.T) {
+	tree := setupExportTreeSized(t, 6000)
+	snapshot := writeTestSnapshot(t, tree)
+
+	// Corrupt the checksum covering nodes up to the importer's batch size, which would make it
+	// flush them if they were added before the checksum is verified.
+	r := bytes.NewReader(snapshot)
+	cr := &snapshotCRCReader{r: bufio.NewReader(r), crc: crc32.New(snapshotCRCTable)}
+	_, err := readSnapshotHeader(cr)
+	require.NoError(t, err)
+	checksums := 0
+	for checksums < maxBatchSize/snapshotChecksumInterval {
+		typ, err := cr.ReadByte()
+		require.NoError(t, err)
+		switch typ {
+		case snapshotRecordNode:
+			_, err = cr.readBytes()
+		case snapshotRecordChecksum:
+			checksums++
+			if checksums < maxBatchSize/snapshotChecksumInterval {
+				err = cr.verifyCRC()
+			}
+		}
+		require.NoError(t, err)
+	}
+	corrupt := append([]byte{}, snapshot...)
+	corrupt[len(snapshot)-r.Len()-cr.r.Buffered()] ^= 0x01
+
+	for _, useReadSnapshot := range []bool{false, true} {
+		memDB := &recordingDB{MemDB: db.NewMemDB(), written: map[string]bool{}}
+		newTree, err := NewMutableTree(memDB, 0)
+		require.NoError(t, err)
+		before := dumpDB(t, memDB)
+		if useReadSnapshot {
+			importer, err := newTree.Import(tree.Version())
+			require.NoError(t, err)
+			err = ReadSnapshot(bytes.NewReader(corrupt), importer)
+			require.True(t, errors.Is(err, ErrInvalidSnapshot), "unexpected error %v", err)
+			require.Equal(t, ErrNoImport, importer.Add(&ExportNode{Key: []byte("a"), Value: []byte{1}}))
+		} else {
+			_, err = newTree.ImportSnapshot(bytes.NewReader(corrupt))
+			require.True(t, errors.Is(err, ErrInvalidSnapshot), "unexpected error %v", err)
+		}
+		require.Empty(t, memDB.written)
+		require.Equal(t, before, dumpDB(t, memDB))
+	}
+}
@@ -10,8 +10,8 @@ import (
 	mrand "math/rand"
 
 	"github.com/stretchr/testify/require"
-	amino "github.com/tendermint/go-amino"
 	cmn "github.com/tendermint/iavl/common"
+	"github.com/tendermint/iavl/internal/encoding"
 	db "github.com/tendermint/tm-db"
 )
 
@@ -21,12 +21,12 @@ func randstr(length int) string {
 
 func i2b(i int) []byte {
 	buf := new(bytes.Buffer)
-	amino.EncodeInt32(buf, int32(i))
+	encoding.EncodeInt32(buf, int32(i))
 	return buf.Bytes()
 }
 
 func b2i(bz []byte) int {
-	i, _, _ := amino.DecodeInt32(bz)
+	i, _, _ := encoding.DecodeInt32(bz)
 	return int(i)
 }
 
@@ -0,0 +1,103 @@
+package iavl
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/pkg/errors"
+)
+
+// TransitionOp is a single write of a state transition: a set of Key to Value, or a removal of
+// Key if Delete is true.
+type TransitionOp struct {
+	Key    []byte
+	Value  []byte
+	Delete bool
+}
+
+func (op TransitionOp) String() string {
+	if op.Delete {
+		return fmt.Sprintf("Remove(%X)", op.Key)
+	}
+	return fmt.Sprintf("Set(%X, %X)", op.Key, op.Value)
+}
+
+// TransitionProof proves that applying a list of TransitionOps to a tree with an old root hash
+// produces a new root hash. It contains the witness of all nodes the operations read, including
+// the ones loaded while rebalancing, so the operations can be replayed on a partial tree.
+type TransitionProof struct {
+	Witness *Witness
+}
+
+// ApplyWithTransitionProof applies the operations to the tree, and returns the proof of the
+// transition from the last saved root hash to the resulting working hash. The changes are not
+// saved, it is up to the caller to call SaveVersion().
+//
+// The tree must not have unsaved changes, and no recording may be in progress, see
+// StartRecording().
+func (tree *MutableTree) ApplyWithTransitionProof(ops []TransitionOp) (*TransitionProof, error) {
+	if err := validateTransitionOps(ops); err != nil {
+		return nil, err
+	}
+	if err := tree.StartRecording(); err != nil {
+		return nil, err
+	}
+	applyTransitionOps(tree, ops)
+	tree.WorkingHash() // Ensure all hashes are calculated.
+	witness, err := tree.StopRecording()
+	if err != nil {
+		return nil, err
+	}
+	return &TransitionProof{Witness: witness}, nil
+}
+
+// Verify verifies that applying the operations to a tree with the old root hash produces the
+// new root hash, by replaying them on the partial tree of the witness. It does not need a
+// database, and fails if the operations read a node missing from the witness.
+func (proof *TransitionProof) Verify(oldRoot, newRoot []byte, ops []TransitionOp) (err error) {
+	if proof == nil || proof.Witness == nil {
+		return errors.Wrap(ErrInvalidProof, "proof is nil")
+	}
+	if !bytes.Equal(proof.Witness.RootHash, oldRoot) {
+		return errors.Wrapf(ErrInvalidRoot, "witness root %X does not match old root %X",
+			proof.Witness.RootHash, oldRoot)
+	}
+	if err := validateTransitionOps(ops); err != nil {
+		return err
+	}
+	tree, err := NewMutableTreeFromWitness(proof.Witness)
+	if err != nil {
+		return errors.Wrap(ErrInvalidProof, err.Error())
+	}
+
+	// Loading a node which is not part of the witness panics.
+	defer func() {
+		if r := recover(); r != nil {
+			err = errors.Wrapf(ErrInvalidProof, "replaying operations: %v", r)
+		}
+	}()
+	applyTransitionOps(tree, ops)
+	if hash := tree.WorkingHash(); !bytes.Equal(hash, newRoot) {
+		return errors.Wrapf(ErrInvalidRoot, "replayed root %X does not match new root %X", hash, newRoot)
+	}
+	return nil
+}
+
+func validateTransitionOps(ops []TransitionOp) error {
+	for i, op := range ops {
+		if !op.Delete && op.Value == nil {
+			return errors.Wrapf(ErrInvalidInputs, "operation %d sets a nil value", i)
+		}
+	}
+	return nil
+}
+
+func applyTransitionOps(tree *MutableTree, ops []TransitionOp) {
+	for _, op := range ops {
+		if op.Delete {
+			tree.Remove(op.Key)
+		} else {
+			tree.Set(op.Key, op.Value)
+		}
+	}
+}
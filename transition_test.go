@@ -0,0 +1,74 @@
+package iavl
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTransitionProof(t *testing.T) {
+	tree, err := getTestTree(0)
+	require.NoError(t, err)
+	for i := 0; i < 1000; i++ {
+		tree.Set([]byte(fmt.Sprintf("key-%03d", i)), []byte(fmt.Sprintf("value-%d", i)))
+	}
+	oldRoot, _, err := tree.SaveVersion()
+	require.NoError(t, err)
+
+	// Appending and removing sequential keys forces rotations along the edges of the tree.
+	ops := []TransitionOp{
+		{Key: []byte("key-020"), Value: []byte("updated")},
+		{Key: []byte("key-500"), Delete: true},
+		{Key: []byte("missing"), Delete: true},
+	}
+	for i := 0; i < 20; i++ {
+		ops = append(ops, TransitionOp{Key: []byte(fmt.Sprintf("key-%03d", i)), Delete: true})
+		ops = append(ops, TransitionOp{Key: []byte(fmt.Sprintf("new-%03d", i)), Value: []byte("new")})
+	}
+
+	proof, err := tree.ApplyWithTransitionProof(ops)
+	require.NoError(t, err)
+	newRoot, _, err := tree.SaveVersion()
+	require.NoError(t, err)
+	assert.Less(t, len(proof.Witness.Nodes), 300, "witness should only contain the touched nodes")
+
+	require.NoError(t, proof.Verify(oldRoot, newRoot, ops))
+	assert.Error(t, proof.Verify(newRoot, newRoot, ops))
+	assert.Error(t, proof.Verify(oldRoot, oldRoot, ops))
+	assert.Error(t, proof.Verify(oldRoot, newRoot, ops[1:]))
+
+	// Operations touching nodes outside the witness can't be replayed.
+	other := append([]TransitionOp{{Key: []byte("key-999"), Value: []byte("foo")}}, ops...)
+	assert.Error(t, proof.Verify(oldRoot, newRoot, other))
+
+	// The witness nodes are verified against their hashes.
+	proof.Witness.Nodes[0] = append([]byte{}, proof.Witness.Nodes[0]...)
+	proof.Witness.Nodes[0][len(proof.Witness.Nodes[0])-1] ^= 0xff
+	assert.Error(t, proof.Verify(oldRoot, newRoot, ops))
+
+	_, err = tree.ApplyWithTransitionProof([]TransitionOp{{Key: []byte("key")}})
+	require.Error(t, err)
+}
+
+func TestTransitionProof_Empty(t *testing.T) {
+	tree, err := getTestTree(0)
+	require.NoError(t, err)
+
+	ops := []TransitionOp{
+		{Key: []byte("a"), Value: []byte("1")},
+		{Key: []byte("b"), Value: []byte("2")},
+		{Key: []byte("c"), Value: []byte("3")},
+		{Key: []byte("b"), Delete: true},
+	}
+	proof, err := tree.ApplyWithTransitionProof(ops)
+	require.NoError(t, err)
+	newRoot, _, err := tree.SaveVersion()
+	require.NoError(t, err)
+	require.Empty(t, proof.Witness.Nodes)
+
+	require.NoError(t, proof.Verify(nil, newRoot, ops))
+	assert.Error(t, proof.Verify(nil, newRoot, ops[:3]))
+	assert.Error(t, (*TransitionProof)(nil).Verify(nil, newRoot, ops))
+}
@@ -0,0 +1,176 @@
+package iavl
+
+import (
+	"bytes"
+	"sort"
+
+	"github.com/pkg/errors"
+
+	dbm "github.com/tendermint/tm-db"
+)
+
+// Witness is a partial tree containing the nodes of a tree version that were loaded during a
+// recording session, see ImmutableTree.StartRecording(). A tree created from it with
+// NewMutableTreeFromWitness() can replay the same reads and writes as the recorded session,
+// and will produce the same root hash, without access to the full database.
+type Witness struct {
+	// Version is the tree version the recording was started on.
+	Version int64
+	// RootHash is the root hash of the recorded version, or nil for an empty tree.
+	RootHash []byte
+	// Nodes are the encoded nodes that were loaded, ordered by hash.
+	Nodes [][]byte
+}
+
+// nodeRecorder keeps track of the nodes loaded by a nodeDB during a recording session.
+type nodeRecorder struct {
+	version  int64
+	rootHash []byte
+	nodes    map[string]*Node
+}
+
+// startRecording starts recording all nodes loaded via GetNode().
+func (ndb *nodeDB) startRecording(version int64, root *Node) error {
+	ndb.mtx.Lock()
+	defer ndb.mtx.Unlock()
+
+	if ndb.recorder != nil {
+		return errors.New("recording already in progress")
+	}
+	ndb.recorder = &nodeRecorder{
+		version: version,
+		nodes:   map[string]*Node{},
+	}
+	if root != nil {
+		ndb.recorder.rootHash = root.hash
+		ndb.recorder.nodes[string(root.hash)] = root
+	}
+	return nil
+}
+
+// stopRecording stops the current recording session and returns it.
+func (ndb *nodeDB) stopRecording() (*nodeRecorder, error) {
+	ndb.mtx.Lock()
+	defer ndb.mtx.Unlock()
+
+	if ndb.recorder == nil {
+		return nil, errors.New("no recording in progress")
+	}
+	recorder := ndb.recorder
+	ndb.recorder = nil
+	return recorder, nil
+}
+
+// StartRecording starts recording every node loaded from the database, until StopRecording()
+// is called. Recording happens in the node database, so loads done by other trees sharing it
+// (e.g. the MutableTree this tree was obtained from) are recorded as well.
+//
+// The tree must not have unsaved changes, and only one recording can be in progress at a time.
+func (t *ImmutableTree) StartRecording() error {
+	if t.ndb == nil {
+		return errors.New("cannot record an in-memory tree")
+	}
+	if t.root != nil && !t.root.saved {
+		return errors.New("cannot record a tree with unsaved changes")
+	}
+	return t.ndb.startRecording(t.version, t.root)
+}
+
+// StopRecording stops the recording started by StartRecording(), and returns a witness with
+// all recorded nodes belonging to the version the recording was started on. Nodes saved by
+// later versions during the session are not included, since a replay recreates them.
+func (t *ImmutableTree) StopRecording() (*Witness, error) {
+	if t.ndb == nil {
+		return nil, errors.New("cannot record an in-memory tree")
+	}
+	recorder, err := t.ndb.stopRecording()
+	if err != nil {
+		return nil, err
+	}
+
+	nodes := make([]*Node, 0, len(recorder.nodes))
+	for _, node := range recorder.nodes {
+		if node.version <= recorder.version {
+			nodes = append(nodes, node)
+		}
+	}
+	sort.Slice(nodes, func(i, j int) bool {
+		return bytes.Compare(nodes[i].hash, nodes[j].hash) < 0
+	})
+
+	witness := &Witness{
+		Version:  recorder.version,
+		RootHash: recorder.rootHash,
+		Nodes:    make([][]byte, 0, len(nodes)),
+	}
+	for _, node := range nodes {
+		var buf bytes.Buffer
+		if err := node.writeBytes(&buf); err != nil {
+			return nil, err
+		}
+		witness.Nodes = append(witness.Nodes, buf.Bytes())
+	}
+	return witness, nil
+}
+
+// NewMutableTreeFromWitness creates an in-memory MutableTree at the witness version, containing
+// only the witness nodes. All node hashes are verified against their contents, and the root
+// must be present in the witness.
+//
+// Replaying the recorded reads and writes on the tree, and saving a version, yields the same
+// root hash as the recorded session. Loading a node which is not part of the witness panics,
+// just like loading a node missing from a regular database.
+func NewMutableTreeFromWitness(w *Witness) (*MutableTree, error) {
+	if w == nil {
+		return nil, errors.New("witness cannot be nil")
+	}
+	if w.Version < 0 {
+		return nil, errors.New("witness version cannot be negative")
+	}
+	if w.Version == 0 && len(w.RootHash) > 0 {
+		return nil, errors.New("witness for version 0 cannot have a root hash")
+	}
+
+	memDB := dbm.NewMemDB()
+	hashes := make(map[string]bool, len(w.Nodes))
+	for i, bz := range w.Nodes {
+		node, err := MakeNode(bz)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid witness node %d", i)
+		}
+		if node.version > w.Version {
+			return nil, errors.Errorf("witness node %d has version %d above witness version %d",
+				i, node.version, w.Version)
+		}
+		hash := node._hash()
+		if err := memDB.Set(nodeKeyFormat.KeyBytes(hash), bz); err != nil {
+			return nil, err
+		}
+		hashes[string(hash)] = true
+	}
+
+	if w.Version > 0 {
+		if len(w.RootHash) > 0 && !hashes[string(w.RootHash)] {
+			return nil, errors.Errorf("witness root %X not found in witness nodes", w.RootHash)
+		}
+		// Empty roots are stored as empty values, just like SaveEmptyRoot().
+		rootHash := w.RootHash
+		if rootHash == nil {
+			rootHash = []byte{}
+		}
+		if err := memDB.Set(rootKeyFormat.Key(w.Version), rootHash); err != nil {
+			return nil, err
+		}
+	}
+
+	tree, err := NewMutableTree(memDB, 0)
+	if err != nil {
+		return nil, err
+	}
+	if w.Version > 0 {
+		if _, err := tree.LoadVersion(w.Version); err != nil {
+			return nil, err
+		}
+	}
+	return tree, nil
+}
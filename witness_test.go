@@ -0,0 +1,120 @@
+package iavl
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	db "github.com/tendermint/tm-db"
+)
+
+// applyWitnessOps performs a fixed set of reads and writes on a tree, returning the values read.
+func applyWitnessOps(t *testing.T, tree *MutableTree) [][]byte {
+	values := [][]byte{}
+	for _, key := range []string{"key-010", "key-500", "missing"} {
+		_, value := tree.Get([]byte(key))
+		values = append(values, value)
+	}
+	tree.Set([]byte("key-020"), []byte("updated"))
+	tree.Set([]byte("key-7500"), []byte("new"))
+	_, removed := tree.Remove([]byte("key-900"))
+	require.True(t, removed)
+	_, value := tree.Get([]byte("key-7500"))
+	values = append(values, value)
+	return values
+}
+
+func TestWitness_Replay(t *testing.T) {
+	tree, err := NewMutableTree(db.NewMemDB(), 0)
+	require.NoError(t, err)
+	for i := 0; i < 1000; i++ {
+		tree.Set([]byte(fmt.Sprintf("key-%03d", i)), []byte(fmt.Sprintf("value-%d", i)))
+	}
+	_, version, err := tree.SaveVersion()
+	require.NoError(t, err)
+
+	require.NoError(t, tree.StartRecording())
+	values := applyWitnessOps(t, tree)
+	hash, _, err := tree.SaveVersion()
+	require.NoError(t, err)
+	witness, err := tree.StopRecording()
+	require.NoError(t, err)
+
+	require.Equal(t, version, witness.Version)
+	assert.NotEmpty(t, witness.Nodes)
+	assert.Less(t, len(witness.Nodes), 100, "witness should only contain the touched nodes")
+
+	wtree, err := NewMutableTreeFromWitness(witness)
+	require.NoError(t, err)
+	require.Equal(t, version, wtree.Version())
+	require.Equal(t, witness.RootHash, wtree.Hash())
+
+	wvalues := applyWitnessOps(t, wtree)
+	require.Equal(t, values, wvalues)
+	whash, wversion, err := wtree.SaveVersion()
+	require.NoError(t, err)
+	require.Equal(t, version+1, wversion)
+	require.Equal(t, hash, whash)
+
+	// Reading an unrecorded node from the witness tree should panic.
+	require.Panics(t, func() {
+		wtree.Get([]byte("key-300"))
+	})
+}
+
+func TestWitness_Empty(t *testing.T) {
+	tree, err := NewMutableTree(db.NewMemDB(), 0)
+	require.NoError(t, err)
+
+	require.NoError(t, tree.StartRecording())
+	tree.Set([]byte("a"), []byte{1})
+	hash, _, err := tree.SaveVersion()
+	require.NoError(t, err)
+	witness, err := tree.StopRecording()
+	require.NoError(t, err)
+	require.EqualValues(t, 0, witness.Version)
+	require.Nil(t, witness.RootHash)
+	require.Empty(t, witness.Nodes)
+
+	wtree, err := NewMutableTreeFromWitness(witness)
+	require.NoError(t, err)
+	wtree.Set([]byte("a"), []byte{1})
+	whash, _, err := wtree.SaveVersion()
+	require.NoError(t, err)
+	require.Equal(t, hash, whash)
+}
+
+func TestWitness_Errors(t *testing.T) {
+	tree, err := NewMutableTree(db.NewMemDB(), 0)
+	require.NoError(t, err)
+	tree.Set([]byte("a"), []byte{1})
+	tree.Set([]byte("b"), []byte{2})
+
+	require.Error(t, tree.StartRecording(), "unsaved changes")
+	_, _, err = tree.SaveVersion()
+	require.NoError(t, err)
+
+	_, err = tree.StopRecording()
+	require.Error(t, err, "not recording")
+
+	itree, err := tree.GetImmutable(1)
+	require.NoError(t, err)
+	require.NoError(t, itree.StartRecording())
+	require.Error(t, tree.StartRecording(), "already recording")
+	_, value := itree.Get([]byte("b"))
+	require.Equal(t, []byte{2}, value)
+	witness, err := itree.StopRecording()
+	require.NoError(t, err)
+	require.Len(t, witness.Nodes, 2)
+
+	_, err = NewMutableTreeFromWitness(&Witness{Version: 1, RootHash: witness.RootHash, Nodes: witness.Nodes[:0]})
+	require.Error(t, err, "missing root")
+
+	_, err = NewMutableTreeFromWitness(&Witness{Version: 1, RootHash: witness.RootHash, Nodes: [][]byte{{0x01}}})
+	require.Error(t, err, "invalid node")
+
+	_, err = NewMutableTreeFromWitness(nil)
+	require.Error(t, err)
+}